@@ -18,6 +18,7 @@ import (
 	"github.com/studiowebux/dockward/internal/registry"
 	"github.com/studiowebux/dockward/internal/saferun"
 	"github.com/studiowebux/dockward/internal/shutdown"
+	"github.com/studiowebux/dockward/internal/state"
 	"github.com/studiowebux/dockward/internal/warden"
 	"github.com/studiowebux/dockward/internal/watcher"
 	"github.com/studiowebux/dockward/internal/wizard"
@@ -89,8 +90,19 @@ func main() {
 	dispatcher := buildDispatcher(cfg)
 
 	// Create clients.
-	dc := docker.NewClient()
-	rc := registry.NewClient(cfg.Registry.URL, cfg.Registry.Insecure)
+	dc, err := newDockerClient(cfg)
+	if err != nil {
+		logger.Fatalf("failed to create docker client: %v", err)
+	}
+	negotiated := negotiateDocker(dc)
+	registries := make(map[string]*registry.Client)
+	for _, reg := range cfg.AllRegistries() {
+		creds, err := registryCredentials(reg)
+		if err != nil {
+			logger.Fatalf("failed to load credentials for registry %q: %v", reg.Name, err)
+		}
+		registries[reg.Name] = registry.NewClient(reg.URL, reg.Insecure, creds)
+	}
 
 	// Create Docker health checker with configured intervals
 	dockerHealth := docker.NewHealthChecker(
@@ -109,10 +121,24 @@ func main() {
 		logger.Printf("audit log: %s", cfg.Audit.Path)
 	}
 
-	// Attach push client if warden_url is configured.
+	// Open persistent state store (memory-only when path is empty).
+	stateStore, err := state.Open(cfg.State.Path)
+	if err != nil {
+		logger.Fatalf("failed to open state file: %v", err)
+	}
+	if cfg.State.Path != "" {
+		logger.Printf("state file: %s", cfg.State.Path)
+	}
+
+	// Attach the push outbox if warden_url is configured.
+	var pushQueue *push.Queue
 	if cfg.Push.WardenURL != "" {
 		pc := push.New(cfg.Push.WardenURL, cfg.Push.Token, cfg.Push.MachineID)
-		auditLog.WithPush(pc)
+		pushQueue, err = push.NewQueue(pc, cfg.Push.QueuePath, cfg.Push.QueueSize)
+		if err != nil {
+			logger.Fatalf("failed to open push queue: %v", err)
+		}
+		auditLog.WithPush(pushQueue)
 		logger.Printf("push: forwarding audit entries to warden at %s (machine=%s)", cfg.Push.WardenURL, cfg.Push.MachineID)
 	}
 
@@ -129,8 +155,8 @@ func main() {
 		metrics.SetDockerHealth(healthy, consecutiveFails)
 	})
 
-	updater := watcher.NewUpdater(cfg, dc, rc, dispatcher, metrics, auditLog)
-	healer := watcher.NewHealer(cfg, dc, dispatcher, updater, metrics, auditLog)
+	updater := watcher.NewUpdater(cfg, dc, registries, dispatcher, metrics, auditLog, stateStore)
+	healer := watcher.NewHealer(cfg, dc, dispatcher, updater, metrics, auditLog, stateStore)
 	monitor := watcher.NewMonitor(cfg, dc, dispatcher, auditLog, metrics)
 
 	// Collect config warnings for health endpoint and set metric
@@ -141,6 +167,7 @@ func main() {
 	metrics.SetInvalidServicesCount(len(cfg.InvalidServices))
 
 	api := watcher.NewAPI(updater, healer, metrics, monitor, auditLog, dockerHealth, configWarnings, cfg.API.Address, *configPath)
+	api.SetPushQueue(pushQueue)
 
 	// Create shutdown coordinator and register managers
 	coordinator := shutdown.NewCoordinator()
@@ -179,7 +206,13 @@ func main() {
 	})
 
 	// Start goroutines with panic recovery.
+	if !negotiated {
+		saferun.RunWithRecovery("docker-negotiate", ctx, dc.RetryNegotiation)
+	}
 	saferun.RunWithRecovery("docker-health", ctx, dockerHealth.Start)
+	if pushQueue != nil {
+		saferun.RunWithRecovery("push-queue", ctx, pushQueue.Run)
+	}
 	saferun.RunWithRecovery("updater", ctx, updater.Run)
 	saferun.RunWithRecovery("healer", ctx, healer.Run)
 	saferun.RunWithRecovery("monitor", ctx, monitor.Run)
@@ -226,32 +259,71 @@ func runWarden(configPath string) {
 		cancel()
 	})
 
-	srv := warden.NewServer(wcfg)
+	srv, err := warden.NewServer(wcfg)
+	if err != nil {
+		logger.Fatalf("failed to start warden: %v", err)
+	}
 	srv.Run(ctx)
 }
 
-func buildDispatcher(cfg *config.Config) *notify.Dispatcher {
-	var notifiers []notify.Notifier
-
-	if cfg.Notifications.Discord != nil && cfg.Notifications.Discord.WebhookURL != "" {
-		notifiers = append(notifiers, notify.NewDiscord(cfg.Notifications.Discord.WebhookURL))
-		logger.Printf("notification: discord enabled")
+// newDockerClient creates the Engine API client for the effective docker_host
+// and exports the endpoint as DOCKER_HOST (plus DOCKER_TLS_VERIFY and
+// DOCKER_CERT_PATH with docker_tls) so compose and hook commands reach the
+// same engine. An unset docker_host stays unset in cfg.
+func newDockerClient(cfg *config.Config) (*docker.Client, error) {
+	host, tlsCfg := cfg.DockerEndpoint()
+	opts := docker.Options{Host: host}
+	if tlsCfg != nil {
+		opts.CertPath = tlsCfg.CertPath
+	}
+	dc, err := docker.NewClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	env := map[string]string{"DOCKER_HOST": host}
+	if tlsCfg != nil {
+		env["DOCKER_TLS_VERIFY"] = "1"
+		env["DOCKER_CERT_PATH"] = tlsCfg.CertPath
 	}
+	for k, v := range env {
+		if err := os.Setenv(k, v); err != nil {
+			return nil, fmt.Errorf("set %s: %w", k, err)
+		}
+	}
+	logger.Printf("docker host: %s", host)
+	return dc, nil
+}
 
-	if cfg.Notifications.SMTP != nil && cfg.Notifications.SMTP.Host != "" {
-		s := cfg.Notifications.SMTP
-		notifiers = append(notifiers, notify.NewSMTP(s.Host, s.Port, s.From, s.To, s.Username, s.Password))
-		logger.Printf("notification: smtp enabled (%s -> %s)", s.From, s.To)
+// negotiateDocker picks the Engine API version to speak. Returns false when
+// the engine is unreachable; the caller retries in the background.
+func negotiateDocker(dc *docker.Client) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := dc.NegotiateVersion(ctx); err != nil {
+		logger.Printf("docker API version negotiation failed, using v%s until the engine answers: %v", dc.APIVersion(), err)
+		return false
 	}
+	logger.Printf("docker API version: v%s", dc.APIVersion())
+	return true
+}
 
-	for _, wh := range cfg.Notifications.Webhooks {
-		w, err := notify.NewWebhook(wh.Name, wh.URL, wh.Method, wh.Headers, wh.Body)
-		if err != nil {
-			logger.Fatalf("webhook %q: %v", wh.Name, err)
-		}
-		notifiers = append(notifiers, w)
-		logger.Printf("notification: webhook %q enabled", wh.Name)
+// registryCredentials resolves the credentials for reg: a docker config.json
+// when docker_config is set, otherwise username/password (possibly empty).
+func registryCredentials(reg config.Registry) (registry.Credentials, error) {
+	if reg.DockerConfig != "" {
+		return registry.LoadDockerConfig(reg.DockerConfig, reg.URL)
 	}
+	username, password := reg.Auth()
+	return registry.Credentials{Username: username, Password: password}, nil
+}
 
+func buildDispatcher(cfg *config.Config) *notify.Dispatcher {
+	notifiers, err := notify.FromConfig(cfg.Notifications)
+	if err != nil {
+		logger.Fatalf("%v", err)
+	}
+	for _, n := range notifiers {
+		logger.Printf("notification: %s enabled", n.Name())
+	}
 	return notify.NewDispatcher(notifiers...)
 }
@@ -32,10 +32,13 @@ type Entry struct {
 	Container string    `json:"container,omitempty"`
 	Reason    string    `json:"reason,omitempty"`
 	Output    string    `json:"output,omitempty"`
+	Logs      string    `json:"logs,omitempty"` // last log lines of the failing container (log_tail)
 }
 
 // Pusher forwards audit entries to a remote warden.
-// Implemented by push.Client. Defined here to avoid an import cycle.
+// Implemented by push.Queue. Defined here to avoid an import cycle.
+// Send is called in write order while the logger is locked, so it must only
+// enqueue the entry, not wait for the warden.
 type Pusher interface {
 	Send(ctx context.Context, e Entry) error
 }
@@ -220,20 +223,17 @@ func (l *Logger) Write(e Entry) error {
 		return err
 	}
 
-	// Capture push and broadcast handlers before unlocking
-	p := l.push
+	// Queue for the warden under the lock so entries are pushed in write order.
+	if l.push != nil {
+		if sendErr := l.push.Send(context.Background(), e); sendErr != nil {
+			logger.Printf("[audit] queue push to warden failed: %v", sendErr)
+		}
+	}
+
+	// Capture broadcast handler before unlocking
 	b := l.bcast
 	l.mu.Unlock() // Manual unlock before spawning goroutines
 
-	// Fire-and-forget push to warden.
-	if p != nil {
-		saferun.Go("audit-push", func() {
-			if sendErr := p.Send(context.Background(), e); sendErr != nil {
-				logger.Printf("[audit] push to warden failed: %v", sendErr)
-			}
-		})
-	}
-
 	// Fan out to local SSE hub.
 	if b != nil {
 		saferun.Go("audit-broadcast", func() {
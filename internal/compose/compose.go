@@ -13,32 +13,68 @@ import (
 
 // Pull runs "<runtime> compose -p <project> -f <file>... pull" for the given compose files.
 // The runtime parameter should be "docker" or "podman".
+// extraEnv entries (KEY=VALUE) are applied after env_file, e.g. a tag selected by a tag policy.
 // Returns the combined stdout+stderr output and any error.
-func Pull(ctx context.Context, runtime string, composeFiles []string, project string, envFile string) (string, error) {
-	return run(ctx, runtime, composeFiles, project, envFile, "pull")
+func Pull(ctx context.Context, runtime string, composeFiles []string, project string, envFile string, extraEnv ...string) (string, error) {
+	return run(ctx, runtime, composeFiles, project, envFile, extraEnv, "pull")
 }
 
 // Up runs "<runtime> compose -p <project> -f <file>... up -d" for the given compose files.
 // The runtime parameter should be "docker" or "podman".
 // Returns the combined stdout+stderr output and any error.
-func Up(ctx context.Context, runtime string, composeFiles []string, project string, envFile string) (string, error) {
-	return run(ctx, runtime, composeFiles, project, envFile, "up", "-d")
+func Up(ctx context.Context, runtime string, composeFiles []string, project string, envFile string, extraEnv ...string) (string, error) {
+	return run(ctx, runtime, composeFiles, project, envFile, extraEnv, "up", "-d")
+}
+
+// Scale runs "<runtime> compose ... up -d --no-deps --no-recreate --scale <service>=<replicas> <service>".
+// Existing containers of the service are left untouched; compose only creates
+// containers (from the current image) to bring the service back to replicas.
+// Used by rolling deploys after removing a batch of old replicas.
+func Scale(ctx context.Context, runtime string, composeFiles []string, project string, envFile string, service string, replicas int, extraEnv ...string) (string, error) {
+	if err := ValidateServiceName(service); err != nil {
+		return "", fmt.Errorf("invalid service name: %w", err)
+	}
+	if replicas < 1 {
+		return "", fmt.Errorf("invalid replicas: must be at least 1, got %d", replicas)
+	}
+	return run(ctx, runtime, composeFiles, project, envFile, extraEnv,
+		"up", "-d", "--no-deps", "--no-recreate", "--scale", fmt.Sprintf("%s=%d", service, replicas), service)
+}
+
+// Recreate runs "<runtime> compose ... up -d --force-recreate --no-deps <service>":
+// the service's containers are replaced with new ones from the current image
+// and configuration. Used by the healer's escalation ladder.
+func Recreate(ctx context.Context, runtime string, composeFiles []string, project string, envFile string, service string, extraEnv ...string) (string, error) {
+	if err := ValidateServiceName(service); err != nil {
+		return "", fmt.Errorf("invalid service name: %w", err)
+	}
+	return run(ctx, runtime, composeFiles, project, envFile, extraEnv, "up", "-d", "--force-recreate", "--no-deps", service)
+}
+
+// RunOneOff runs "<runtime> compose ... run --rm -T <service>": a one-off
+// container of the service with its default command, removed afterwards.
+// Used by deploy hooks such as smoke tests. Fails if the command exits non-zero.
+func RunOneOff(ctx context.Context, runtime string, composeFiles []string, project string, envFile string, service string, extraEnv ...string) (string, error) {
+	if err := ValidateServiceName(service); err != nil {
+		return "", fmt.Errorf("invalid service name: %w", err)
+	}
+	return run(ctx, runtime, composeFiles, project, envFile, extraEnv, "run", "--rm", "-T", service)
 }
 
 // Restart runs "<runtime> compose down" followed by "<runtime> compose up -d".
 // Used to recover stuck containers (created/restarting state).
 // The runtime parameter should be "docker" or "podman".
 // Returns the combined output of both commands and any error.
-func Restart(ctx context.Context, runtime string, composeFiles []string, project string, envFile string) (string, error) {
-	downOut, err := run(ctx, runtime, composeFiles, project, envFile, "down")
+func Restart(ctx context.Context, runtime string, composeFiles []string, project string, envFile string, extraEnv ...string) (string, error) {
+	downOut, err := run(ctx, runtime, composeFiles, project, envFile, extraEnv, "down")
 	if err != nil {
 		return downOut, err
 	}
-	upOut, err := run(ctx, runtime, composeFiles, project, envFile, "up", "-d")
+	upOut, err := run(ctx, runtime, composeFiles, project, envFile, extraEnv, "up", "-d")
 	return downOut + upOut, err
 }
 
-func run(ctx context.Context, runtime string, composeFiles []string, project string, envFile string, args ...string) (string, error) {
+func run(ctx context.Context, runtime string, composeFiles []string, project string, envFile string, extraEnv []string, args ...string) (string, error) {
 	// Validate runtime is either docker or podman
 	if runtime != "docker" && runtime != "podman" {
 		return "", fmt.Errorf("invalid runtime: must be 'docker' or 'podman', got %q", runtime)
@@ -75,6 +111,7 @@ func run(ctx context.Context, runtime string, composeFiles []string, project str
 		}
 		cmd.Env = append(cmd.Env, extra...)
 	}
+	cmd.Env = append(cmd.Env, extraEnv...)
 
 	output, err := cmd.CombinedOutput()
 	out := strings.TrimSpace(string(output))
@@ -12,6 +12,8 @@ import (
 var (
 	// projectNameRegex enforces strict project name validation: alphanumeric + dash + underscore only, 1-64 chars
 	projectNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+	// serviceNameRegex matches compose service names: alphanumeric first, then alphanumeric, dot, dash or underscore
+	serviceNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,63}$`)
 )
 
 // validateProjectName ensures the project name contains only safe characters.
@@ -26,6 +28,15 @@ func validateProjectName(name string) error {
 	return nil
 }
 
+// ValidateServiceName ensures a compose service name contains only safe characters.
+// Config validation uses it for hooks that run a compose service.
+func ValidateServiceName(name string) error {
+	if !serviceNameRegex.MatchString(name) {
+		return fmt.Errorf("service name contains invalid characters: must match ^[a-zA-Z0-9][a-zA-Z0-9._-]{0,63}$ (got %q)", name)
+	}
+	return nil
+}
+
 // validateFilePath ensures a file path is absolute, exists, and contains no path traversal attempts.
 // Prevents directory traversal attacks via malicious compose file paths.
 func validateFilePath(path string) error {
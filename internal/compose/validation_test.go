@@ -57,6 +57,30 @@ func TestValidateProjectName(t *testing.T) {
 	}
 }
 
+func TestValidateServiceName(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr bool
+	}{
+		{"web", false},
+		{"api.v2", false},
+		{"worker_1-blue", false},
+		{"", true},
+		{"-web", true},
+		{".hidden", true},
+		{"web api", true},
+		{"web;ls", true},
+		{"web/api", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if err := ValidateServiceName(tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateServiceName(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateFilePath(t *testing.T) {
 	// Create a temporary file for testing
 	tmpDir := t.TempDir()
@@ -6,16 +6,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 
+	"github.com/studiowebux/dockward/internal/compose"
 	"github.com/studiowebux/dockward/internal/logger"
+	"github.com/studiowebux/dockward/internal/schedule"
+	"github.com/studiowebux/dockward/internal/tagpolicy"
 )
 
+// envNameRegex matches a POSIX environment variable name.
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// DefaultTagEnv is the compose variable set to the selected tag when
+// tag_policy.env is not configured.
+const DefaultTagEnv = "DOCKWARD_TAG"
+
 // projectNameRegex enforces strict project name validation: alphanumeric + dash + underscore only, 1-64 chars.
 var projectNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
 
@@ -24,6 +36,8 @@ type Push struct {
 	WardenURL string `json:"warden_url"` // empty = disabled
 	Token     string `json:"token"`      // bearer token; $ENV_VAR expansion supported
 	MachineID string `json:"machine_id"` // identifier shown in warden UI
+	QueuePath string `json:"queue_path,omitempty"` // outbox file for undelivered entries; empty = memory only
+	QueueSize int    `json:"queue_size,omitempty"` // max queued entries before the oldest are dropped (default: 10000)
 }
 
 // Config is the top-level configuration.
@@ -31,9 +45,14 @@ type Config struct {
 	mu              sync.RWMutex  `json:"-"` // guards Services during live config mutations via the API
 
 	Runtime         string        `json:"runtime"`        // Container runtime: "docker" or "podman", default: "docker"
-	Registry        Registry      `json:"registry"`
+	DockerHost      string        `json:"docker_host,omitempty"` // unix:///path or tcp://host:port; default: $DOCKER_HOST, else the runtime's local socket
+	DockerTLS       *DockerTLS    `json:"docker_tls,omitempty"`  // client certificates for a tcp:// docker_host
+	Registry        Registry      `json:"registry"`             // default registry, implicitly named "default"
+	Registries      []Registry    `json:"registries,omitempty"` // additional named registries
 	API             API           `json:"api"`
 	Audit           Audit         `json:"audit"`
+	State           State         `json:"state"`
+	DeployWindows   []DeployWindow `json:"deploy_windows,omitempty"` // global deploy windows; services without their own use these
 	Monitor         Monitor       `json:"monitor"`
 	DockerHealth    DockerHealth  `json:"docker_health"`
 	Notifications   Notifications `json:"notifications"`
@@ -42,6 +61,39 @@ type Config struct {
 	InvalidServices []ServiceValidationError `json:"-"` // Services that failed validation (not serialized)
 }
 
+// DeployWindow is a weekly time range during which image updates may be
+// deployed. Outside every window, detected updates are held as pending.
+// Times are in the host's local time zone (TZ).
+type DeployWindow struct {
+	Days  []string `json:"days,omitempty"` // "mon".."sun"; empty = every day
+	Start string   `json:"start"`          // "HH:MM"
+	End   string   `json:"end"`            // "HH:MM"; at or before start spans midnight
+}
+
+// Windows parses a list of deploy windows.
+func Windows(windows []DeployWindow) ([]schedule.Window, error) {
+	out := make([]schedule.Window, 0, len(windows))
+	for i, w := range windows {
+		parsed, err := schedule.ParseWindow(w.Days, w.Start, w.End)
+		if err != nil {
+			return nil, fmt.Errorf("deploy_windows[%d]: %w", i, err)
+		}
+		out = append(out, parsed)
+	}
+	return out, nil
+}
+
+// DeployWindowsFor returns the deploy windows that apply to svc: its own
+// when set, the global ones otherwise. Empty means deploys are always allowed.
+func (c *Config) DeployWindowsFor(svc Service) []DeployWindow {
+	if len(svc.DeployWindows) > 0 {
+		return svc.DeployWindows
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.DeployWindows
+}
+
 // ServiceValidationError records why a service was skipped during validation.
 type ServiceValidationError struct {
 	Index   int
@@ -55,16 +107,55 @@ type Audit struct {
 	Path string `json:"path"` // absolute path to JSON Lines log file; empty = disabled
 }
 
+// State defines the persistent agent state file (blocked digests, healer
+// counters, deploy history). Empty path keeps state in memory only.
+type State struct {
+	Path string `json:"path"` // absolute path to JSON state file; empty = memory-only
+}
+
 // API defines the trigger/metrics HTTP server.
 type API struct {
-	Address []string `json:"address"` // e.g. ["127.0.0.1:9090"]; default: ["127.0.0.1:9090"]
+	Address []string   `json:"address"`          // e.g. ["127.0.0.1:9090"]; default: ["127.0.0.1:9090"]
+	Tokens  []APIToken `json:"tokens,omitempty"` // bearer tokens; empty = authentication disabled
 }
 
-// Registry defines the local Docker registry connection.
+// API token roles. An operator can do everything a viewer can.
+const (
+	RoleViewer   = "viewer"   // read-only: status, metrics, audit, UI
+	RoleOperator = "operator" // viewer + trigger, redeploy, unblock, config mutation
+)
+
+// APIToken grants a role on the agent HTTP API.
+type APIToken struct {
+	Name  string `json:"name"`  // label recorded in audit entries
+	Token string `json:"token"` // bearer token; $ENV_VAR expansion supported
+	Role  string `json:"role"`  // "viewer" or "operator"
+}
+
+// Secret returns the bearer token with $ENV_VAR references expanded. Token
+// keeps the form written in the config file so Save does not persist the
+// expanded value.
+func (t APIToken) Secret() string { return os.ExpandEnv(t.Token) }
+
+// DefaultRegistryName is the implicit name of the top-level registry block.
+const DefaultRegistryName = "default"
+
+// Registry defines a Docker registry connection.
 type Registry struct {
+	Name         string `json:"name,omitempty"` // required for entries in registries; "default" for the top-level registry
 	URL          string `json:"url"`
 	PollInterval int    `json:"poll_interval"` // seconds
 	Insecure     bool   `json:"insecure"`      // skip TLS verification for self-signed certs
+	Username     string `json:"username,omitempty"`      // basic/token auth user; $ENV_VAR expansion supported
+	Password     string `json:"password,omitempty"`      // #nosec G117 -- registry credential; $ENV_VAR expansion supported
+	DockerConfig string `json:"docker_config,omitempty"` // path to a docker config.json to read credentials from (instead of username/password)
+}
+
+// Auth returns the username and password with $ENV_VAR references expanded.
+// The fields keep the form written in the config file so Save does not
+// persist the expanded values.
+func (r Registry) Auth() (username, password string) {
+	return os.ExpandEnv(r.Username), os.ExpandEnv(r.Password)
 }
 
 // Monitor controls resource stat collection (CPU, memory).
@@ -76,6 +167,15 @@ type Monitor struct {
 type DockerHealth struct {
 	CheckInterval int `json:"check_interval"` // seconds; how often to ping Docker daemon (default: 30)
 	Timeout       int `json:"timeout"`        // seconds; timeout for each ping request (default: 5)
+	// seconds; how often the healer inspects every service's containers for
+	// transitions missed by the event stream (default: 60)
+	ReconcileInterval int `json:"reconcile_interval"`
+}
+
+// DockerTLS enables TLS for a tcp:// docker_host. CertPath holds ca.pem,
+// cert.pem and key.pem, the layout docker uses for DOCKER_CERT_PATH.
+type DockerTLS struct {
+	CertPath string `json:"cert_path"`
 }
 
 // Notifications defines all notification channels.
@@ -112,21 +212,343 @@ type Webhook struct {
 // Service defines a watched Docker service.
 type Service struct {
 	Name            string   `json:"name"`
-	Images          []string `json:"images,omitempty"`        // Registry images to watch for updates
+	Images          []string `json:"images,omitempty"`        // Registry images to watch for updates; fully-qualified refs (host/name:tag) select the registry by host
+	Registry        string   `json:"registry,omitempty"`      // registry name for short image refs; default: "default"
 	Silent          bool     `json:"silent"`                  // Exclude from validation and monitoring (e.g., heal-only with no images)
 	ComposeFiles    []string `json:"compose_files,omitempty"` // Ordered list of compose files; merged left to right
 	ComposeProject  string   `json:"compose_project"`
 	ContainerName   string   `json:"container_name,omitempty"`
 	EnvFile         string   `json:"env_file,omitempty"`
 	AutoUpdate      bool     `json:"auto_update"`
+	RequireApproval bool     `json:"require_approval"` // hold detected updates until approved via POST /approve
 	AutoStart       bool     `json:"auto_start"`
 	AutoHeal        bool     `json:"auto_heal"`
 	ComposeWatch    bool     `json:"compose_watch"`    // re-deploy on compose file content change (no pull)
-	CPUThreshold    float64  `json:"cpu_threshold"`    // alert when CPU % exceeds this value; 0 = disabled
-	MemoryThreshold float64  `json:"memory_threshold"` // alert when memory % exceeds this value; 0 = disabled
+	CPUThreshold    float64  `json:"cpu_threshold"`    // warning when CPU % exceeds this value; 0 = disabled
+	MemoryThreshold float64  `json:"memory_threshold"` // warning when memory % exceeds this value; 0 = disabled
+	CPUCritical     float64  `json:"cpu_critical"`     // critical alert when CPU % exceeds this value; 0 = disabled
+	MemoryCritical  float64  `json:"memory_critical"`  // critical alert when memory % exceeds this value; 0 = disabled
+	ThresholdSamples    int     `json:"threshold_samples"`    // consecutive samples above a threshold before alerting, default 1
+	ThresholdDuration   int     `json:"threshold_duration"`   // seconds above a threshold before alerting; 0 = disabled, else either condition alerts
+	ThresholdHysteresis *float64 `json:"threshold_hysteresis,omitempty"` // points below a threshold at which its alert recovers; unset = 5
 	HealthGrace     int      `json:"health_grace"`     // seconds, default 60
 	HealCooldown    int      `json:"heal_cooldown"`    // seconds, default 300
 	HealMaxRestarts int      `json:"heal_max_restarts"` // max consecutive failed restarts before giving up, default 3
+	HealEscalation  []HealStep `json:"heal_escalation,omitempty"` // recovery actions tried in order; replaces heal_max_restarts
+	CrashLoopDeaths int      `json:"crash_loop_deaths"` // deaths within crash_loop_window that make a crash loop, default 3
+	CrashLoopWindow int      `json:"crash_loop_window"` // seconds, default 300
+	HistoryLimit    int      `json:"history_limit"`     // deployed digests retained per image for rollback, default 5
+	TagPolicy       *TagPolicy `json:"tag_policy,omitempty"` // follow the highest matching tag instead of the tag in images
+	DeployWindows   []DeployWindow `json:"deploy_windows,omitempty"` // overrides the global deploy_windows
+	Rollout         *Rollout `json:"rollout,omitempty"` // how an update replaces containers; default: whole project at once
+	Verify          string   `json:"verify,omitempty"`          // post-deploy health scope: "changed" (default), "all" or "any"
+	VerifyServices  []string `json:"verify_services,omitempty"` // compose services to verify instead; every container of each must be healthy
+	Probe           *Probe   `json:"probe,omitempty"`           // application-level health check for images without a HEALTHCHECK
+	LogTail         *LogTail `json:"log_tail,omitempty"`        // attach the failing container's last log lines to events
+	SmokeTests      []Hook   `json:"smoke_tests,omitempty"`     // run in order after a healthy deploy; a failure rolls it back
+	PreDeploy       []Hook   `json:"pre_deploy,omitempty"`      // run after pull, before containers are replaced; a failure aborts the deploy
+	PostDeploy      []Hook   `json:"post_deploy,omitempty"`     // run after a successful deploy
+	PostRollback    []Hook   `json:"post_rollback,omitempty"`   // run after a completed rollback
+	PreHeal         []Hook   `json:"pre_heal,omitempty"`        // run before the healer restarts a container
+}
+
+// Heal escalation actions, from least to most disruptive.
+const (
+	HealRestart        = "restart"         // restart the container
+	HealRecreate       = "recreate"        // compose up -d --force-recreate the container's compose service
+	HealProjectRestart = "project_restart" // compose down, then up -d, the whole project
+)
+
+// HealStep is one rung of a service's heal escalation ladder: an action tried
+// Attempts times before the healer moves on to the next step.
+type HealStep struct {
+	Action   string `json:"action"`   // restart, recreate or project_restart
+	Attempts int    `json:"attempts"` // failed attempts before escalating, default 1
+	Cooldown int    `json:"cooldown"` // seconds before the next heal attempt, default heal_cooldown
+}
+
+// HealLadder returns the service's heal escalation steps: heal_escalation, or
+// a single restart step of heal_max_restarts attempts and heal_cooldown.
+func (s *Service) HealLadder() []HealStep {
+	if len(s.HealEscalation) > 0 {
+		return s.HealEscalation
+	}
+	return []HealStep{{Action: HealRestart, Attempts: s.HealMaxRestarts, Cooldown: s.HealCooldown}}
+}
+
+// HookList is one hook list of a service, named by its config field.
+type HookList struct {
+	Field string
+	Hooks []Hook
+}
+
+// HookLists returns every hook list of the service in deploy order.
+// The slices alias the service's own.
+func (s *Service) HookLists() []HookList {
+	return []HookList{
+		{"pre_deploy", s.PreDeploy},
+		{"smoke_tests", s.SmokeTests},
+		{"post_deploy", s.PostDeploy},
+		{"post_rollback", s.PostRollback},
+		{"pre_heal", s.PreHeal},
+	}
+}
+
+// Hook is a user-defined check or task run around a deploy. Exactly one of
+// Service, Command and HTTP is set.
+type Hook struct {
+	Name    string   `json:"name,omitempty"`    // label used in logs and audit output; default: derived from the target
+	Service string   `json:"service,omitempty"` // compose service run one-off: compose run --rm <service>
+	Command []string `json:"command,omitempty"` // host command, argv form; exit 0 is success
+	HTTP    string   `json:"http,omitempty"`    // URL to GET; any 2xx is success
+	Timeout int      `json:"timeout"`           // seconds, default 60
+}
+
+// Label returns the hook name, or a description of its target.
+func (h *Hook) Label() string {
+	switch {
+	case h.Name != "":
+		return h.Name
+	case h.Service != "":
+		return "compose run " + h.Service
+	case len(h.Command) > 0:
+		return h.Command[0]
+	default:
+		return "GET " + h.HTTP
+	}
+}
+
+// Probe is an application-level health check for images that ship without a
+// Docker HEALTHCHECK. Exactly one of HTTP, TCP and Exec is set. Probe results
+// gate post-deploy verification and drive the healer like Docker health events.
+type Probe struct {
+	HTTP             string   `json:"http,omitempty"`          // URL to GET, e.g. "http://127.0.0.1:8080/healthz"
+	ExpectStatus     int      `json:"expect_status,omitempty"` // required HTTP status; default: any 2xx
+	ExpectBody       string   `json:"expect_body,omitempty"`   // substring the HTTP response body must contain
+	TCP              string   `json:"tcp,omitempty"`           // host:port that must accept a connection
+	Exec             []string `json:"exec,omitempty"`          // command run in the container via docker exec; exit 0 is healthy
+	Service          string   `json:"service,omitempty"`       // compose service whose container is probed and restarted; default: first running container
+	Interval         int      `json:"interval"`                // seconds between probes, default 10
+	Timeout          int      `json:"timeout"`                 // seconds per attempt, default 5
+	FailureThreshold int      `json:"failure_threshold"`       // consecutive failures before unhealthy, default 3
+}
+
+// LogTail attaches the last lines of the failing container's log to
+// rollback, unhealthy and died events.
+type LogTail struct {
+	Lines  int      `json:"lines"`            // default 50, max 1000
+	Redact []string `json:"redact,omitempty"` // regular expressions; matches are replaced with [REDACTED]
+}
+
+// Standalone reports whether the service is a single container without
+// compose files. Updates of standalone services replace the container
+// directly through the Engine API.
+func (s *Service) Standalone() bool {
+	return s.ContainerName != "" && s.ComposeProject == "" && len(s.ComposeFiles) == 0
+}
+
+// containerNameRe matches Docker container names.
+var containerNameRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,127}$`)
+
+// Post-deploy verification policies.
+const (
+	VerifyChanged = "changed" // every container running a changed image must be healthy
+	VerifyAll     = "all"     // every container of the compose project must be healthy
+	VerifyAny     = "any"     // at least one container of the compose project must be healthy
+)
+
+// Rollout strategies.
+const (
+	RolloutAll     = "all"     // compose up -d on the whole project
+	RolloutRolling = "rolling" // replace replicas in health-checked batches
+)
+
+// Rollout controls how an image update replaces the containers of a service.
+// The rolling strategy recreates the replicas of each compose service running
+// a changed image a batch at a time, waiting for each batch to pass its health
+// check before continuing.
+type Rollout struct {
+	Strategy     string `json:"strategy"`                // "all" (default) or "rolling"
+	BatchSize    int    `json:"batch_size,omitempty"`    // replicas replaced per step, default 1
+	BatchPercent int    `json:"batch_percent,omitempty"` // alternative to batch_size: percentage of replicas per step, rounded up
+}
+
+// validateHooks validates each hook of the list named field.
+func validateHooks(field string, hooks []Hook, svc *Service) error {
+	for i := range hooks {
+		if err := validateHook(&hooks[i], svc); err != nil {
+			return fmt.Errorf("%s[%d]: %w", field, i, err)
+		}
+	}
+	return nil
+}
+
+// validateHook checks that exactly one hook kind is set and well formed.
+// Compose hooks need the service's compose project.
+func validateHook(h *Hook, svc *Service) error {
+	kinds := 0
+	for _, set := range []bool{h.Service != "", len(h.Command) > 0, h.HTTP != ""} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("exactly one of service, command or http is required")
+	}
+	if h.Service != "" {
+		if err := compose.ValidateServiceName(h.Service); err != nil {
+			return err
+		}
+		if svc.ComposeProject == "" || len(svc.ComposeFiles) == 0 {
+			return fmt.Errorf("service requires compose_project and compose_files")
+		}
+	}
+	if len(h.Command) > 0 && h.Command[0] == "" {
+		return fmt.Errorf("command cannot start with an empty program")
+	}
+	if h.HTTP != "" {
+		u, err := url.Parse(h.HTTP)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("http must be an http(s) URL, got %q", h.HTTP)
+		}
+	}
+	if h.Timeout > 3600 {
+		return fmt.Errorf("timeout must be at most 3600 seconds, got %d", h.Timeout)
+	}
+	return nil
+}
+
+// validateProbe checks that exactly one probe kind is set and well formed.
+func validateProbe(p *Probe) error {
+	kinds := 0
+	for _, set := range []bool{p.HTTP != "", p.TCP != "", len(p.Exec) > 0} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("exactly one of http, tcp or exec is required")
+	}
+	if p.HTTP != "" {
+		u, err := url.Parse(p.HTTP)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("http must be an http(s) URL, got %q", p.HTTP)
+		}
+	}
+	if p.ExpectStatus != 0 && (p.ExpectStatus < 100 || p.ExpectStatus > 599) {
+		return fmt.Errorf("expect_status must be 100-599, got %d", p.ExpectStatus)
+	}
+	if (p.ExpectStatus != 0 || p.ExpectBody != "") && p.HTTP == "" {
+		return fmt.Errorf("expect_status and expect_body require http")
+	}
+	if p.TCP != "" {
+		if _, port, err := net.SplitHostPort(p.TCP); err != nil || port == "" {
+			return fmt.Errorf("tcp must be host:port, got %q", p.TCP)
+		}
+	}
+	if p.Timeout > p.Interval {
+		return fmt.Errorf("timeout (%ds) cannot exceed interval (%ds)", p.Timeout, p.Interval)
+	}
+	return nil
+}
+
+// validateLogTail checks the line count and redact patterns of log_tail.
+func validateLogTail(l *LogTail) error {
+	if l.Lines < 0 || l.Lines > 1000 {
+		return fmt.Errorf("lines must be 1-1000, got %d", l.Lines)
+	}
+	for _, p := range l.Redact {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("redact %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// Rolling reports whether r selects the rolling strategy. Nil-safe.
+func (r *Rollout) Rolling() bool {
+	return r != nil && r.Strategy == RolloutRolling
+}
+
+// Batch returns how many of replicas are replaced per rolling step,
+// clamped to [1, replicas].
+func (r *Rollout) Batch(replicas int) int {
+	n := r.BatchSize
+	if r.BatchPercent > 0 {
+		n = (replicas*r.BatchPercent + 99) / 100
+	}
+	return max(1, min(n, replicas))
+}
+
+// TagPolicy makes a service follow the highest registry tag matching a
+// semver constraint or a regular expression. The selected tag is passed to
+// compose as an environment variable, so the compose file must reference it
+// (image: registry/app:${DOCKWARD_TAG}). The tag in the service's single
+// images entry is used until the first policy deploy.
+type TagPolicy struct {
+	Semver  string `json:"semver,omitempty"`  // constraint, e.g. "~1.4", "^2", "1.x"
+	Pattern string `json:"pattern,omitempty"` // regular expression, e.g. "^release-\\d+$"
+	Env     string `json:"env,omitempty"`     // compose variable set to the selected tag, default DOCKWARD_TAG
+}
+
+// AllRegistries returns the default registry followed by the named registries,
+// under a read lock. The default registry's Name is always DefaultRegistryName.
+func (c *Config) AllRegistries() []Registry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.allRegistries()
+}
+
+func (c *Config) allRegistries() []Registry {
+	def := c.Registry
+	def.Name = DefaultRegistryName
+	return append([]Registry{def}, c.Registries...)
+}
+
+// ImageRegistry returns the name of the registry an image entry of svc is
+// pulled from. A fully-qualified ref (first path component contains "." or
+// ":", or is "localhost") is matched against the configured registry hosts;
+// any other ref uses svc.Registry, or the default registry when unset.
+// Returns an error when a fully-qualified host matches no configured registry.
+func (c *Config) ImageRegistry(svc Service, image string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.imageRegistry(svc, image)
+}
+
+func (c *Config) imageRegistry(svc Service, image string) (string, error) {
+	host, _ := SplitImageHost(image)
+	if host == "" {
+		if svc.Registry != "" {
+			return svc.Registry, nil
+		}
+		return DefaultRegistryName, nil
+	}
+	for _, r := range c.allRegistries() {
+		if RegistryHost(r.URL) == host {
+			return r.Name, nil
+		}
+	}
+	return "", fmt.Errorf("no registry configured for host %q", host)
+}
+
+// SplitImageHost splits a fully-qualified image ref into its registry host and
+// the remainder ("ghcr.io/org/app:1" -> "ghcr.io", "org/app:1"). host is empty
+// for short refs ("app:latest", "org/app").
+func SplitImageHost(image string) (host, rest string) {
+	first, after, ok := strings.Cut(image, "/")
+	if ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return first, after
+	}
+	return "", image
+}
+
+// RegistryHost strips the scheme and trailing slash from a registry URL
+// ("https://ghcr.io/" -> "ghcr.io").
+func RegistryHost(url string) string {
+	s := strings.TrimPrefix(url, "http://")
+	s = strings.TrimPrefix(s, "https://")
+	return strings.TrimRight(s, "/")
 }
 
 // SnapshotServices returns a copy of the services slice under a read lock.
@@ -190,9 +612,82 @@ func Load(path string) (*Config, error) {
 	cfg.Push.WardenURL = os.ExpandEnv(cfg.Push.WardenURL)
 	cfg.Push.Token = os.ExpandEnv(cfg.Push.Token)
 
+	// API tokens are expanded on use (APIToken.Secret). A token that expands
+	// to an empty string would lock everyone out, so refuse to start instead.
+	for i := range cfg.API.Tokens {
+		if cfg.API.Tokens[i].Secret() == "" {
+			return nil, fmt.Errorf("validate config: api.tokens[%d] %q: token is empty after environment expansion", i, cfg.API.Tokens[i].Name)
+		}
+	}
+
 	return cfg, nil
 }
 
+// DockerEndpoint returns the engine endpoint to connect to: docker_host and
+// docker_tls when set, else the defaults from the environment (see
+// defaultDockerHost). The defaults are resolved on every call and never stored
+// in the config, so Save does not write them to the file.
+func (c *Config) DockerEndpoint() (string, *DockerTLS) {
+	if c.DockerHost != "" {
+		return c.DockerHost, c.DockerTLS
+	}
+	host := defaultDockerHost(c.Runtime)
+	tlsCfg := c.DockerTLS
+	if tlsCfg == nil && os.Getenv("DOCKER_HOST") != "" && os.Getenv("DOCKER_TLS_VERIFY") != "" {
+		tlsCfg = &DockerTLS{CertPath: os.Getenv("DOCKER_CERT_PATH")}
+		if tlsCfg.CertPath == "" {
+			if home, err := os.UserHomeDir(); err == nil {
+				tlsCfg.CertPath = filepath.Join(home, ".docker")
+			}
+		}
+	}
+	return host, tlsCfg
+}
+
+// defaultDockerHost returns $DOCKER_HOST, else the local socket of runtime:
+// the rootless Podman socket under $XDG_RUNTIME_DIR when not running as root,
+// the system Podman socket otherwise, and the Docker socket for docker.
+func defaultDockerHost(runtime string) string {
+	if h := os.Getenv("DOCKER_HOST"); h != "" {
+		return h
+	}
+	if runtime != "podman" {
+		return "unix:///var/run/docker.sock"
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" && os.Geteuid() != 0 {
+		return "unix://" + filepath.Join(dir, "podman", "podman.sock")
+	}
+	return "unix:///run/podman/podman.sock"
+}
+
+// validateDockerHost checks the effective docker_host and docker_tls (FATAL -
+// dockward cannot reach the engine without them).
+func validateDockerHost(host string, tlsCfg *DockerTLS) error {
+	u, err := url.Parse(host)
+	if err != nil {
+		return fmt.Errorf("docker_host: %w", err)
+	}
+	switch u.Scheme {
+	case "unix":
+		if !filepath.IsAbs(u.Path) {
+			return fmt.Errorf("docker_host: unix socket path must be absolute, got %q", host)
+		}
+		if tlsCfg != nil {
+			return fmt.Errorf("docker_tls requires a tcp:// docker_host, got %q", host)
+		}
+	case "tcp":
+		if u.Hostname() == "" || u.Port() == "" {
+			return fmt.Errorf("docker_host: tcp address must be tcp://host:port, got %q", host)
+		}
+	default:
+		return fmt.Errorf("docker_host must start with unix:// or tcp://, got %q", host)
+	}
+	if tlsCfg != nil && !filepath.IsAbs(tlsCfg.CertPath) {
+		return fmt.Errorf("docker_tls.cert_path must be an absolute path, got %q", tlsCfg.CertPath)
+	}
+	return nil
+}
+
 func (c *Config) setDefaults() {
 	if c.Runtime == "" {
 		c.Runtime = "docker" // Default to docker for backward compatibility
@@ -203,19 +698,54 @@ func (c *Config) setDefaults() {
 	if c.Registry.PollInterval <= 0 {
 		c.Registry.PollInterval = 300
 	}
+	for i := range c.Registries {
+		if c.Registries[i].PollInterval <= 0 {
+			c.Registries[i].PollInterval = c.Registry.PollInterval
+		}
+	}
 	if c.Monitor.StatsInterval <= 0 {
 		c.Monitor.StatsInterval = c.Registry.PollInterval
 	}
 	if c.DockerHealth.CheckInterval <= 0 {
 		c.DockerHealth.CheckInterval = 30
 	}
+	if c.DockerHealth.ReconcileInterval <= 0 {
+		c.DockerHealth.ReconcileInterval = 60
+	}
 	if c.DockerHealth.Timeout <= 0 {
 		c.DockerHealth.Timeout = 5
 	}
+	if c.Push.QueueSize <= 0 {
+		c.Push.QueueSize = 10000
+	}
 	if len(c.API.Address) == 0 {
 		c.API.Address = []string{"127.0.0.1:9090"}
 	}
 	for i := range c.Services {
+		for _, l := range c.Services[i].HookLists() {
+			for j := range l.Hooks {
+				if l.Hooks[j].Timeout <= 0 {
+					l.Hooks[j].Timeout = 60
+				}
+			}
+		}
+		if p := c.Services[i].Probe; p != nil {
+			if p.Interval <= 0 {
+				p.Interval = 10
+			}
+			if p.Timeout <= 0 {
+				p.Timeout = 5
+			}
+			if p.FailureThreshold <= 0 {
+				p.FailureThreshold = 3
+			}
+		}
+		if l := c.Services[i].LogTail; l != nil && l.Lines <= 0 {
+			l.Lines = 50
+		}
+		if c.Services[i].Verify == "" {
+			c.Services[i].Verify = VerifyChanged
+		}
 		if c.Services[i].HealthGrace <= 0 {
 			c.Services[i].HealthGrace = 60
 		}
@@ -225,15 +755,101 @@ func (c *Config) setDefaults() {
 		if c.Services[i].HealMaxRestarts <= 0 {
 			c.Services[i].HealMaxRestarts = 3
 		}
+		for j := range c.Services[i].HealEscalation {
+			st := &c.Services[i].HealEscalation[j]
+			if st.Attempts <= 0 {
+				st.Attempts = 1
+			}
+			if st.Cooldown <= 0 {
+				st.Cooldown = c.Services[i].HealCooldown
+			}
+		}
+		if c.Services[i].CrashLoopDeaths <= 0 {
+			c.Services[i].CrashLoopDeaths = 3
+		}
+		if c.Services[i].CrashLoopWindow <= 0 {
+			c.Services[i].CrashLoopWindow = 300
+		}
+		if c.Services[i].ThresholdSamples == 0 && c.Services[i].ThresholdDuration == 0 {
+			c.Services[i].ThresholdSamples = 1
+		}
+		if c.Services[i].ThresholdHysteresis == nil {
+			hysteresis := 5.0
+			c.Services[i].ThresholdHysteresis = &hysteresis
+		}
+		if c.Services[i].HistoryLimit == 0 {
+			c.Services[i].HistoryLimit = 5
+		}
+		if tp := c.Services[i].TagPolicy; tp != nil && tp.Env == "" {
+			tp.Env = DefaultTagEnv
+		}
 	}
 }
 
+// validateHealEscalation checks the actions of heal_escalation. Compose
+// actions need the service's compose files and project.
+func validateHealEscalation(svc *Service) error {
+	for i, st := range svc.HealEscalation {
+		switch st.Action {
+		case HealRestart:
+		case HealRecreate, HealProjectRestart:
+			if svc.ComposeProject == "" || len(svc.ComposeFiles) == 0 {
+				return fmt.Errorf("heal_escalation[%d]: %s requires compose_files and compose_project", i, st.Action)
+			}
+		default:
+			return fmt.Errorf("heal_escalation[%d]: action must be %q, %q or %q, got %q", i, HealRestart, HealRecreate, HealProjectRestart, st.Action)
+		}
+		if st.Attempts < 0 || st.Cooldown < 0 {
+			return fmt.Errorf("heal_escalation[%d]: attempts and cooldown cannot be negative", i)
+		}
+	}
+	return nil
+}
+
+// validateResourceAlerts checks the critical levels and the sustain and
+// recovery settings of cpu_threshold and memory_threshold.
+func validateResourceAlerts(svc *Service) error {
+	if svc.CPUCritical < 0 || svc.CPUCritical > 100 {
+		return fmt.Errorf("cpu_critical must be between 0-100, got %.0f", svc.CPUCritical)
+	}
+	if svc.MemoryCritical < 0 || svc.MemoryCritical > 100 {
+		return fmt.Errorf("memory_critical must be between 0-100, got %.0f", svc.MemoryCritical)
+	}
+	if svc.CPUThreshold > 0 && svc.CPUCritical > 0 && svc.CPUCritical <= svc.CPUThreshold {
+		return fmt.Errorf("cpu_critical (%.0f) must be greater than cpu_threshold (%.0f)", svc.CPUCritical, svc.CPUThreshold)
+	}
+	if svc.MemoryThreshold > 0 && svc.MemoryCritical > 0 && svc.MemoryCritical <= svc.MemoryThreshold {
+		return fmt.Errorf("memory_critical (%.0f) must be greater than memory_threshold (%.0f)", svc.MemoryCritical, svc.MemoryThreshold)
+	}
+	if svc.ThresholdSamples < 0 || svc.ThresholdDuration < 0 {
+		return fmt.Errorf("threshold_samples and threshold_duration cannot be negative")
+	}
+	if svc.ThresholdHysteresis == nil {
+		return nil
+	}
+	hysteresis := *svc.ThresholdHysteresis
+	if hysteresis < 0 || hysteresis > 100 {
+		return fmt.Errorf("threshold_hysteresis must be between 0-100, got %.0f", hysteresis)
+	}
+	// An alert clears below threshold minus hysteresis; at or under zero it never would.
+	for _, th := range []float64{svc.CPUThreshold, svc.CPUCritical, svc.MemoryThreshold, svc.MemoryCritical} {
+		if th > 0 && hysteresis >= th {
+			return fmt.Errorf("threshold_hysteresis (%g) must be below every enabled threshold, got one at %g", hysteresis, th)
+		}
+	}
+	return nil
+}
+
 func (c *Config) validate() error {
 	// Validate runtime is either docker or podman (FATAL - cannot proceed without valid runtime)
 	if c.Runtime != "docker" && c.Runtime != "podman" {
 		return fmt.Errorf("runtime must be 'docker' or 'podman', got %q", c.Runtime)
 	}
 
+	if err := validateDockerHost(c.DockerEndpoint()); err != nil {
+		return err
+	}
+
 	// Collect valid services and track invalid ones (non-fatal)
 	validServices := make([]Service, 0, len(c.Services))
 	c.InvalidServices = []ServiceValidationError{}
@@ -351,12 +967,56 @@ func (c *Config) validate() error {
 				markInvalid("images is required when auto_update is true")
 				continue
 			}
-			if len(svc.ComposeFiles) == 0 {
-				markInvalid("compose_files is required when auto_update is true")
+			if svc.Standalone() {
+				if len(svc.Images) != 1 {
+					markInvalid("a container_name service without compose_files updates exactly one image")
+					continue
+				}
+				if !containerNameRe.MatchString(svc.ContainerName) {
+					markInvalid(fmt.Sprintf("container_name contains invalid characters (got %q)", svc.ContainerName))
+					continue
+				}
+				if svc.Rollout.Rolling() {
+					markInvalid("rollout.strategy rolling requires compose_files")
+					continue
+				}
+			} else {
+				if len(svc.ComposeFiles) == 0 {
+					markInvalid("compose_files (or container_name alone) is required when auto_update is true")
+					continue
+				}
+				if svc.ComposeProject == "" {
+					markInvalid("compose_project is required when auto_update is true")
+					continue
+				}
+			}
+		}
+		if svc.Registry != "" && !c.hasRegistry(svc.Registry) {
+			markInvalid(fmt.Sprintf("registry %q is not defined", svc.Registry))
+			continue
+		}
+		imagesValid := true
+		for _, img := range svc.Images {
+			if _, err := c.imageRegistry(svc, img); err != nil {
+				markInvalid(fmt.Sprintf("image %q: %v", img, err))
+				imagesValid = false
+				break
+			}
+		}
+		if !imagesValid {
+			continue
+		}
+		if tp := svc.TagPolicy; tp != nil {
+			if len(svc.Images) != 1 {
+				markInvalid("tag_policy requires exactly one entry in images")
 				continue
 			}
-			if svc.ComposeProject == "" {
-				markInvalid("compose_project is required when auto_update is true")
+			if _, err := tagpolicy.Parse(tp.Semver, tp.Pattern); err != nil {
+				markInvalid(fmt.Sprintf("tag_policy: %v", err))
+				continue
+			}
+			if !envNameRegex.MatchString(tp.Env) {
+				markInvalid(fmt.Sprintf("tag_policy.env must be a valid environment variable name, got %q", tp.Env))
 				continue
 			}
 		}
@@ -374,6 +1034,10 @@ func (c *Config) validate() error {
 			markInvalid(fmt.Sprintf("memory_threshold must be between 0-100, got %.0f", svc.MemoryThreshold))
 			continue
 		}
+		if err := validateResourceAlerts(&svc); err != nil {
+			markInvalid(err.Error())
+			continue
+		}
 
 		// Validate timing values
 		if svc.HealthGrace < 0 {
@@ -388,6 +1052,66 @@ func (c *Config) validate() error {
 			markInvalid("heal_max_restarts cannot be negative")
 			continue
 		}
+		if err := validateHealEscalation(&svc); err != nil {
+			markInvalid(err.Error())
+			continue
+		}
+		if svc.CrashLoopDeaths < 0 || svc.CrashLoopWindow < 0 {
+			markInvalid("crash_loop_deaths and crash_loop_window cannot be negative")
+			continue
+		}
+		if svc.HistoryLimit < 0 || svc.HistoryLimit > 50 {
+			markInvalid(fmt.Sprintf("history_limit must be 1-50, got %d", svc.HistoryLimit))
+			continue
+		}
+		if _, err := Windows(svc.DeployWindows); err != nil {
+			markInvalid(err.Error())
+			continue
+		}
+		if svc.Verify != VerifyChanged && svc.Verify != VerifyAll && svc.Verify != VerifyAny {
+			markInvalid(fmt.Sprintf("verify must be %q, %q or %q, got %q", VerifyChanged, VerifyAll, VerifyAny, svc.Verify))
+			continue
+		}
+		if slices.Contains(svc.VerifyServices, "") {
+			markInvalid("verify_services cannot contain an empty name")
+			continue
+		}
+		if svc.Probe != nil {
+			if err := validateProbe(svc.Probe); err != nil {
+				markInvalid("probe: " + err.Error())
+				continue
+			}
+		}
+		if svc.LogTail != nil {
+			if err := validateLogTail(svc.LogTail); err != nil {
+				markInvalid("log_tail: " + err.Error())
+				continue
+			}
+		}
+		var hookErr error
+		for _, l := range svc.HookLists() {
+			if hookErr = validateHooks(l.Field, l.Hooks, &svc); hookErr != nil {
+				break
+			}
+		}
+		if hookErr != nil {
+			markInvalid(hookErr.Error())
+			continue
+		}
+		if ro := svc.Rollout; ro != nil {
+			if ro.Strategy != RolloutAll && ro.Strategy != RolloutRolling {
+				markInvalid(fmt.Sprintf("rollout.strategy must be %q or %q, got %q", RolloutAll, RolloutRolling, ro.Strategy))
+				continue
+			}
+			if ro.BatchSize < 0 || ro.BatchPercent < 0 || ro.BatchPercent > 100 {
+				markInvalid("rollout.batch_size cannot be negative and rollout.batch_percent must be 0-100")
+				continue
+			}
+			if ro.BatchSize > 0 && ro.BatchPercent > 0 {
+				markInvalid("rollout.batch_size and rollout.batch_percent are mutually exclusive")
+				continue
+			}
+		}
 
 		// Service passed all validation checks
 		validServices = append(validServices, svc)
@@ -403,6 +1127,38 @@ func (c *Config) validate() error {
 	if c.Registry.PollInterval > 86400 {
 		return fmt.Errorf("registry.poll_interval cannot exceed 86400 seconds (24 hours), got %d", c.Registry.PollInterval)
 	}
+	if err := validateRegistryAuth("registry", c.Registry); err != nil {
+		return err
+	}
+	regNames := map[string]bool{DefaultRegistryName: true}
+	for i, r := range c.Registries {
+		field := fmt.Sprintf("registries[%d]", i)
+		if r.Name == "" {
+			return fmt.Errorf("%s: name is required", field)
+		}
+		if regNames[r.Name] {
+			return fmt.Errorf("%s: name %q is reserved or duplicate", field, r.Name)
+		}
+		regNames[r.Name] = true
+		if r.URL == "" {
+			return fmt.Errorf("%s %q: url is required", field, r.Name)
+		}
+		if r.PollInterval < 10 || r.PollInterval > 86400 {
+			return fmt.Errorf("%s %q: poll_interval must be between 10 and 86400 seconds, got %d", field, r.Name, r.PollInterval)
+		}
+		if err := validateRegistryAuth(field, r); err != nil {
+			return err
+		}
+	}
+	if c.Push.QueuePath != "" && !filepath.IsAbs(c.Push.QueuePath) {
+		return fmt.Errorf("push.queue_path must be absolute path: %q", c.Push.QueuePath)
+	}
+	if c.State.Path != "" && !filepath.IsAbs(c.State.Path) {
+		return fmt.Errorf("state.path must be absolute path: %q", c.State.Path)
+	}
+	if _, err := Windows(c.DeployWindows); err != nil {
+		return err
+	}
 	if c.Monitor.StatsInterval < 5 && c.Monitor.StatsInterval != 0 {
 		return fmt.Errorf("monitor.stats_interval must be at least 5 seconds or 0 (disabled), got %d", c.Monitor.StatsInterval)
 	}
@@ -420,6 +1176,9 @@ func (c *Config) validate() error {
 	if c.DockerHealth.Timeout > 30 {
 		return fmt.Errorf("docker_health.timeout cannot exceed 30 seconds, got %d", c.DockerHealth.Timeout)
 	}
+	if c.DockerHealth.ReconcileInterval != 0 && (c.DockerHealth.ReconcileInterval < 10 || c.DockerHealth.ReconcileInterval > 3600) {
+		return fmt.Errorf("docker_health.reconcile_interval must be 10-3600 seconds, got %d", c.DockerHealth.ReconcileInterval)
+	}
 	if c.DockerHealth.Timeout >= c.DockerHealth.CheckInterval {
 		return fmt.Errorf("docker_health.timeout (%ds) must be less than check_interval (%ds)", c.DockerHealth.Timeout, c.DockerHealth.CheckInterval)
 	}
@@ -447,5 +1206,44 @@ func (c *Config) validate() error {
 		seen[addr] = true
 	}
 
+	// Validate API tokens
+	names := make(map[string]bool, len(c.API.Tokens))
+	for i, t := range c.API.Tokens {
+		if t.Name == "" {
+			return fmt.Errorf("api.tokens[%d]: name is required", i)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("api.tokens[%d] %q is a duplicate name", i, t.Name)
+		}
+		names[t.Name] = true
+		if t.Token == "" {
+			return fmt.Errorf("api.tokens[%d] %q: token is required", i, t.Name)
+		}
+		if t.Role != RoleViewer && t.Role != RoleOperator {
+			return fmt.Errorf("api.tokens[%d] %q: role must be %q or %q, got %q", i, t.Name, RoleViewer, RoleOperator, t.Role)
+		}
+	}
+
+	return nil
+}
+
+// hasRegistry reports whether name refers to the default or a named registry.
+func (c *Config) hasRegistry(name string) bool {
+	for _, r := range c.allRegistries() {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateRegistryAuth checks the credential fields of one registry block.
+func validateRegistryAuth(field string, r Registry) error {
+	if r.DockerConfig != "" && (r.Username != "" || r.Password != "") {
+		return fmt.Errorf("%s: docker_config and username/password are mutually exclusive", field)
+	}
+	if r.DockerConfig != "" && !filepath.IsAbs(r.DockerConfig) {
+		return fmt.Errorf("%s.docker_config must be absolute path: %q", field, r.DockerConfig)
+	}
 	return nil
 }
@@ -13,6 +13,31 @@ func (c *Config) ApplyDefaults() {
 	c.setDefaults()
 }
 
+// RedactedSecret replaces secrets in configs served over the API.
+const RedactedSecret = "********"
+
+// Redacted returns a copy of the config for serving over the API, with API
+// tokens and registry passwords replaced by RedactedSecret. The caller holds
+// the read lock.
+func (c *Config) Redacted() (*Config, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("marshal config: %w", err)
+	}
+	var out Config
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("copy config: %w", err)
+	}
+	for i := range out.API.Tokens {
+		out.API.Tokens[i].Token = RedactedSecret
+	}
+	out.Registry.redact()
+	for i := range out.Registries {
+		out.Registries[i].redact()
+	}
+	return &out, nil
+}
+
 // Save writes the current config to path atomically (write to temp, then rename).
 // InvalidServices is excluded (json:"-") — only valid services are persisted.
 func (c *Config) Save(path string) error {
@@ -43,3 +68,10 @@ func (c *Config) Save(path string) error {
 	}
 	return nil
 }
+
+// redact replaces a set password with RedactedSecret.
+func (r *Registry) redact() {
+	if r.Password != "" {
+		r.Password = RedactedSecret
+	}
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -379,4 +381,495 @@ func containsMiddle(s, substr string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+
+func TestConfigValidation_APITokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		tokens  []APIToken
+		wantErr bool
+	}{
+		{"no tokens", nil, false},
+		{"viewer and operator", []APIToken{{Name: "ro", Token: "a", Role: RoleViewer}, {Name: "ops", Token: "b", Role: RoleOperator}}, false},
+		{"missing name", []APIToken{{Token: "a", Role: RoleViewer}}, true},
+		{"missing token", []APIToken{{Name: "ro", Role: RoleViewer}}, true},
+		{"unknown role", []APIToken{{Name: "ro", Token: "a", Role: "admin"}}, true},
+		{"duplicate name", []APIToken{{Name: "x", Token: "a", Role: RoleViewer}, {Name: "x", Token: "b", Role: RoleOperator}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{API: API{Tokens: tt.tokens}}
+			cfg.setDefaults()
+			err := cfg.validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigLoad_APITokenEnvExpansion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"api": {"tokens": [{"name": "ci", "token": "$DOCKWARD_TEST_TOKEN", "role": "operator"}]}}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("DOCKWARD_TEST_TOKEN", "s3cret")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.API.Tokens[0].Secret(); got != "s3cret" {
+		t.Errorf("want expanded token %q, got %q", "s3cret", got)
+	}
+
+	// Saving keeps the reference; responses hide the token.
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	saved, _ := os.ReadFile(path)
+	if !strings.Contains(string(saved), `"$DOCKWARD_TEST_TOKEN"`) || strings.Contains(string(saved), "s3cret") {
+		t.Errorf("want the $VAR reference persisted, got %s", saved)
+	}
+	redacted, err := cfg.Redacted()
+	if err != nil {
+		t.Fatalf("Redacted: %v", err)
+	}
+	if got := redacted.API.Tokens[0].Token; got != RedactedSecret {
+		t.Errorf("want redacted token, got %q", got)
+	}
+	if cfg.API.Tokens[0].Token != "$DOCKWARD_TEST_TOKEN" {
+		t.Error("Redacted must not modify the config")
+	}
+
+	t.Setenv("DOCKWARD_TEST_TOKEN", "")
+	if _, err := Load(path); err == nil {
+		t.Error("want error for token expanding to empty string, got nil")
+	}
+}
+
+func TestConfigLoad_RegistryPasswordEnvExpansion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{
+		"registry": {"url": "http://localhost:5000", "username": "bot", "password": "$DOCKWARD_TEST_PASSWORD"},
+		"registries": [{"name": "ghcr", "url": "https://ghcr.io", "username": "$DOCKWARD_TEST_USER", "password": "literal"}]
+	}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DOCKWARD_TEST_PASSWORD", "hunter2")
+	t.Setenv("DOCKWARD_TEST_USER", "alice")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if user, pass := cfg.Registry.Auth(); user != "bot" || pass != "hunter2" {
+		t.Errorf("default registry: want bot/hunter2, got %s/%s", user, pass)
+	}
+	if user, _ := cfg.Registries[0].Auth(); user != "alice" {
+		t.Errorf("named registry: want expanded user alice, got %s", user)
+	}
+
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	saved, _ := os.ReadFile(path)
+	if !strings.Contains(string(saved), `"$DOCKWARD_TEST_PASSWORD"`) || strings.Contains(string(saved), "hunter2") {
+		t.Errorf("want the $VAR reference persisted, got %s", saved)
+	}
+
+	redacted, err := cfg.Redacted()
+	if err != nil {
+		t.Fatalf("Redacted: %v", err)
+	}
+	if redacted.Registry.Password != RedactedSecret || redacted.Registries[0].Password != RedactedSecret {
+		t.Errorf("want passwords redacted, got %q and %q", redacted.Registry.Password, redacted.Registries[0].Password)
+	}
+	if redacted.Registries[0].Username != "$DOCKWARD_TEST_USER" {
+		t.Errorf("usernames are not secret, got %q", redacted.Registries[0].Username)
+	}
+}
+
+func TestConfigValidation_NamedRegistries(t *testing.T) {
+	tests := []struct {
+		name        string
+		registries  []Registry
+		svc         Service
+		wantErr     bool
+		wantInvalid bool
+	}{
+		{"valid named registry", []Registry{{Name: "ghcr", URL: "https://ghcr.io"}}, Service{Name: "a", Images: []string{"ghcr.io/org/a:1"}}, false, false},
+		{"missing name", []Registry{{URL: "https://ghcr.io"}}, Service{Name: "a"}, true, false},
+		{"reserved name", []Registry{{Name: "default", URL: "https://ghcr.io"}}, Service{Name: "a"}, true, false},
+		{"missing url", []Registry{{Name: "ghcr"}}, Service{Name: "a"}, true, false},
+		{"unknown service registry", nil, Service{Name: "a", Registry: "nope"}, false, true},
+		{"unconfigured image host", nil, Service{Name: "a", Images: []string{"quay.io/org/a:1"}}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Registries: tt.registries, Services: []Service{tt.svc}}
+			cfg.setDefaults()
+			err := cfg.validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := len(cfg.InvalidServices) > 0; got != tt.wantInvalid {
+				t.Errorf("invalid services = %v, want invalid %v", cfg.InvalidServices, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestConfigValidation_TagPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		svc         Service
+		wantInvalid bool
+	}{
+		{"semver", Service{Name: "a", Images: []string{"app:1.4.0"}, TagPolicy: &TagPolicy{Semver: "~1.4"}}, false},
+		{"pattern", Service{Name: "a", Images: []string{"app"}, TagPolicy: &TagPolicy{Pattern: `^release-\d+$`}}, false},
+		{"neither", Service{Name: "a", Images: []string{"app"}, TagPolicy: &TagPolicy{}}, true},
+		{"both", Service{Name: "a", Images: []string{"app"}, TagPolicy: &TagPolicy{Semver: "^1", Pattern: ".*"}}, true},
+		{"bad constraint", Service{Name: "a", Images: []string{"app"}, TagPolicy: &TagPolicy{Semver: "~one"}}, true},
+		{"two images", Service{Name: "a", Images: []string{"app", "worker"}, TagPolicy: &TagPolicy{Semver: "^1"}}, true},
+		{"bad env name", Service{Name: "a", Images: []string{"app"}, TagPolicy: &TagPolicy{Semver: "^1", Env: "APP-TAG"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Services: []Service{tt.svc}}
+			cfg.setDefaults()
+			if err := cfg.validate(); err != nil {
+				t.Fatalf("validate() error = %v", err)
+			}
+			if got := len(cfg.InvalidServices) > 0; got != tt.wantInvalid {
+				t.Errorf("invalid services = %v, want invalid %v", cfg.InvalidServices, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestConfigDefaults_TagPolicyEnv(t *testing.T) {
+	cfg := &Config{Services: []Service{{Name: "a", Images: []string{"app"}, TagPolicy: &TagPolicy{Semver: "^1"}}}}
+	cfg.setDefaults()
+	if got := cfg.Services[0].TagPolicy.Env; got != DefaultTagEnv {
+		t.Errorf("want env %q, got %q", DefaultTagEnv, got)
+	}
+}
+
+func TestConfigValidation_DeployWindows(t *testing.T) {
+	valid := []DeployWindow{{Days: []string{"sat", "sun"}, Start: "02:00", End: "05:00"}}
+	invalid := []DeployWindow{{Days: []string{"saturday"}, Start: "02:00", End: "05:00"}}
+
+	cfg := &Config{DeployWindows: invalid}
+	cfg.setDefaults()
+	if err := cfg.validate(); err == nil {
+		t.Error("invalid global deploy window: want fatal error, got nil")
+	}
+
+	cfg = &Config{DeployWindows: valid, Services: []Service{
+		{Name: "ok", DeployWindows: valid},
+		{Name: "bad", DeployWindows: []DeployWindow{{Start: "2am", End: "05:00"}}},
+	}}
+	cfg.setDefaults()
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	if len(cfg.InvalidServices) != 1 || cfg.InvalidServices[0].Name != "bad" {
+		t.Errorf("want only service %q invalid, got %+v", "bad", cfg.InvalidServices)
+	}
+}
+
+func TestConfigValidation_Rollout(t *testing.T) {
+	cfg := &Config{Services: []Service{
+		{Name: "rolling", Rollout: &Rollout{Strategy: RolloutRolling, BatchPercent: 25}},
+		{Name: "all", Rollout: &Rollout{Strategy: RolloutAll}},
+		{Name: "bad-strategy", Rollout: &Rollout{Strategy: "canary"}},
+		{Name: "bad-percent", Rollout: &Rollout{Strategy: RolloutRolling, BatchPercent: 150}},
+		{Name: "both", Rollout: &Rollout{Strategy: RolloutRolling, BatchSize: 2, BatchPercent: 50}},
+	}}
+	cfg.setDefaults()
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	if len(cfg.Services) != 2 {
+		t.Errorf("want 2 valid services, got %d (invalid: %+v)", len(cfg.Services), cfg.InvalidServices)
+	}
+}
+
+func TestConfigValidation_HealEscalation(t *testing.T) {
+	composeFile := filepath.Join(t.TempDir(), "compose.yml")
+	if err := os.WriteFile(composeFile, []byte("services: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ladder := []HealStep{{Action: HealRestart, Attempts: 2}, {Action: HealRecreate, Cooldown: 60}, {Action: HealProjectRestart}}
+	cfg := &Config{Services: []Service{
+		{Name: "ladder", ComposeProject: "app", ComposeFiles: []string{composeFile}, HealEscalation: ladder},
+		{Name: "default"},
+		{Name: "no-compose", HealEscalation: []HealStep{{Action: HealRecreate}}},
+		{Name: "bad-action", HealEscalation: []HealStep{{Action: "reboot"}}},
+	}}
+	cfg.setDefaults()
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	if len(cfg.Services) != 2 {
+		t.Fatalf("want 2 valid services, got %d (invalid: %+v)", len(cfg.Services), cfg.InvalidServices)
+	}
+
+	got := cfg.Services[0].HealLadder()
+	want := []HealStep{{HealRestart, 2, 300}, {HealRecreate, 1, 60}, {HealProjectRestart, 1, 300}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ladder defaults: got %+v, want %+v", got, want)
+	}
+	if got := cfg.Services[1].HealLadder(); !reflect.DeepEqual(got, []HealStep{{HealRestart, 3, 300}}) {
+		t.Errorf("default ladder: got %+v", got)
+	}
+}
+
+func TestConfigValidation_LogTail(t *testing.T) {
+	cfg := &Config{Services: []Service{
+		{Name: "default", LogTail: &LogTail{}},
+		{Name: "redact", LogTail: &LogTail{Lines: 200, Redact: []string{`(?i)password=\S+`}}},
+		{Name: "too-many", LogTail: &LogTail{Lines: 5000}},
+		{Name: "bad-regex", LogTail: &LogTail{Redact: []string{"("}}},
+	}}
+	cfg.setDefaults()
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	if len(cfg.Services) != 2 {
+		t.Fatalf("want 2 valid services, got %d (invalid: %+v)", len(cfg.Services), cfg.InvalidServices)
+	}
+	if got := cfg.Services[0].LogTail.Lines; got != 50 {
+		t.Errorf("want default 50 lines, got %d", got)
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestConfigValidation_ResourceAlerts(t *testing.T) {
+	cfg := &Config{Services: []Service{
+		{Name: "default", CPUThreshold: 80},
+		{Name: "levels", CPUThreshold: 80, CPUCritical: 95, MemoryCritical: 90, ThresholdDuration: 120},
+		{Name: "critical-below-warning", MemoryThreshold: 90, MemoryCritical: 85},
+		{Name: "bad-critical", CPUCritical: 150},
+		{Name: "negative-samples", ThresholdSamples: -1},
+		{Name: "bad-hysteresis", ThresholdHysteresis: ptr(-5.0)},
+		{Name: "no-hysteresis", CPUThreshold: 80, ThresholdHysteresis: ptr(0.0)},
+		{Name: "low-threshold-default-hysteresis", MemoryThreshold: 90, MemoryCritical: 95, CPUThreshold: 4},
+		{Name: "hysteresis-at-threshold", CPUThreshold: 80, ThresholdHysteresis: ptr(80.0)},
+	}}
+	cfg.setDefaults()
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	if len(cfg.Services) != 3 {
+		t.Fatalf("want 3 valid services, got %d (invalid: %+v)", len(cfg.Services), cfg.InvalidServices)
+	}
+	if s := cfg.Services[0]; s.ThresholdSamples != 1 || *s.ThresholdHysteresis != 5 {
+		t.Errorf("want 1 sample and 5 points hysteresis by default, got %d and %.0f", s.ThresholdSamples, *s.ThresholdHysteresis)
+	}
+	if s := cfg.Services[1]; s.ThresholdSamples != 0 {
+		t.Errorf("threshold_duration alone should not default threshold_samples, got %d", s.ThresholdSamples)
+	}
+	if s := cfg.Services[2]; *s.ThresholdHysteresis != 0 {
+		t.Errorf("want an explicit hysteresis of 0 kept, got %.0f", *s.ThresholdHysteresis)
+	}
+}
+
+func TestConfigValidation_HistoryLimit(t *testing.T) {
+	cfg := &Config{Services: []Service{
+		{Name: "default"},
+		{Name: "max", HistoryLimit: 50},
+		{Name: "negative", HistoryLimit: -1},
+		{Name: "too-many", HistoryLimit: 51},
+	}}
+	cfg.setDefaults()
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	if len(cfg.Services) != 2 || cfg.Services[0].HistoryLimit != 5 {
+		t.Fatalf("want default and max valid, got %+v (invalid: %+v)", cfg.Services, cfg.InvalidServices)
+	}
+}
+
+func TestRollout_Batch(t *testing.T) {
+	tests := []struct {
+		name     string
+		rollout  Rollout
+		replicas int
+		want     int
+	}{
+		{"default one at a time", Rollout{}, 4, 1},
+		{"batch size", Rollout{BatchSize: 2}, 5, 2},
+		{"batch size above replicas", Rollout{BatchSize: 10}, 3, 3},
+		{"percent rounds up", Rollout{BatchPercent: 25}, 6, 2},
+		{"small percent at least one", Rollout{BatchPercent: 1}, 3, 1},
+		{"full percent", Rollout{BatchPercent: 100}, 4, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rollout.Batch(tt.replicas); got != tt.want {
+				t.Errorf("want %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConfigValidation_Verify(t *testing.T) {
+	cfg := &Config{Services: []Service{
+		{Name: "default"},
+		{Name: "any", Verify: VerifyAny},
+		{Name: "named", VerifyServices: []string{"api", "worker"}},
+		{Name: "bad", Verify: "first"},
+		{Name: "empty-name", VerifyServices: []string{""}},
+	}}
+	cfg.setDefaults()
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	if len(cfg.Services) != 3 {
+		t.Fatalf("want 3 valid services, got %d (invalid: %+v)", len(cfg.Services), cfg.InvalidServices)
+	}
+	if got := cfg.Services[0].Verify; got != VerifyChanged {
+		t.Errorf("want default verify %q, got %q", VerifyChanged, got)
+	}
+}
+
+func TestConfigValidation_Probe(t *testing.T) {
+	cfg := &Config{Services: []Service{
+		{Name: "http", Probe: &Probe{HTTP: "http://127.0.0.1:8080/healthz", ExpectStatus: 204}},
+		{Name: "tcp", Probe: &Probe{TCP: "127.0.0.1:5432"}},
+		{Name: "exec", Probe: &Probe{Exec: []string{"pg_isready"}}},
+		{Name: "none", Probe: &Probe{}},
+		{Name: "two-kinds", Probe: &Probe{HTTP: "http://127.0.0.1/", TCP: "127.0.0.1:80"}},
+		{Name: "bad-url", Probe: &Probe{HTTP: "127.0.0.1:8080/healthz"}},
+		{Name: "bad-tcp", Probe: &Probe{TCP: "127.0.0.1"}},
+		{Name: "body-without-http", Probe: &Probe{TCP: "127.0.0.1:80", ExpectBody: "ok"}},
+		{Name: "slow", Probe: &Probe{TCP: "127.0.0.1:80", Interval: 5, Timeout: 10}},
+	}}
+	cfg.setDefaults()
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	if len(cfg.Services) != 3 {
+		t.Fatalf("want 3 valid services, got %d (invalid: %+v)", len(cfg.Services), cfg.InvalidServices)
+	}
+	p := cfg.Services[0].Probe
+	if p.Interval != 10 || p.Timeout != 5 || p.FailureThreshold != 3 {
+		t.Errorf("want defaults 10/5/3, got %d/%d/%d", p.Interval, p.Timeout, p.FailureThreshold)
+	}
+}
+
+func TestConfigValidation_Hooks(t *testing.T) {
+	composeFile := filepath.Join(t.TempDir(), "compose.yml")
+	if err := os.WriteFile(composeFile, []byte("services: {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{Services: []Service{
+		{Name: "compose", ComposeProject: "app", ComposeFiles: []string{composeFile}, SmokeTests: []Hook{{Service: "smoke"}}},
+		{Name: "command", SmokeTests: []Hook{{Command: []string{"/usr/local/bin/check"}}, {HTTP: "https://app.example.com/"}}},
+		{Name: "no-project", SmokeTests: []Hook{{Service: "smoke"}}},
+		{Name: "two-kinds", SmokeTests: []Hook{{Command: []string{"true"}, HTTP: "http://127.0.0.1/"}}},
+		{Name: "bad-url", SmokeTests: []Hook{{HTTP: "ftp://example.com/"}}},
+		{Name: "too-long", SmokeTests: []Hook{{Command: []string{"true"}, Timeout: 7200}}},
+		{Name: "lifecycle", PreDeploy: []Hook{{Command: []string{"/usr/local/bin/backup"}}}, PostRollback: []Hook{{HTTP: "https://hooks.example.com/rollback"}}},
+		{Name: "bad-pre-heal", PreHeal: []Hook{{}}},
+	}}
+	cfg.setDefaults()
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	if len(cfg.Services) != 3 {
+		t.Fatalf("want 3 valid services, got %d (invalid: %+v)", len(cfg.Services), cfg.InvalidServices)
+	}
+	if got := cfg.Services[1].SmokeTests[0].Timeout; got != 60 {
+		t.Errorf("want default timeout 60, got %d", got)
+	}
+	if got := cfg.Services[2].PreDeploy[0].Timeout; got != 60 {
+		t.Errorf("want default pre_deploy timeout 60, got %d", got)
+	}
+}
+
+func TestConfigValidation_Standalone(t *testing.T) {
+	cfg := &Config{Services: []Service{
+		{Name: "standalone", ContainerName: "pihole", Images: []string{"pihole/pihole:latest"}, AutoUpdate: true},
+		{Name: "two-images", ContainerName: "app", Images: []string{"app:latest", "sidecar:latest"}, AutoUpdate: true},
+		{Name: "bad-name", ContainerName: "/app", Images: []string{"app:latest"}, AutoUpdate: true},
+		{Name: "rolling", ContainerName: "app", Images: []string{"app:latest"}, AutoUpdate: true, Rollout: &Rollout{Strategy: RolloutRolling}},
+		{Name: "no-target", Images: []string{"app:latest"}, AutoUpdate: true},
+	}}
+	cfg.setDefaults()
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	if len(cfg.Services) != 1 || cfg.Services[0].Name != "standalone" {
+		t.Fatalf("want only the standalone service valid, got %+v (invalid: %+v)", cfg.Services, cfg.InvalidServices)
+	}
+	if !cfg.Services[0].Standalone() {
+		t.Error("want Standalone() for container_name without compose files")
+	}
+}
+
+func TestConfigValidation_DockerHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		tls     *DockerTLS
+		wantErr bool
+	}{
+		{host: "unix:///var/run/docker.sock"},
+		{host: "tcp://10.0.0.5:2376", tls: &DockerTLS{CertPath: "/etc/dockward/certs"}},
+		{host: "tcp://10.0.0.5:2375"},
+		{host: "/var/run/docker.sock", wantErr: true},
+		{host: "ssh://user@host", wantErr: true},
+		{host: "tcp://10.0.0.5", wantErr: true},
+		{host: "unix:///var/run/docker.sock", tls: &DockerTLS{CertPath: "/etc/dockward/certs"}, wantErr: true},
+		{host: "tcp://10.0.0.5:2376", tls: &DockerTLS{CertPath: "certs"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		cfg := &Config{DockerHost: tt.host, DockerTLS: tt.tls}
+		cfg.setDefaults()
+		if err := cfg.validate(); (err != nil) != tt.wantErr {
+			t.Errorf("validate() with docker_host=%q: error = %v, wantErr %v", tt.host, err, tt.wantErr)
+		}
+	}
+}
+
+func TestConfig_DockerEndpoint(t *testing.T) {
+	t.Setenv("DOCKER_HOST", "")
+	cfg := &Config{}
+	cfg.setDefaults()
+	if host, _ := cfg.DockerEndpoint(); host != "unix:///var/run/docker.sock" {
+		t.Errorf("docker default = %q", host)
+	}
+
+	cfg = &Config{Runtime: "podman"}
+	cfg.setDefaults()
+	if host, _ := cfg.DockerEndpoint(); !strings.HasPrefix(host, "unix://") || !strings.HasSuffix(host, "/podman/podman.sock") {
+		t.Errorf("podman default = %q", host)
+	}
+
+	t.Setenv("DOCKER_HOST", "tcp://10.0.0.5:2376")
+	t.Setenv("DOCKER_TLS_VERIFY", "1")
+	t.Setenv("DOCKER_CERT_PATH", "/etc/docker/certs")
+	cfg = &Config{}
+	cfg.setDefaults()
+	host, tlsCfg := cfg.DockerEndpoint()
+	if host != "tcp://10.0.0.5:2376" || tlsCfg == nil || tlsCfg.CertPath != "/etc/docker/certs" {
+		t.Errorf("env defaults = %q %+v", host, tlsCfg)
+	}
+	// The defaults are not stored, so Save does not freeze them into the file.
+	if cfg.DockerHost != "" || cfg.DockerTLS != nil {
+		t.Errorf("want docker_host and docker_tls left unset, got %q %+v", cfg.DockerHost, cfg.DockerTLS)
+	}
+
+	cfg = &Config{DockerHost: "unix:///run/custom.sock"}
+	if host, tlsCfg := cfg.DockerEndpoint(); host != "unix:///run/custom.sock" || tlsCfg != nil {
+		t.Errorf("configured endpoint = %q %+v, want docker_host over the environment", host, tlsCfg)
+	}
+}
@@ -1,57 +1,244 @@
 // Package docker provides a minimal Docker Engine API client
-// that communicates over the Unix socket using net/http.
+// that communicates over a Unix socket or TCP (optionally TLS) using net/http.
 package docker
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/studiowebux/dockward/internal/logger"
 )
 
 const (
-	apiVersion = "v1.45"
-	socketPath = "/var/run/docker.sock"
+	// maxAPIVersion is the newest Engine API version dockward speaks. It is
+	// used until NegotiateVersion lowers it to what the engine supports.
+	maxAPIVersion = "1.45"
+
+	// DefaultHost is the engine endpoint used when none is configured.
+	DefaultHost = "unix:///var/run/docker.sock"
 )
 
-// Client communicates with the Docker Engine API over a Unix socket.
+// Options configure how a Client reaches the engine.
+type Options struct {
+	Host     string // unix:///path or tcp://host:port; empty means DefaultHost
+	CertPath string // directory holding ca.pem, cert.pem and key.pem; enables TLS for tcp://
+}
+
+// Client communicates with the Docker Engine API over a Unix socket or TCP.
 type Client struct {
-	http *http.Client
+	http   *http.Client
+	stream *http.Client // no timeout, for long-lived streaming responses
+	base   string       // scheme and host of every request; empty means http://localhost
+
+	mu      sync.RWMutex
+	version string // negotiated API version, e.g. "1.41"; empty until negotiated
 }
 
-// NewClient creates a Docker API client connected to the local socket.
-func NewClient() *Client {
-	dialer := &net.Dialer{}
+// NewClient creates a Docker API client for the endpoint in opts.
+func NewClient(opts Options) (*Client, error) {
+	host := opts.Host
+	if host == "" {
+		host = DefaultHost
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("docker host %q: %w", host, err)
+	}
+
+	transport := &http.Transport{}
+	base := "http://localhost"
+	switch u.Scheme {
+	case "unix":
+		if u.Path == "" {
+			return nil, fmt.Errorf("docker host %q: missing socket path", host)
+		}
+		if opts.CertPath != "" {
+			return nil, fmt.Errorf("docker host %q: TLS requires tcp://", host)
+		}
+		dialer := &net.Dialer{}
+		socket := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	case "tcp":
+		if u.Port() == "" {
+			return nil, fmt.Errorf("docker host %q: missing port", host)
+		}
+		base = "http://" + u.Host
+		if opts.CertPath != "" {
+			tlsCfg, err := loadTLS(opts.CertPath)
+			if err != nil {
+				return nil, fmt.Errorf("docker host %q: %w", host, err)
+			}
+			transport.TLSClientConfig = tlsCfg
+			base = "https://" + u.Host
+		}
+	default:
+		return nil, fmt.Errorf("docker host %q: unsupported scheme, use unix:// or tcp://", host)
+	}
+
 	return &Client{
-		http: &http.Client{
-			Transport: &http.Transport{
-				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
-					return dialer.DialContext(ctx, "unix", socketPath)
-				},
-			},
-			Timeout: 30 * time.Second,
-		},
+		http:   &http.Client{Transport: transport, Timeout: 30 * time.Second},
+		stream: &http.Client{Transport: transport},
+		base:   base,
+	}, nil
+}
+
+// loadTLS builds a client TLS config from ca.pem, cert.pem and key.pem in dir,
+// the layout docker uses for DOCKER_CERT_PATH. The engine's certificate is
+// verified against ca.pem.
+func loadTLS(dir string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("load client certificate: %w", err)
+	}
+	caPEM, err := os.ReadFile(filepath.Join(dir, "ca.pem")) // #nosec G304 -- path from local config file
+	if err != nil {
+		return nil, fmt.Errorf("load CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("load CA: no certificates in %s", filepath.Join(dir, "ca.pem"))
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// streamClient returns the client used for streaming responses (pulls, events).
+func (c *Client) streamClient() *http.Client {
+	if c.stream != nil {
+		return c.stream
+	}
+	return c.http
+}
+
+// APIVersion returns the Engine API version requests are sent with.
+func (c *Client) APIVersion() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.version == "" {
+		return maxAPIVersion
+	}
+	return c.version
+}
+
+// NegotiateVersion asks the engine for its API version (GET /version, which is
+// served unversioned) and uses the older of it and maxAPIVersion from then on,
+// so older engines and Podman's Docker-compatible API are spoken to in a
+// version they accept.
+func (c *Client) NegotiateVersion(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL()+"/version", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req) // #nosec G704 -- configured engine endpoint only
+	if err != nil {
+		return fmt.Errorf("docker version: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("docker version: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return &apiError{resp.StatusCode, fmt.Sprintf("docker API /version: %d %s", resp.StatusCode, string(body))}
+	}
+	var v struct {
+		APIVersion string `json:"ApiVersion"`
+	}
+	if err := decodeJSON(body, &v); err != nil {
+		return fmt.Errorf("docker version: %w", err)
+	}
+	if _, ok := parseAPIVersion(v.APIVersion); !ok {
+		return fmt.Errorf("docker version: invalid ApiVersion %q", v.APIVersion)
+	}
+
+	version := maxAPIVersion
+	if compareAPIVersions(v.APIVersion, maxAPIVersion) < 0 {
+		version = v.APIVersion
+	}
+	c.mu.Lock()
+	c.version = version
+	c.mu.Unlock()
+	return nil
+}
+
+// RetryNegotiation calls NegotiateVersion every 5 seconds until it succeeds or
+// ctx is cancelled. Used when the engine is unreachable at startup.
+func (c *Client) RetryNegotiation(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := c.NegotiateVersion(ctx); err == nil {
+			logger.Printf("[docker] API version: v%s", c.APIVersion())
+			return
+		}
+	}
+}
+
+// parseAPIVersion splits "major.minor" into its numbers.
+func parseAPIVersion(v string) ([2]int, bool) {
+	major, minor, ok := strings.Cut(v, ".")
+	if !ok {
+		return [2]int{}, false
+	}
+	a, err1 := strconv.Atoi(major)
+	b, err2 := strconv.Atoi(minor)
+	if err1 != nil || err2 != nil {
+		return [2]int{}, false
+	}
+	return [2]int{a, b}, true
+}
+
+// compareAPIVersions returns -1, 0 or 1 as a is older than, equal to or newer
+// than b. Unparsable versions compare equal.
+func compareAPIVersions(a, b string) int {
+	va, okA := parseAPIVersion(a)
+	vb, okB := parseAPIVersion(b)
+	if !okA || !okB {
+		return 0
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			if va[i] < vb[i] {
+				return -1
+			}
+			return 1
+		}
 	}
+	return 0
 }
 
-// newStreamClient returns a client with no timeout for long-lived streaming connections.
-func newStreamClient() *http.Client {
-	dialer := &net.Dialer{}
-	return &http.Client{
-		Transport: &http.Transport{
-			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
-				return dialer.DialContext(ctx, "unix", socketPath)
-			},
-		},
+func (c *Client) baseURL() string {
+	if c.base == "" {
+		return "http://localhost"
 	}
+	return c.base
 }
 
 func (c *Client) url(path string) string {
-	return fmt.Sprintf("http://localhost/%s%s", apiVersion, path)
+	return fmt.Sprintf("%s/v%s%s", c.baseURL(), c.APIVersion(), path)
 }
 
 func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
@@ -59,7 +246,7 @@ func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	resp, err := c.http.Do(req) // #nosec G704 -- unix socket only, no external network
+	resp, err := c.http.Do(req) // #nosec G704 -- configured engine endpoint only
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +257,7 @@ func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
 		return nil, err
 	}
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("docker API %s: %d %s", path, resp.StatusCode, string(body))
+		return nil, &apiError{resp.StatusCode, fmt.Sprintf("docker API %s: %d %s", path, resp.StatusCode, string(body))}
 	}
 	return body, nil
 }
@@ -87,7 +274,7 @@ func (c *Client) post(ctx context.Context, path string, payload string) ([]byte,
 	if payload != "" {
 		req.Header.Set("Content-Type", "application/json")
 	}
-	resp, err := c.http.Do(req) // #nosec G704 -- unix socket only, no external network
+	resp, err := c.http.Do(req) // #nosec G704 -- configured engine endpoint only
 	if err != nil {
 		return nil, err
 	}
@@ -98,7 +285,7 @@ func (c *Client) post(ctx context.Context, path string, payload string) ([]byte,
 		return nil, err
 	}
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("docker API POST %s: %d %s", path, resp.StatusCode, string(body))
+		return nil, &apiError{resp.StatusCode, fmt.Sprintf("docker API POST %s: %d %s", path, resp.StatusCode, string(body))}
 	}
 	return body, nil
 }
@@ -108,14 +295,14 @@ func (c *Client) delete(ctx context.Context, path string) error {
 	if err != nil {
 		return err
 	}
-	resp, err := c.http.Do(req) // #nosec G704 -- unix socket only, no external network
+	resp, err := c.http.Do(req) // #nosec G704 -- configured engine endpoint only
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 	_, _ = io.Copy(io.Discard, resp.Body) // Drain body before close.
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("docker API DELETE %s: %d", path, resp.StatusCode)
+		return &apiError{resp.StatusCode, fmt.Sprintf("docker API DELETE %s: %d", path, resp.StatusCode)}
 	}
 	return nil
 }
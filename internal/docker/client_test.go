@@ -0,0 +1,87 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewClient_Hosts(t *testing.T) {
+	tests := []struct {
+		host     string
+		certPath string
+		wantBase string
+		wantErr  bool
+	}{
+		{host: "", wantBase: "http://localhost"},
+		{host: "unix:///run/podman/podman.sock", wantBase: "http://localhost"},
+		{host: "tcp://10.0.0.5:2375", wantBase: "http://10.0.0.5:2375"},
+		{host: "tcp://10.0.0.5:2376", certPath: t.TempDir(), wantErr: true}, // no certificates in dir
+		{host: "unix:///var/run/docker.sock", certPath: "/certs", wantErr: true},
+		{host: "tcp://10.0.0.5", wantErr: true},
+		{host: "ssh://user@host", wantErr: true},
+	}
+	for _, tt := range tests {
+		c, err := NewClient(Options{Host: tt.host, CertPath: tt.certPath})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewClient(%q) error = %v, wantErr %v", tt.host, err, tt.wantErr)
+			continue
+		}
+		if err == nil && c.base != tt.wantBase {
+			t.Errorf("NewClient(%q) base = %q, want %q", tt.host, c.base, tt.wantBase)
+		}
+	}
+}
+
+func TestNegotiateVersion(t *testing.T) {
+	tests := []struct {
+		server string
+		want   string
+	}{
+		{server: "1.41", want: "1.41"},
+		{server: "1.47", want: maxAPIVersion},
+		{server: "1.45", want: maxAPIVersion},
+	}
+	for _, tt := range tests {
+		var paths []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			if r.URL.Path == "/version" {
+				w.Write([]byte(`{"Version": "4.9.3", "ApiVersion": "` + tt.server + `", "MinAPIVersion": "1.24"}`))
+				return
+			}
+			w.Write([]byte(`[]`))
+		}))
+		c := newTestClient(server)
+		if err := c.NegotiateVersion(context.Background()); err != nil {
+			t.Fatalf("NegotiateVersion() error = %v", err)
+		}
+		if got := c.APIVersion(); got != tt.want {
+			t.Errorf("server %s: APIVersion() = %q, want %q", tt.server, got, tt.want)
+		}
+		if _, err := c.ListContainersByProject(context.Background(), "app"); err != nil {
+			t.Fatalf("list containers: %v", err)
+		}
+		if last := paths[len(paths)-1]; !strings.HasPrefix(last, "/v"+tt.want+"/") {
+			t.Errorf("server %s: request path %q, want /v%s/ prefix", tt.server, last, tt.want)
+		}
+		server.Close()
+	}
+}
+
+func TestNegotiateVersion_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ApiVersion": "latest"}`))
+	}))
+	defer server.Close()
+
+	c := newTestClient(server)
+	if err := c.NegotiateVersion(context.Background()); err == nil {
+		t.Fatal("want error for invalid ApiVersion")
+	}
+	if got := c.APIVersion(); got != maxAPIVersion {
+		t.Errorf("APIVersion() = %q, want fallback %q", got, maxAPIVersion)
+	}
+}
@@ -26,6 +26,8 @@ type ContainerInspect struct {
 	State  ContainerState  `json:"State"`
 	Config ContainerConfig `json:"Config"`
 	Mounts []MountPoint    `json:"Mounts"`
+
+	HostConfig ContainerHostConfig `json:"HostConfig"`
 }
 
 // MountPoint describes a volume/bind mount on a container.
@@ -41,6 +43,10 @@ type MountPoint struct {
 type ContainerState struct {
 	Status  string        `json:"Status"` // running, exited, restarting, etc.
 	Running bool          `json:"Running"`
+	ExitCode int          `json:"ExitCode"`
+	OOMKilled bool        `json:"OOMKilled"`
+	FinishedAt time.Time  `json:"FinishedAt"` // zero-year when the container never stopped
+	Error   string        `json:"Error"` // runtime error that stopped the container, if any
 	Health  *HealthState  `json:"Health,omitempty"`
 }
 
@@ -59,6 +65,11 @@ type HealthLog struct {
 	Output   string    `json:"Output"`
 }
 
+// ContainerHostConfig holds the host-side limits of a container.
+type ContainerHostConfig struct {
+	Memory int64 `json:"Memory"` // bytes, 0 = unlimited
+}
+
 // ContainerConfig holds the container's configuration.
 type ContainerConfig struct {
 	Image  string            `json:"Image"`
@@ -131,6 +142,15 @@ func (c *Client) StopContainer(ctx context.Context, id string, timeoutSec int) e
 	return nil
 }
 
+// RemoveContainer removes a stopped container. force also kills a running one.
+func (c *Client) RemoveContainer(ctx context.Context, id string, force bool) error {
+	path := fmt.Sprintf("/containers/%s?force=%t", url.PathEscape(id), force)
+	if err := c.delete(ctx, path); err != nil {
+		return fmt.Errorf("remove container %s: %w", id, err)
+	}
+	return nil
+}
+
 // ContainerName returns the clean name (without leading slash).
 func (ci *ContainerInspect) ContainerName() string {
 	if len(ci.Name) > 0 && ci.Name[0] == '/' {
@@ -12,10 +12,11 @@ import (
 
 // Event represents a Docker engine event from the event stream.
 type Event struct {
-	Type   string     `json:"Type"`
-	Action string     `json:"Action"`
-	Actor  EventActor `json:"Actor"`
-	Time   int64      `json:"time"`
+	Type     string     `json:"Type"`
+	Action   string     `json:"Action"`
+	Actor    EventActor `json:"Actor"`
+	Time     int64      `json:"time"`
+	TimeNano int64      `json:"timeNano"`
 }
 
 // EventActor identifies the object that triggered the event.
@@ -29,36 +30,71 @@ func (e *Event) ContainerName() string {
 	return e.Actor.Attributes["name"]
 }
 
+// At returns when the event happened, or now when the engine did not say.
+func (e *Event) At() time.Time {
+	switch {
+	case e.TimeNano > 0:
+		return time.Unix(0, e.TimeNano)
+	case e.Time > 0:
+		return time.Unix(e.Time, 0)
+	}
+	return time.Now()
+}
+
+// eventTime formats t for the since parameter of the events endpoint:
+// seconds with a nanosecond fraction.
+func eventTime(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
+
 // EventHandler is called for each event received from the stream.
 type EventHandler func(event Event)
 
+// reconnectDelay is the pause before the event stream reconnects.
+var reconnectDelay = 5 * time.Second
+
 // StreamEvents opens a long-lived connection to the Docker event stream
-// and calls handler for each event. It automatically reconnects on failure.
-// Blocks until ctx is cancelled.
+// and calls handler for each event. It automatically reconnects on failure,
+// resuming from the last event handled so that events emitted while
+// disconnected are replayed from the daemon's buffer, each at most once.
+// Events lost with a daemon restart are not replayed. Blocks until ctx is
+// cancelled.
 func (c *Client) StreamEvents(ctx context.Context, handler EventHandler) {
+	// Start from now to avoid replaying buffered events from before dockward started.
+	since := time.Now()
+	var last int64 // timeNano of the last event handled
 	for {
 		if ctx.Err() != nil {
 			return
 		}
-		err := c.streamOnce(ctx, handler)
+		err := c.streamOnce(ctx, since, func(event Event) {
+			if event.TimeNano != 0 {
+				if event.TimeNano <= last {
+					return // already handled before the reconnect
+				}
+				last = event.TimeNano
+			}
+			handler(event)
+		})
 		if ctx.Err() != nil {
 			return
 		}
-		logger.Printf("[events] stream disconnected: %v, reconnecting in 5s", err)
+		if last != 0 {
+			since = time.Unix(0, last)
+		}
+		logger.Printf("[events] stream disconnected: %v, resuming from %s in %s", err, since.Format(time.RFC3339), reconnectDelay)
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(5 * time.Second):
+		case <-time.After(reconnectDelay):
 		}
 	}
 }
 
-func (c *Client) streamOnce(ctx context.Context, handler EventHandler) error {
-	// Filter for container health_status, die, and start events.
-	// Use since=<now> to avoid replaying buffered events from before connection.
-	filters := url.QueryEscape(`{"type":["container"],"event":["health_status","die","start"]}`)
-	since := fmt.Sprintf("%d", time.Now().Unix())
-	reqURL := fmt.Sprintf("http://localhost/%s/events?since=%s&filters=%s", apiVersion, since, filters)
+func (c *Client) streamOnce(ctx context.Context, since time.Time, handler EventHandler) error {
+	// Filter for container health_status, die, oom and start events.
+	filters := url.QueryEscape(`{"type":["container"],"event":["health_status","die","oom","start"]}`)
+	reqURL := c.url(fmt.Sprintf("/events?since=%s&filters=%s", eventTime(since), filters))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
@@ -66,8 +102,7 @@ func (c *Client) streamOnce(ctx context.Context, handler EventHandler) error {
 	}
 
 	// Use stream client (no timeout) for long-lived connection.
-	stream := newStreamClient()
-	resp, err := stream.Do(req) // #nosec G704 -- unix socket only, no external network
+	resp, err := c.streamClient().Do(req) // #nosec G704 -- configured engine endpoint only
 	if err != nil {
 		return fmt.Errorf("connect event stream: %w", err)
 	}
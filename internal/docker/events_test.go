@@ -0,0 +1,70 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStreamEvents_ResumesFromLastEvent(t *testing.T) {
+	old := reconnectDelay
+	reconnectDelay = 10 * time.Millisecond
+	defer func() { reconnectDelay = old }()
+
+	base := time.Now().Add(-time.Minute).UnixNano()
+	event := func(action string, n int64) string {
+		return fmt.Sprintf(`{"Type":"container","Action":%q,"Actor":{"ID":"abc"},"timeNano":%d}`+"\n", action, base+n)
+	}
+
+	var mu sync.Mutex
+	var sinces []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sinces = append(sinces, r.URL.Query().Get("since"))
+		n := len(sinces)
+		mu.Unlock()
+		switch n {
+		case 1:
+			w.Write([]byte(event("start", 1) + event("die", 2)))
+		case 2:
+			// The daemon replays from since, inclusive: "die" is a duplicate.
+			w.Write([]byte(event("die", 2) + event("health_status: unhealthy", 3)))
+		default:
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var got []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		newTestClient(server).StreamEvents(ctx, func(e Event) {
+			got = append(got, e.Action)
+			if len(got) == 3 {
+				cancel()
+			}
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		cancel()
+		t.Fatal("stream did not deliver the events")
+	}
+
+	want := []string{"start", "die", "health_status: unhealthy"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got events %v, want %v", got, want)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if wantSince := eventTime(time.Unix(0, base+2)); len(sinces) < 2 || sinces[1] != wantSince {
+		t.Errorf("reconnect since = %v, want %s", sinces, wantSince)
+	}
+}
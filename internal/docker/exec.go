@@ -0,0 +1,83 @@
+package docker
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ExecResult is the outcome of a command run inside a container.
+type ExecResult struct {
+	ExitCode int
+	Output   string // stdout and stderr, interleaved
+}
+
+// Exec runs cmd inside a running container and waits for it to finish.
+// The command's lifetime is bounded by ctx.
+func (c *Client) Exec(ctx context.Context, id string, cmd []string) (*ExecResult, error) {
+	payload, err := json.Marshal(map[string]any{
+		"Cmd":          cmd,
+		"AttachStdout": true,
+		"AttachStderr": true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode exec: %w", err)
+	}
+	data, err := c.post(ctx, "/containers/"+url.PathEscape(id)+"/exec", string(payload))
+	if err != nil {
+		return nil, fmt.Errorf("create exec in %s: %w", id, err)
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := decodeJSON(data, &created); err != nil {
+		return nil, fmt.Errorf("decode exec create: %w", err)
+	}
+
+	// Without a TTY the attached output is multiplexed; start returns once the
+	// command exits and the stream closes.
+	out, err := c.post(ctx, "/exec/"+url.PathEscape(created.ID)+"/start", `{"Detach":false,"Tty":false}`)
+	if err != nil {
+		return nil, fmt.Errorf("start exec in %s: %w", id, err)
+	}
+
+	data, err = c.get(ctx, "/exec/"+url.PathEscape(created.ID)+"/json")
+	if err != nil {
+		return nil, fmt.Errorf("inspect exec in %s: %w", id, err)
+	}
+	var inspect struct {
+		ExitCode int  `json:"ExitCode"`
+		Running  bool `json:"Running"`
+	}
+	if err := decodeJSON(data, &inspect); err != nil {
+		return nil, fmt.Errorf("decode exec inspect: %w", err)
+	}
+	if inspect.Running {
+		return nil, fmt.Errorf("exec in %s: still running after output closed", id)
+	}
+	return &ExecResult{ExitCode: inspect.ExitCode, Output: demuxOutput(out)}, nil
+}
+
+// demuxOutput joins the payloads of a multiplexed attach stream. Each frame
+// is an 8-byte header (stream type, 3 zero bytes, big-endian payload size)
+// followed by the payload. Input that is not framed is returned as is.
+func demuxOutput(b []byte) string {
+	var sb strings.Builder
+	for len(b) > 0 {
+		if len(b) < 8 || b[0] > 2 || b[1] != 0 || b[2] != 0 || b[3] != 0 {
+			sb.Write(b) // not a frame header: raw (TTY) output
+			break
+		}
+		size := int(binary.BigEndian.Uint32(b[4:8]))
+		b = b[8:]
+		if size > len(b) {
+			size = len(b)
+		}
+		sb.Write(b[:size])
+		b = b[size:]
+	}
+	return sb.String()
+}
@@ -0,0 +1,49 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// frame builds one multiplexed attach-stream frame.
+func frame(stream byte, payload string) string {
+	n := len(payload)
+	return string([]byte{stream, 0, 0, 0, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}) + payload
+}
+
+func TestExec(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/containers/abc/exec"):
+			w.Write([]byte(`{"Id":"exec1"}`))
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/exec/exec1/start"):
+			w.Write([]byte(frame(1, "ready\n") + frame(2, "warn: slow\n")))
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/exec/exec1/json"):
+			w.Write([]byte(`{"ExitCode":3,"Running":false}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	res, err := newTestClient(server).Exec(context.Background(), "abc", []string{"pg_isready"})
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if res.ExitCode != 3 {
+		t.Errorf("want exit code 3, got %d", res.ExitCode)
+	}
+	if res.Output != "ready\nwarn: slow\n" {
+		t.Errorf("want demuxed output, got %q", res.Output)
+	}
+}
+
+func TestDemuxOutput_Raw(t *testing.T) {
+	if got := demuxOutput([]byte("plain tty output")); got != "plain tty output" {
+		t.Errorf("want raw output unchanged, got %q", got)
+	}
+}
@@ -114,7 +114,7 @@ func (hc *HealthChecker) ping(ctx context.Context) error {
 		return fmt.Errorf("create ping request: %w", err)
 	}
 
-	resp, err := hc.client.http.Do(req) // #nosec G704 -- unix socket only
+	resp, err := hc.client.http.Do(req) // #nosec G704 -- configured engine endpoint only
 	if err != nil {
 		return fmt.Errorf("ping docker: %w", err)
 	}
@@ -60,14 +60,12 @@ func (c *Client) PullImage(ctx context.Context, image string) error {
 	)
 
 	// Pull uses a streaming response. We need a client without timeout.
-	stream := newStreamClient()
-	reqURL := fmt.Sprintf("http://localhost/%s%s", apiVersion, path)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(path), nil)
 	if err != nil {
 		return fmt.Errorf("pull image %s: %w", image, err)
 	}
 
-	resp, err := stream.Do(req) // #nosec G704 -- unix socket only, no external network
+	resp, err := c.streamClient().Do(req) // #nosec G704 -- configured engine endpoint only
 	if err != nil {
 		return fmt.Errorf("pull image %s: %w", image, err)
 	}
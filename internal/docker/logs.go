@@ -0,0 +1,22 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ContainerLogs returns the last lines of a container's stdout and stderr,
+// interleaved. Output of containers without a TTY is demultiplexed.
+func (c *Client) ContainerLogs(ctx context.Context, id string, lines int) (string, error) {
+	q := url.Values{}
+	q.Set("stdout", "1")
+	q.Set("stderr", "1")
+	q.Set("tail", strconv.Itoa(lines))
+	data, err := c.get(ctx, "/containers/"+url.PathEscape(id)+"/logs?"+q.Encode())
+	if err != nil {
+		return "", fmt.Errorf("logs of %s: %w", id, err)
+	}
+	return demuxOutput(data), nil
+}
@@ -0,0 +1,31 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestContainerLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/containers/abc/logs") {
+			t.Errorf("unexpected request: %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("tail") != "20" || q.Get("stdout") != "1" || q.Get("stderr") != "1" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(frame(1, "listening on :8080\n") + frame(2, "panic: nil map\n")))
+	}))
+	defer server.Close()
+
+	out, err := newTestClient(server).ContainerLogs(context.Background(), "abc", 20)
+	if err != nil {
+		t.Fatalf("ContainerLogs: %v", err)
+	}
+	if out != "listening on :8080\npanic: nil map\n" {
+		t.Errorf("want demuxed logs, got %q", out)
+	}
+}
@@ -0,0 +1,172 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+)
+
+// apiError is a Docker API error response. Error() keeps the message format
+// the client has always returned; IsNotFound inspects the status.
+type apiError struct {
+	status int
+	msg    string
+}
+
+func (e *apiError) Error() string { return e.msg }
+
+// IsNotFound reports whether err is a Docker API 404 (no such container or image).
+func IsNotFound(err error) bool {
+	var ae *apiError
+	return errors.As(err, &ae) && ae.status == http.StatusNotFound
+}
+
+// imageDefaults are the container Config fields an image provides. When a
+// container's value equals the old image's, it is dropped from the clone so
+// the new image's default applies instead.
+var imageDefaults = []string{"Cmd", "Entrypoint", "WorkingDir", "User", "Healthcheck", "StopSignal", "ExposedPorts", "Volumes", "OnBuild", "Shell"}
+
+// CloneSpec builds a container create body that reproduces container id with
+// image swapped in: its Config, HostConfig and network endpoints. Values the
+// container inherited from its old image (command, env entries, labels, ...)
+// are left out so the new image's defaults apply, as with docker run.
+func (c *Client) CloneSpec(ctx context.Context, id, image string) ([]byte, error) {
+	data, err := c.get(ctx, "/containers/"+url.PathEscape(id)+"/json")
+	if err != nil {
+		return nil, fmt.Errorf("inspect container %s: %w", id, err)
+	}
+	var ctr struct {
+		ID              string                     `json:"Id"`
+		Image           string                     `json:"Image"`
+		Config          map[string]json.RawMessage `json:"Config"`
+		HostConfig      json.RawMessage            `json:"HostConfig"`
+		NetworkSettings struct {
+			Networks map[string]struct {
+				IPAMConfig json.RawMessage   `json:"IPAMConfig,omitempty"`
+				Links      []string          `json:"Links,omitempty"`
+				Aliases    []string          `json:"Aliases,omitempty"`
+				DriverOpts map[string]string `json:"DriverOpts,omitempty"`
+			} `json:"Networks"`
+		} `json:"NetworkSettings"`
+	}
+	if err := decodeJSON(data, &ctr); err != nil {
+		return nil, fmt.Errorf("decode inspect %s: %w", id, err)
+	}
+
+	data, err = c.get(ctx, "/images/"+ctr.Image+"/json")
+	if err != nil {
+		return nil, fmt.Errorf("inspect image %s: %w", ctr.Image, err)
+	}
+	var img struct {
+		Config map[string]json.RawMessage `json:"Config"`
+	}
+	if err := decodeJSON(data, &img); err != nil {
+		return nil, fmt.Errorf("decode image %s: %w", ctr.Image, err)
+	}
+
+	spec := make(map[string]any, len(ctr.Config)+2)
+	for k, v := range ctr.Config {
+		spec[k] = v
+	}
+	for _, k := range imageDefaults {
+		if v, ok := ctr.Config[k]; ok && jsonEqual(v, img.Config[k]) {
+			delete(spec, k)
+		}
+	}
+	spec["Env"] = stripInherited(ctr.Config["Env"], img.Config["Env"])
+	spec["Labels"] = stripInheritedLabels(ctr.Config["Labels"], img.Config["Labels"])
+	// The default hostname is the short container ID; let the new container get its own.
+	var hostname string
+	_ = json.Unmarshal(ctr.Config["Hostname"], &hostname)
+	if len(ctr.ID) >= 12 && hostname == ctr.ID[:12] {
+		delete(spec, "Hostname")
+	}
+	spec["Image"] = image
+	spec["HostConfig"] = ctr.HostConfig
+
+	endpoints := make(map[string]any, len(ctr.NetworkSettings.Networks))
+	for name, ep := range ctr.NetworkSettings.Networks {
+		// Docker adds the short container ID as an alias; it would point at the old container.
+		ep.Aliases = slices.DeleteFunc(ep.Aliases, func(a string) bool { return len(ctr.ID) >= 12 && a == ctr.ID[:12] })
+		endpoints[name] = ep
+	}
+	spec["NetworkingConfig"] = map[string]any{"EndpointsConfig": endpoints}
+
+	return json.Marshal(spec)
+}
+
+// stripInherited drops the env entries the container inherited unchanged from its image.
+func stripInherited(ctrRaw, imgRaw json.RawMessage) []string {
+	var ctr, img []string
+	_ = json.Unmarshal(ctrRaw, &ctr)
+	_ = json.Unmarshal(imgRaw, &img)
+	return slices.DeleteFunc(ctr, func(e string) bool { return slices.Contains(img, e) })
+}
+
+// stripInheritedLabels drops the labels the container inherited unchanged from its image.
+func stripInheritedLabels(ctrRaw, imgRaw json.RawMessage) map[string]string {
+	var ctr, img map[string]string
+	_ = json.Unmarshal(ctrRaw, &ctr)
+	_ = json.Unmarshal(imgRaw, &img)
+	for k, v := range ctr {
+		if iv, ok := img[k]; ok && iv == v {
+			delete(ctr, k)
+		}
+	}
+	return ctr
+}
+
+// jsonEqual reports whether two JSON values are semantically equal.
+// A missing value equals null.
+func jsonEqual(a, b json.RawMessage) bool {
+	var av, bv any
+	if len(a) > 0 {
+		if err := json.Unmarshal(a, &av); err != nil {
+			return false
+		}
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &bv); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+// CreateContainer creates a container named name from a create body such as
+// one built by CloneSpec. Returns the new container ID.
+func (c *Client) CreateContainer(ctx context.Context, name string, spec []byte) (string, error) {
+	data, err := c.post(ctx, "/containers/create?name="+url.QueryEscape(name), string(spec))
+	if err != nil {
+		return "", fmt.Errorf("create container %s: %w", name, err)
+	}
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := decodeJSON(data, &created); err != nil {
+		return "", fmt.Errorf("decode create %s: %w", name, err)
+	}
+	return created.ID, nil
+}
+
+// StartContainer starts a created or stopped container.
+func (c *Client) StartContainer(ctx context.Context, id string) error {
+	if _, err := c.post(ctx, "/containers/"+url.PathEscape(id)+"/start", ""); err != nil {
+		return fmt.Errorf("start container %s: %w", id, err)
+	}
+	return nil
+}
+
+// RenameContainer gives a container a new name.
+func (c *Client) RenameContainer(ctx context.Context, id, name string) error {
+	path := fmt.Sprintf("/containers/%s/rename?name=%s", url.PathEscape(id), url.QueryEscape(name))
+	if _, err := c.post(ctx, path, ""); err != nil {
+		return fmt.Errorf("rename container %s to %s: %w", id, name, err)
+	}
+	return nil
+}
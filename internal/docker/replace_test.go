@@ -0,0 +1,103 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCloneSpec(t *testing.T) {
+	const id = "0123456789abcdef0123"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/web/json"):
+			w.Write([]byte(`{
+				"Id": "` + id + `",
+				"Image": "sha256:old",
+				"Config": {
+					"Hostname": "0123456789ab",
+					"Image": "app:1.0",
+					"Cmd": ["serve"],
+					"Entrypoint": ["/custom"],
+					"Env": ["PATH=/usr/bin", "APP_VERSION=1.0", "DB_URL=postgres://db"],
+					"Labels": {"org.opencontainers.image.version": "1.0", "team": "web"}
+				},
+				"HostConfig": {"RestartPolicy": {"Name": "unless-stopped"}, "PortBindings": {"80/tcp": [{"HostPort": "8080"}]}},
+				"NetworkSettings": {"Networks": {"backend": {"Aliases": ["web", "0123456789ab"], "IPAddress": "172.18.0.5"}}}
+			}`))
+		case strings.HasSuffix(r.URL.Path, "/images/sha256:old/json"):
+			w.Write([]byte(`{"Config": {
+				"Cmd": ["serve"],
+				"Entrypoint": ["/entrypoint.sh"],
+				"Env": ["PATH=/usr/bin", "APP_VERSION=1.0"],
+				"Labels": {"org.opencontainers.image.version": "1.0"}
+			}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	data, err := newTestClient(server).CloneSpec(context.Background(), "web", "app:1.1")
+	if err != nil {
+		t.Fatalf("CloneSpec: %v", err)
+	}
+	var spec struct {
+		Hostname         *string
+		Image            string
+		Cmd              []string
+		Entrypoint       []string
+		Env              []string
+		Labels           map[string]string
+		HostConfig       map[string]any
+		NetworkingConfig struct {
+			EndpointsConfig map[string]struct{ Aliases []string }
+		}
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.Image != "app:1.1" {
+		t.Errorf("want new image, got %q", spec.Image)
+	}
+	if spec.Hostname != nil {
+		t.Errorf("want default hostname dropped, got %q", *spec.Hostname)
+	}
+	if spec.Cmd != nil {
+		t.Errorf("want image default Cmd dropped, got %v", spec.Cmd)
+	}
+	if len(spec.Entrypoint) != 1 || spec.Entrypoint[0] != "/custom" {
+		t.Errorf("want overridden Entrypoint kept, got %v", spec.Entrypoint)
+	}
+	if len(spec.Env) != 1 || spec.Env[0] != "DB_URL=postgres://db" {
+		t.Errorf("want only container env kept, got %v", spec.Env)
+	}
+	if len(spec.Labels) != 1 || spec.Labels["team"] != "web" {
+		t.Errorf("want only container labels kept, got %v", spec.Labels)
+	}
+	if spec.HostConfig["PortBindings"] == nil {
+		t.Error("want HostConfig copied")
+	}
+	aliases := spec.NetworkingConfig.EndpointsConfig["backend"].Aliases
+	if len(aliases) != 1 || aliases[0] != "web" {
+		t.Errorf("want short-ID alias dropped, got %v", aliases)
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"No such container: web"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server).InspectContainer(context.Background(), "web")
+	if !IsNotFound(err) {
+		t.Errorf("want not found, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "404") {
+		t.Errorf("want status in message, got %v", err)
+	}
+}
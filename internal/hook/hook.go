@@ -0,0 +1,86 @@
+// Package hook runs user-defined deploy hooks: a one-off compose service, a
+// host command, or an HTTP GET. Each hook is bounded by its timeout and
+// returns its output for the audit log.
+package hook
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/compose"
+	"github.com/studiowebux/dockward/internal/config"
+)
+
+// maxBody bounds how much of an HTTP response is kept as hook output.
+const maxBody = 16 << 10
+
+// Compose locates the compose project that compose hooks run in.
+type Compose struct {
+	Runtime string
+	Files   []string
+	Project string
+	EnvFile string
+}
+
+// Run executes h with env (KEY=VALUE entries) added to the environment of
+// compose and host commands. Returns the hook output and an error when the
+// hook fails: a non-zero exit, a non-2xx response, or the timeout.
+func Run(ctx context.Context, h *config.Hook, c Compose, env []string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(h.Timeout)*time.Second)
+	defer cancel()
+
+	var (
+		out string
+		err error
+	)
+	switch {
+	case h.Service != "":
+		out, err = compose.RunOneOff(ctx, c.Runtime, c.Files, c.Project, c.EnvFile, h.Service, env...)
+	case len(h.Command) > 0:
+		out, err = runCommand(ctx, h.Command, env)
+	case h.HTTP != "":
+		out, err = get(ctx, h.HTTP)
+	default:
+		return "", fmt.Errorf("hook has no service, command or http target")
+	}
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("%s: timed out after %ds", h.Label(), h.Timeout)
+	}
+	return out, err
+}
+
+func runCommand(ctx context.Context, argv []string, env []string) (string, error) {
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...) // #nosec G204 -- command from local config file
+	cmd.Env = append(os.Environ(), env...)
+	output, err := cmd.CombinedOutput()
+	out := strings.TrimSpace(string(output))
+	if err != nil {
+		return out, fmt.Errorf("%s: %w", argv[0], err)
+	}
+	return out, nil
+}
+
+func get(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req) // #nosec G107 -- URL from local config file
+	if err != nil {
+		return "", fmt.Errorf("GET %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	out := fmt.Sprintf("HTTP %d\n%s", resp.StatusCode, strings.TrimSpace(string(body)))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return out, fmt.Errorf("GET %s: HTTP %d", url, resp.StatusCode)
+	}
+	return out, nil
+}
@@ -0,0 +1,65 @@
+package hook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/studiowebux/dockward/internal/config"
+)
+
+func TestRun_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ok" {
+			w.Write([]byte("all checks passed"))
+			return
+		}
+		http.Error(w, "checkout broken", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	out, err := Run(context.Background(), &config.Hook{HTTP: server.URL + "/ok", Timeout: 2}, Compose{}, nil)
+	if err != nil {
+		t.Fatalf("2xx: want success, got %v", err)
+	}
+	if !strings.Contains(out, "all checks passed") {
+		t.Errorf("want body in output, got %q", out)
+	}
+
+	out, err = Run(context.Background(), &config.Hook{HTTP: server.URL + "/fail", Timeout: 2}, Compose{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 500") {
+		t.Errorf("5xx: want HTTP 500 error, got %v", err)
+	}
+	if !strings.Contains(out, "checkout broken") {
+		t.Errorf("5xx: want body in output, got %q", out)
+	}
+}
+
+func TestRun_Command(t *testing.T) {
+	h := &config.Hook{Command: []string{"sh", "-c", `echo "smoke $SMOKE_TARGET"`}, Timeout: 5}
+	out, err := Run(context.Background(), h, Compose{}, []string{"SMOKE_TARGET=api"})
+	if err != nil {
+		t.Fatalf("want success, got %v", err)
+	}
+	if out != "smoke api" {
+		t.Errorf("want env passed to command, got %q", out)
+	}
+
+	h = &config.Hook{Command: []string{"sh", "-c", "echo failed >&2; exit 3"}, Timeout: 5}
+	out, err = Run(context.Background(), h, Compose{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("want exit status error, got %v", err)
+	}
+	if out != "failed" {
+		t.Errorf("want stderr in output, got %q", out)
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	h := &config.Hook{Command: []string{"sleep", "5"}, Timeout: 1}
+	if _, err := Run(context.Background(), h, Compose{}, nil); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("want timeout error, got %v", err)
+	}
+}
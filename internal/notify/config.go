@@ -0,0 +1,29 @@
+package notify
+
+import (
+	"fmt"
+
+	"github.com/studiowebux/dockward/internal/config"
+)
+
+// FromConfig builds the notifiers enabled in n, in the order discord, smtp,
+// webhooks. Shared by the agent and the warden, which accept the same
+// notification channels.
+func FromConfig(n config.Notifications) ([]Notifier, error) {
+	var notifiers []Notifier
+	if n.Discord != nil && n.Discord.WebhookURL != "" {
+		notifiers = append(notifiers, NewDiscord(n.Discord.WebhookURL))
+	}
+	if n.SMTP != nil && n.SMTP.Host != "" {
+		s := n.SMTP
+		notifiers = append(notifiers, NewSMTP(s.Host, s.Port, s.From, s.To, s.Username, s.Password))
+	}
+	for _, wh := range n.Webhooks {
+		w, err := NewWebhook(wh.Name, wh.URL, wh.Method, wh.Headers, wh.Body)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %q: %w", wh.Name, err)
+		}
+		notifiers = append(notifiers, w)
+	}
+	return notifiers, nil
+}
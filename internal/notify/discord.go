@@ -38,7 +38,7 @@ func (d *Discord) Send(ctx context.Context, alert Alert) error {
 
 	payload := discordPayload{
 		Embeds: []discordEmbed{{
-			Title:       fmt.Sprintf("[%s] %s: %s", alert.Level, alert.Event, alert.Service),
+			Title:       fmt.Sprintf("[%s] %s: %s", alert.Level, alert.Event, alertSubject(alert)),
 			Description: description,
 			Color:       color,
 			Timestamp:   alert.Timestamp.Format(time.RFC3339),
@@ -90,6 +90,15 @@ func colorForLevel(level string) int {
 	}
 }
 
+// alertSubject names what an alert is about: the service, prefixed by the machine
+// when the alert comes from the warden.
+func alertSubject(alert Alert) string {
+	if alert.Machine != "" {
+		return alert.Machine + "/" + alert.Service
+	}
+	return alert.Service
+}
+
 func shortDigest(digest string) string {
 	if len(digest) > 19 {
 		return digest[:19]
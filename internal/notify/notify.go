@@ -16,6 +16,7 @@ const (
 
 // Alert is the data passed to all notifiers.
 type Alert struct {
+	Machine   string // agent that raised the event; set by the warden, empty on agents
 	Service   string
 	Event     string // updated, rolled_back, unhealthy, restarted, critical, healthy, died
 	Message   string
@@ -23,6 +24,7 @@ type Alert struct {
 	OldDigest string
 	NewDigest string
 	Container string
+	Logs      string // last log lines of the failing container, when the service sets log_tail
 	Timestamp time.Time
 	Level     string // info, warning, critical
 }
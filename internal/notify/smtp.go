@@ -32,9 +32,12 @@ func NewSMTP(host string, port int, from, to, username, password string) *SMTPNo
 func (s *SMTPNotifier) Name() string { return "smtp" }
 
 func (s *SMTPNotifier) Send(_ context.Context, alert Alert) error {
-	subject := fmt.Sprintf("[watcher] [%s] %s: %s", alert.Level, alert.Event, alert.Service)
+	subject := fmt.Sprintf("[watcher] [%s] %s: %s", alert.Level, alert.Event, alertSubject(alert))
 
 	var body strings.Builder
+	if alert.Machine != "" {
+		body.WriteString(fmt.Sprintf("Machine: %s\n", alert.Machine))
+	}
 	body.WriteString(fmt.Sprintf("Service: %s\n", alert.Service))
 	body.WriteString(fmt.Sprintf("Event: %s\n", alert.Event))
 	body.WriteString(fmt.Sprintf("Container: %s\n", alert.Container))
@@ -49,6 +52,9 @@ func (s *SMTPNotifier) Send(_ context.Context, alert Alert) error {
 	if alert.NewDigest != "" {
 		body.WriteString(fmt.Sprintf("\nNew digest: %s", alert.NewDigest))
 	}
+	if alert.Logs != "" {
+		body.WriteString(fmt.Sprintf("\n\nContainer logs:\n%s", alert.Logs))
+	}
 
 	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
 		s.from, s.to, subject, body.String())
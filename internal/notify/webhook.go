@@ -23,6 +23,7 @@ type WebhookNotifier struct {
 
 // webhookData is the template context passed to body/header templates.
 type webhookData struct {
+	Machine   string
 	Service   string
 	Event     string
 	Message   string
@@ -30,6 +31,7 @@ type webhookData struct {
 	OldDigest string
 	NewDigest string
 	Container string
+	Logs      string
 	Timestamp string
 	Level     string
 }
@@ -61,6 +63,7 @@ func (w *WebhookNotifier) Name() string { return "webhook:" + w.name }
 
 func (w *WebhookNotifier) Send(ctx context.Context, alert Alert) error {
 	data := webhookData{
+		Machine:   alert.Machine,
 		Service:   alert.Service,
 		Event:     alert.Event,
 		Message:   alert.Message,
@@ -68,6 +71,7 @@ func (w *WebhookNotifier) Send(ctx context.Context, alert Alert) error {
 		OldDigest: alert.OldDigest,
 		NewDigest: alert.NewDigest,
 		Container: alert.Container,
+		Logs:      alert.Logs,
 		Timestamp: alert.Timestamp.Format(time.RFC3339),
 		Level:     alert.Level,
 	}
@@ -0,0 +1,104 @@
+// Package probe runs application-level health checks for containers without
+// a Docker HEALTHCHECK: an HTTP GET with an expected status and body, a TCP
+// connect, or a command executed inside the container.
+package probe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+)
+
+// maxBody bounds how much of an HTTP response is read for expect_body.
+const maxBody = 64 << 10
+
+// ExecFunc runs cmd inside the probed container and returns its exit code
+// and combined output.
+type ExecFunc func(ctx context.Context, cmd []string) (int, string, error)
+
+// client does not follow redirects: a probe checks the response of the
+// configured URL itself, and 3xx fails unless expect_status asks for it.
+var client = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// Run performs one probe attempt, bounded by p.Timeout. Returns nil when the
+// target is healthy, or an error describing the failure.
+func Run(ctx context.Context, p *config.Probe, exec ExecFunc) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(p.Timeout)*time.Second)
+	defer cancel()
+
+	switch {
+	case p.HTTP != "":
+		return checkHTTP(ctx, p.HTTP, p.ExpectStatus, p.ExpectBody)
+	case p.TCP != "":
+		return checkTCP(ctx, p.TCP)
+	case len(p.Exec) > 0:
+		return checkExec(ctx, p.Exec, exec)
+	default:
+		return fmt.Errorf("probe has no http, tcp or exec target")
+	}
+}
+
+func checkHTTP(ctx context.Context, url string, wantStatus int, wantBody string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	resp, err := client.Do(req) // #nosec G107 -- URL from local config file
+	if err != nil {
+		return fmt.Errorf("GET %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case wantStatus != 0 && resp.StatusCode != wantStatus:
+		return fmt.Errorf("GET %s: HTTP %d, want %d", url, resp.StatusCode, wantStatus)
+	case wantStatus == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299):
+		return fmt.Errorf("GET %s: HTTP %d", url, resp.StatusCode)
+	}
+	if wantBody == "" {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	if err != nil {
+		return fmt.Errorf("GET %s: read body: %w", url, err)
+	}
+	if !strings.Contains(string(body), wantBody) {
+		return fmt.Errorf("GET %s: body does not contain %q", url, wantBody)
+	}
+	return nil
+}
+
+func checkTCP(ctx context.Context, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("connect %s: %w", addr, err)
+	}
+	return conn.Close()
+}
+
+func checkExec(ctx context.Context, cmd []string, exec ExecFunc) error {
+	code, out, err := exec(ctx, cmd)
+	if err != nil {
+		return fmt.Errorf("exec %s: %w", cmd[0], err)
+	}
+	if code != 0 {
+		out = strings.TrimSpace(out)
+		if len(out) > 200 {
+			out = out[:200]
+		}
+		if out == "" {
+			return fmt.Errorf("exec %s: exit code %d", cmd[0], code)
+		}
+		return fmt.Errorf("exec %s: exit code %d: %s", cmd[0], code, out)
+	}
+	return nil
+}
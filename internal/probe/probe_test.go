@@ -0,0 +1,82 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/studiowebux/dockward/internal/config"
+)
+
+func TestRun_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte(`{"status":"ready"}`))
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		default:
+			http.Error(w, "down", http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		probe   config.Probe
+		wantErr string
+	}{
+		{"2xx", config.Probe{HTTP: server.URL + "/ok"}, ""},
+		{"body matches", config.Probe{HTTP: server.URL + "/ok", ExpectBody: `"ready"`}, ""},
+		{"body mismatch", config.Probe{HTTP: server.URL + "/ok", ExpectBody: "healthy"}, "body does not contain"},
+		{"5xx", config.Probe{HTTP: server.URL + "/down"}, "HTTP 503"},
+		{"expected 503", config.Probe{HTTP: server.URL + "/down", ExpectStatus: 503}, ""},
+		{"redirect not followed", config.Probe{HTTP: server.URL + "/moved"}, "HTTP 302"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.probe.Timeout = 2
+			err := Run(context.Background(), &tt.probe, nil)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("want healthy, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("want error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRun_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+
+	if err := Run(context.Background(), &config.Probe{TCP: addr, Timeout: 2}, nil); err != nil {
+		t.Errorf("listening port: want healthy, got %v", err)
+	}
+	ln.Close()
+	if err := Run(context.Background(), &config.Probe{TCP: addr, Timeout: 2}, nil); err == nil {
+		t.Error("closed port: want error, got nil")
+	}
+}
+
+func TestRun_Exec(t *testing.T) {
+	exec := func(code int, out string) ExecFunc {
+		return func(ctx context.Context, cmd []string) (int, string, error) { return code, out, nil }
+	}
+	p := &config.Probe{Exec: []string{"pg_isready", "-q"}, Timeout: 2}
+
+	if err := Run(context.Background(), p, exec(0, "")); err != nil {
+		t.Errorf("exit 0: want healthy, got %v", err)
+	}
+	err := Run(context.Background(), p, exec(2, "no response\n"))
+	if err == nil || !strings.Contains(err.Error(), "exit code 2: no response") {
+		t.Errorf("exit 2: want error with output, got %v", err)
+	}
+}
@@ -1,11 +1,12 @@
 // Package push forwards audit entries to a remote warden via HTTP.
-// The Client satisfies the audit.Pusher interface.
+// The Queue satisfies the audit.Pusher interface and delivers through a Client.
 package push
 
 import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -13,6 +14,18 @@ import (
 	"github.com/studiowebux/dockward/internal/audit"
 )
 
+// StatusError is returned when the warden answers with a non-2xx status.
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("push: warden returned %d", e.Code)
+}
+
+// errBatchUnsupported reports a warden without POST /ingest/batch.
+var errBatchUnsupported = errors.New("push: warden does not support batch ingest")
+
 // Client sends audit entries to a warden /ingest endpoint.
 type Client struct {
 	url     string
@@ -34,7 +47,6 @@ func New(url, token, machine string) *Client {
 
 // Send POSTs entry to <url>/ingest with a Bearer token header.
 // entry.Machine is set to c.machine before sending.
-// Callers should invoke Send in a goroutine (fire and forget).
 func (c *Client) Send(ctx context.Context, entry audit.Entry) error {
 	entry.Machine = c.machine
 
@@ -42,8 +54,33 @@ func (c *Client) Send(ctx context.Context, entry audit.Entry) error {
 	if err != nil {
 		return fmt.Errorf("push: marshal entry: %w", err)
 	}
+	return c.post(ctx, "/ingest", data)
+}
+
+// SendBatch POSTs entries, in order, as a JSON array to <url>/ingest/batch.
+// Machine is set on each entry. Returns errBatchUnsupported when the warden
+// predates the batch endpoint (404 or 405).
+func (c *Client) SendBatch(ctx context.Context, entries []audit.Entry) error {
+	batch := make([]audit.Entry, len(entries))
+	for i, e := range entries {
+		e.Machine = c.machine
+		batch[i] = e
+	}
+
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("push: marshal batch: %w", err)
+	}
+	err = c.post(ctx, "/ingest/batch", data)
+	var se *StatusError
+	if errors.As(err, &se) && (se.Code == http.StatusNotFound || se.Code == http.StatusMethodNotAllowed) {
+		return errBatchUnsupported
+	}
+	return err
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/ingest", bytes.NewReader(data))
+func (c *Client) post(ctx context.Context, path string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+path, bytes.NewReader(data))
 	if err != nil {
 		return fmt.Errorf("push: build request: %w", err)
 	}
@@ -57,7 +94,7 @@ func (c *Client) Send(ctx context.Context, entry audit.Entry) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("push: warden returned %d", resp.StatusCode)
+		return &StatusError{Code: resp.StatusCode}
 	}
 
 	return nil
@@ -0,0 +1,422 @@
+package push
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/studiowebux/dockward/internal/audit"
+	"github.com/studiowebux/dockward/internal/logger"
+)
+
+const (
+	batchSize  = 100             // entries per /ingest/batch request
+	batchBytes = 4 << 20         // encoded entries per request; the warden accepts 8 MB
+	minBackoff = time.Second     // first retry delay after a failed delivery
+	maxBackoff = 5 * time.Minute // retry delay cap
+
+	// maxOutputBytes caps the Output and Logs of a queued entry, keeping
+	// their end, so one entry stays under the warden's 1 MB /ingest limit.
+	maxOutputBytes = 256 << 10
+)
+
+// Status is a snapshot of the outbox, exposed in /health and /metrics.
+type Status struct {
+	Depth       int       `json:"queue_depth"`
+	Delivered   int64     `json:"delivered"`
+	Dropped     int64     `json:"dropped"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastSuccess time.Time `json:"last_success,omitzero"`
+}
+
+// Queue is an outbox of audit entries waiting for delivery to the warden.
+// Entries are delivered in order, in batches, by Run; a failed delivery is
+// retried with exponential backoff. With a path, the outbox is a JSON Lines
+// file so undelivered entries survive a restart.
+type Queue struct {
+	client *Client
+	path   string // empty = memory only
+	max    int    // oldest entries are dropped beyond this depth
+
+	mu          sync.Mutex
+	entries     []audit.Entry
+	first       int64 // sequence number of entries[0]; advances as entries leave
+	acked       int   // entries at the head of the file already delivered or dropped
+	delivered   int64
+	dropped     int64
+	lastErr     string
+	lastSuccess time.Time
+	wake        chan struct{}
+}
+
+// NewQueue creates an outbox delivering through client. Entries left in the
+// file at path by a previous run are loaded and delivered first.
+func NewQueue(client *Client, path string, limit int) (*Queue, error) {
+	q := &Queue{
+		client: client,
+		path:   path,
+		max:    limit,
+		wake:   make(chan struct{}, 1),
+	}
+	if path == "" {
+		return q, nil
+	}
+	entries, err := loadQueue(path)
+	if err != nil {
+		return nil, err
+	}
+	q.entries = entries[min(loadAcked(path), len(entries)):]
+	q.trim()
+	// Rewrite so a line torn by a crash is not merged with the next append.
+	if err := q.save(); err != nil {
+		return nil, fmt.Errorf("push queue %s: %w", path, err)
+	}
+	if len(q.entries) > 0 {
+		logger.Printf("[push] %d queued entries restored from %s", len(q.entries), path)
+	}
+	return q, nil
+}
+
+// loadQueue reads the entries of a queue file. A missing file is empty;
+// undecodable lines (e.g. a write torn by a crash) are skipped. Lines are
+// not length-limited: a long entry must not keep the agent from starting.
+func loadQueue(path string) ([]audit.Entry, error) {
+	f, err := os.Open(path) // #nosec G304 -- path from config, not user input
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open push queue %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var entries []audit.Entry
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var e audit.Entry
+			if uerr := json.Unmarshal(line, &e); uerr != nil {
+				logger.Printf("[push] skipping unreadable queue entry: %v", uerr)
+			} else {
+				entries = append(entries, e)
+			}
+		}
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read push queue %s: %w", path, err)
+		}
+	}
+}
+
+// ackedPath is the file holding the number of entries at the head of the
+// queue file that left the queue since it was last rewritten.
+func ackedPath(path string) string { return path + ".acked" }
+
+// loadAcked reads the acked count of a queue file; 0 when absent or unreadable.
+func loadAcked(path string) int {
+	data, err := os.ReadFile(ackedPath(path)) // #nosec G304 -- path from config, not user input
+	if err != nil {
+		return 0
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// Send queues entry for delivery and returns without waiting for the warden.
+// Implements audit.Pusher; the audit logger calls it in write order.
+func (q *Queue) Send(_ context.Context, entry audit.Entry) error {
+	entry.Output = truncateHead(entry.Output, maxOutputBytes)
+	entry.Logs = truncateHead(entry.Logs, maxOutputBytes)
+
+	q.mu.Lock()
+	q.entries = append(q.entries, entry)
+	trimmed := q.trim()
+	var err error
+	if trimmed {
+		err = q.save()
+	} else {
+		err = q.appendFile(entry)
+	}
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return err
+}
+
+// truncateHead returns the last n bytes of s, cut at a rune boundary and
+// marked, or s when it is shorter.
+func truncateHead(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	i := len(s) - n
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return "[truncated]\n" + s[i:]
+}
+
+// trim drops the oldest entries beyond max, plus a tenth of max so a full
+// queue is compacted, and its file rewritten, once every max/10 entries
+// rather than on every Send. Caller holds q.mu.
+func (q *Queue) trim() bool {
+	over := len(q.entries) - q.max
+	if q.max <= 0 || over <= 0 {
+		return false
+	}
+	over = min(over+q.max/10, len(q.entries))
+	q.entries = append([]audit.Entry(nil), q.entries[over:]...)
+	q.first += int64(over)
+	q.dropped += int64(over)
+	logger.Printf("[push] queue full, dropped %d oldest entries", over)
+	return true
+}
+
+// Status returns the current outbox state.
+func (q *Queue) Status() Status {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return Status{
+		Depth:       len(q.entries),
+		Delivered:   q.delivered,
+		Dropped:     q.dropped,
+		LastError:   q.lastErr,
+		LastSuccess: q.lastSuccess,
+	}
+}
+
+// Run delivers queued entries until ctx is cancelled. Blocks.
+func (q *Queue) Run(ctx context.Context) {
+	var backoff time.Duration
+	size := batchSize
+	for {
+		q.mu.Lock()
+		start := q.first
+		batch := nextBatch(q.entries, size)
+		q.mu.Unlock()
+
+		if len(batch) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+			}
+			continue
+		}
+
+		err := q.deliver(ctx, start, batch)
+		if ctx.Err() != nil {
+			return
+		}
+		if tooLarge(err) && len(batch) > 1 {
+			size = len(batch) / 2
+			logger.Printf("[push] warden rejected %d entries as too large, retrying %d at a time", len(batch), size)
+			continue
+		}
+		if err == nil || permanent(err) {
+			q.ack(start, len(batch), err)
+			backoff = 0
+			size = batchSize
+			continue
+		}
+
+		backoff = min(max(2*backoff, minBackoff), maxBackoff)
+		q.mu.Lock()
+		q.lastErr = err.Error()
+		depth := len(q.entries)
+		q.mu.Unlock()
+		logger.Printf("[push] delivery failed, %d entries queued, retrying in %s: %v", depth, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// nextBatch copies the first entries of the queue: at most n, and no more
+// than batchBytes once encoded, but always at least one.
+func nextBatch(entries []audit.Entry, n int) []audit.Entry {
+	var batch []audit.Entry
+	total := 0
+	for _, e := range entries[:min(n, len(entries))] {
+		data, err := json.Marshal(e)
+		if err == nil {
+			total += len(data)
+		}
+		if len(batch) > 0 && total > batchBytes {
+			break
+		}
+		batch = append(batch, e)
+	}
+	return batch
+}
+
+// deliver sends batch to the warden, one entry at a time when the warden
+// has no batch endpoint.
+func (q *Queue) deliver(ctx context.Context, start int64, batch []audit.Entry) error {
+	err := q.client.SendBatch(ctx, batch)
+	if !errors.Is(err, errBatchUnsupported) {
+		return err
+	}
+	for i, e := range batch {
+		if err := q.client.Send(ctx, e); err != nil {
+			// Entries before i were accepted; drop them so they are not resent.
+			if i > 0 {
+				q.ack(start, i, nil)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// permanent reports a rejection that retrying cannot fix (malformed entries,
+// or a single entry too large). The batch is dropped so it does not block
+// the queue; Run splits a larger batch rejected as too large instead.
+func permanent(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && (se.Code == http.StatusBadRequest || se.Code == http.StatusRequestEntityTooLarge)
+}
+
+func tooLarge(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.Code == http.StatusRequestEntityTooLarge
+}
+
+// ack removes the n entries starting at sequence number start after delivery,
+// or after a permanent rejection (err non-nil), which counts them as dropped.
+// Entries trimmed from a full queue meanwhile are already gone.
+//
+// The queue file is not rewritten on every ack: the count of acked entries
+// at its head is recorded instead, and the file is compacted once they are
+// at least as many as the entries left, or none is left.
+func (q *Queue) ack(start int64, n int, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n = min(n-int(q.first-start), len(q.entries))
+	if n <= 0 {
+		return
+	}
+	q.entries = q.entries[n:]
+	q.first += int64(n)
+	q.acked += n
+	if err != nil {
+		q.dropped += int64(n)
+		q.lastErr = err.Error()
+		logger.Printf("[push] warden rejected %d entries, dropping them: %v", n, err)
+	} else {
+		q.delivered += int64(n)
+		q.lastErr = ""
+		q.lastSuccess = time.Now()
+	}
+	var serr error
+	if len(q.entries) == 0 || q.acked >= len(q.entries) {
+		serr = q.save()
+	} else {
+		serr = q.saveAcked()
+	}
+	if serr != nil {
+		logger.Printf("[push] save queue: %v", serr)
+	}
+}
+
+// saveAcked records the acked count of the queue file. Caller holds q.mu.
+func (q *Queue) saveAcked() error {
+	if q.path == "" {
+		return nil
+	}
+	return os.WriteFile(ackedPath(q.path), []byte(strconv.Itoa(q.acked)), 0o600)
+}
+
+// appendFile adds entry to the queue file. Caller holds q.mu.
+func (q *Queue) appendFile(entry audit.Entry) error {
+	if q.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("push: marshal entry: %w", err)
+	}
+	f, err := os.OpenFile(q.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) // #nosec G304 -- path from config, not user input
+	if err != nil {
+		return fmt.Errorf("push: open queue: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("push: write queue: %w", err)
+	}
+	return f.Close()
+}
+
+// save rewrites the queue file atomically with the current entries.
+// Caller holds q.mu.
+func (q *Queue) save() error {
+	if q.path == "" {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), ".dockward-push-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, e := range q.entries {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			os.Remove(tmpName) // #nosec G104 — best-effort cleanup
+			return fmt.Errorf("write temp file: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName) // #nosec G104 — best-effort cleanup
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	// fsync before rename so a crash cannot leave a renamed but empty file.
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName) // #nosec G104 — best-effort cleanup
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName) // #nosec G104 — best-effort cleanup
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	// Drop the acked count first: a crash before the rename then resends
+	// acked entries rather than skipping unacked ones.
+	if err := os.Remove(ackedPath(q.path)); err != nil && !os.IsNotExist(err) {
+		os.Remove(tmpName) // #nosec G104 — best-effort cleanup
+		return fmt.Errorf("remove acked count: %w", err)
+	}
+	if err := os.Rename(tmpName, q.path); err != nil {
+		os.Remove(tmpName) // #nosec G104 — best-effort cleanup
+		return fmt.Errorf("atomic rename: %w", err)
+	}
+	q.acked = 0
+	return nil
+}
@@ -0,0 +1,311 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/audit"
+)
+
+// wardenStub records delivered entries. While down, every request fails with 503.
+type wardenStub struct {
+	mu       sync.Mutex
+	down     bool
+	noBatch  bool
+	maxBatch int // larger batches are rejected with 413; 0 = unlimited
+	received []audit.Entry
+	batches  int
+}
+
+func (s *wardenStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	switch r.URL.Path {
+	case "/ingest/batch":
+		if s.noBatch {
+			http.NotFound(w, r)
+			return
+		}
+		var entries []audit.Entry
+		_ = json.NewDecoder(r.Body).Decode(&entries)
+		if s.maxBatch > 0 && len(entries) > s.maxBatch {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		s.received = append(s.received, entries...)
+		s.batches++
+	case "/ingest":
+		var e audit.Entry
+		_ = json.NewDecoder(r.Body).Decode(&e)
+		s.received = append(s.received, e)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *wardenStub) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]string, len(s.received))
+	for i, e := range s.received {
+		out[i] = e.Message
+	}
+	return out
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestQueue_DeliversInOrderAfterOutage(t *testing.T) {
+	stub := &wardenStub{down: true}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	q, err := NewQueue(New(srv.URL, "tok", "m1"), "", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	for _, msg := range []string{"a", "b", "c"} {
+		if err := q.Send(ctx, audit.Entry{Message: msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor(t, func() bool { return q.Status().LastError != "" })
+	if got := q.Status().Depth; got != 3 {
+		t.Fatalf("want 3 queued entries while warden is down, got %d", got)
+	}
+
+	stub.mu.Lock()
+	stub.down = false
+	stub.mu.Unlock()
+
+	waitFor(t, func() bool { return q.Status().Depth == 0 })
+	if got := stub.messages(); len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Fatalf("want a,b,c in order, got %v", got)
+	}
+	st := q.Status()
+	if st.Delivered != 3 || st.LastError != "" || st.LastSuccess.IsZero() {
+		t.Errorf("unexpected status after delivery: %+v", st)
+	}
+	stub.mu.Lock()
+	machine := stub.received[0].Machine
+	stub.mu.Unlock()
+	if machine != "m1" {
+		t.Errorf("Machine: got %q, want m1", machine)
+	}
+}
+
+func TestQueue_FallsBackWithoutBatchEndpoint(t *testing.T) {
+	stub := &wardenStub{noBatch: true}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	q, _ := NewQueue(New(srv.URL, "tok", "m1"), "", 100)
+	_ = q.Send(context.Background(), audit.Entry{Message: "a"})
+	_ = q.Send(context.Background(), audit.Entry{Message: "b"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	waitFor(t, func() bool { return q.Status().Depth == 0 })
+	if got := stub.messages(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("want a,b via /ingest, got %v", got)
+	}
+}
+
+func TestQueue_PersistsAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "push-queue.jsonl")
+	q, err := NewQueue(New("http://127.0.0.1:1", "tok", "m1"), path, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"a", "b"} {
+		if err := q.Send(context.Background(), audit.Entry{Message: msg}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stub := &wardenStub{}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	restarted, err := NewQueue(New(srv.URL, "tok", "m1"), path, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := restarted.Status().Depth; got != 2 {
+		t.Fatalf("want 2 entries restored, got %d", got)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go restarted.Run(ctx)
+
+	waitFor(t, func() bool { return restarted.Status().Depth == 0 })
+	if got := stub.messages(); len(got) != 2 || got[0] != "a" {
+		t.Fatalf("want restored entries delivered, got %v", got)
+	}
+	entries, err := loadQueue(path)
+	if err != nil || len(entries) != 0 {
+		t.Errorf("want empty queue file after delivery, got %d entries (err %v)", len(entries), err)
+	}
+}
+
+func TestQueue_DropsOldestWhenFull(t *testing.T) {
+	q, _ := NewQueue(New("http://127.0.0.1:1", "tok", "m1"), "", 2)
+	for _, msg := range []string{"a", "b", "c"} {
+		_ = q.Send(context.Background(), audit.Entry{Message: msg})
+	}
+	st := q.Status()
+	if st.Depth != 2 || st.Dropped != 1 {
+		t.Fatalf("want depth 2 and 1 dropped, got %+v", st)
+	}
+	if q.entries[0].Message != "b" {
+		t.Errorf("want oldest entry dropped, queue starts with %q", q.entries[0].Message)
+	}
+}
+
+func TestQueue_CompactsFullQueueInBatches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.jsonl")
+	q, err := NewQueue(New("http://127.0.0.1:1", "tok", "m1"), path, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 101 {
+		_ = q.Send(context.Background(), audit.Entry{Message: strconv.Itoa(i)})
+	}
+	if st := q.Status(); st.Depth != 90 || st.Dropped != 11 {
+		t.Fatalf("want a tenth of the queue dropped at once, got %+v", st)
+	}
+
+	// The next entries are appended until the queue is full again.
+	for i := 101; i < 111; i++ {
+		_ = q.Send(context.Background(), audit.Entry{Message: strconv.Itoa(i)})
+	}
+	if st := q.Status(); st.Depth != 100 || st.Dropped != 11 {
+		t.Fatalf("want no drop below queue_size, got %+v", st)
+	}
+	entries, err := loadQueue(path)
+	if err != nil || len(entries) != 100 || entries[0].Message != "11" || entries[99].Message != "110" {
+		t.Errorf("want the file to match the queue, got %d entries (err %v)", len(entries), err)
+	}
+}
+
+func TestQueue_LoadsLongEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.jsonl")
+	long, _ := json.Marshal(audit.Entry{Message: "long", Output: strings.Repeat("x", 2<<20)})
+	data := append(append(long, '\n'), `{"message":"next"}`+"\n"...)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := NewQueue(New("http://127.0.0.1:1", "tok", "m1"), path, 100)
+	if err != nil {
+		t.Fatalf("NewQueue: %v", err)
+	}
+	if len(q.entries) != 2 || q.entries[0].Message != "long" || q.entries[1].Message != "next" {
+		t.Fatalf("want both entries restored, got %d", len(q.entries))
+	}
+}
+
+func TestQueue_CapsOutputAndLogs(t *testing.T) {
+	q, _ := NewQueue(New("http://127.0.0.1:1", "tok", "m1"), "", 100)
+	_ = q.Send(context.Background(), audit.Entry{Output: strings.Repeat("a", 2<<20) + "tail", Logs: "short"})
+
+	e := q.entries[0]
+	if len(e.Output) > maxOutputBytes+len("[truncated]\n") || !strings.HasPrefix(e.Output, "[truncated]") || !strings.HasSuffix(e.Output, "tail") {
+		t.Errorf("want output truncated to its end, got %d bytes", len(e.Output))
+	}
+	if e.Logs != "short" {
+		t.Errorf("short logs should be kept, got %q", e.Logs)
+	}
+}
+
+func TestQueue_SplitsBatchRejectedAsTooLarge(t *testing.T) {
+	stub := &wardenStub{maxBatch: 2}
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	q, _ := NewQueue(New(srv.URL, "tok", "m1"), "", 100)
+	for _, msg := range []string{"a", "b", "c", "d", "e"} {
+		_ = q.Send(context.Background(), audit.Entry{Message: msg})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	waitFor(t, func() bool { return q.Status().Depth == 0 })
+	if got := stub.messages(); strings.Join(got, "") != "abcde" {
+		t.Fatalf("want every entry delivered in order, got %v", got)
+	}
+	if st := q.Status(); st.Dropped != 0 || st.Delivered != 5 {
+		t.Errorf("want nothing dropped, got %+v", st)
+	}
+}
+
+func TestNextBatch_LimitsBytes(t *testing.T) {
+	big := audit.Entry{Output: strings.Repeat("x", maxOutputBytes)}
+	entries := make([]audit.Entry, 40)
+	for i := range entries {
+		entries[i] = big
+	}
+	if got := len(nextBatch(entries, batchSize)); got != batchBytes/maxOutputBytes-1 {
+		t.Errorf("want the batch cut at %d bytes, got %d entries", batchBytes, got)
+	}
+	if got := len(nextBatch([]audit.Entry{{Output: strings.Repeat("x", 2*batchBytes)}}, batchSize)); got != 1 {
+		t.Errorf("want a single oversized entry sent alone, got %d entries", got)
+	}
+}
+
+func TestQueue_AckRecordsHeadInsteadOfRewriting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.jsonl")
+	q, _ := NewQueue(New("http://127.0.0.1:1", "tok", "m1"), path, 100)
+	for _, msg := range []string{"a", "b", "c", "d", "e"} {
+		_ = q.Send(context.Background(), audit.Entry{Message: msg})
+	}
+
+	q.ack(0, 2, nil)
+	if entries, _ := loadQueue(path); len(entries) != 5 {
+		t.Fatalf("want the file kept while few entries are acked, got %d entries", len(entries))
+	}
+	restarted, err := NewQueue(New("http://127.0.0.1:1", "tok", "m1"), path, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restarted.entries) != 3 || restarted.entries[0].Message != "c" {
+		t.Fatalf("want acked entries skipped after restart, got %+v", restarted.entries)
+	}
+
+	restarted.ack(0, 2, nil)
+	entries, _ := loadQueue(path)
+	if len(entries) != 1 || entries[0].Message != "e" {
+		t.Errorf("want the file compacted once most entries are acked, got %d entries", len(entries))
+	}
+	if _, err := os.Stat(ackedPath(path)); !os.IsNotExist(err) {
+		t.Errorf("want the acked count removed after compaction, got %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Credentials authenticate against a registry. The zero value is anonymous.
+type Credentials struct {
+	Username string
+	Password string // #nosec G117 -- registry credential, not a secret leak
+}
+
+// anonymous reports whether no credentials are set.
+func (c Credentials) anonymous() bool {
+	return c.Username == "" && c.Password == ""
+}
+
+// tokenExpiryMargin is subtracted from a token's lifetime so it is refreshed
+// before the registry starts rejecting it.
+const tokenExpiryMargin = 10 * time.Second
+
+// defaultTokenLifetime applies when the token server omits expires_in.
+// The distribution spec mandates a minimum of 60 seconds.
+const defaultTokenLifetime = 60 * time.Second
+
+// cachedToken is a bearer token scoped to one repository action.
+type cachedToken struct {
+	value   string
+	expires time.Time
+}
+
+// challenge is a parsed WWW-Authenticate header.
+type challenge struct {
+	scheme string            // "basic" or "bearer", lowercased
+	params map[string]string // realm, service, scope, ...
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// `Bearer realm="https://auth.example.com/token",service="registry"`.
+func parseChallenge(header string) challenge {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	ch := challenge{scheme: strings.ToLower(scheme), params: make(map[string]string)}
+	for rest != "" {
+		rest = strings.TrimLeft(rest, ", ")
+		key, after, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		var val string
+		if strings.HasPrefix(after, `"`) {
+			after = after[1:]
+			end := strings.Index(after, `"`)
+			if end < 0 {
+				val, rest = after, ""
+			} else {
+				val, rest = after[:end], after[end+1:]
+			}
+		} else {
+			val, rest, _ = strings.Cut(after, ",")
+		}
+		ch.params[key] = strings.TrimSpace(val)
+	}
+	return ch
+}
+
+// pullScope returns the token scope needed to read a repository.
+func pullScope(name string) string {
+	return "repository:" + name + ":pull"
+}
+
+// do sends a request built by build, authenticating as required.
+// A cached bearer token for scope (or basic credentials once the registry has
+// asked for them) is attached up front. On 401 the challenge is answered once
+// and the request retried. build is called per attempt so bodies are fresh.
+func (c *Client) do(ctx context.Context, scope string, build func() (*http.Request, error)) (*http.Response, error) {
+	req, err := build()
+	if err != nil {
+		return nil, err
+	}
+	c.authorize(req, scope)
+
+	resp, err := c.http.Do(req) // #nosec G704 -- URL built from configured registry
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	ch := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	drain(resp)
+
+	switch ch.scheme {
+	case "basic":
+		if c.creds.anonymous() {
+			return nil, fmt.Errorf("registry requires basic auth but no credentials are configured")
+		}
+		c.authMu.Lock()
+		c.useBasic = true
+		c.authMu.Unlock()
+	case "bearer":
+		if err := c.fetchToken(ctx, ch, scope); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported auth challenge %q", ch.scheme)
+	}
+
+	req, err = build()
+	if err != nil {
+		return nil, err
+	}
+	c.authorize(req, scope)
+	return c.http.Do(req) // #nosec G704 -- URL built from configured registry
+}
+
+// authorize attaches the best known credential for scope to req.
+func (c *Client) authorize(req *http.Request, scope string) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	if tok, ok := c.tokens[scope]; ok && time.Now().Before(tok.expires) {
+		req.Header.Set("Authorization", "Bearer "+tok.value)
+		return
+	}
+	if c.useBasic {
+		req.SetBasicAuth(c.creds.Username, c.creds.Password)
+	}
+}
+
+// fetchToken requests a bearer token from the realm in ch and caches it for scope.
+// Credentials, when configured, are sent as basic auth to the token server;
+// otherwise an anonymous token is requested (public repositories).
+func (c *Client) fetchToken(ctx context.Context, ch challenge, scope string) error {
+	realm := ch.params["realm"]
+	if realm == "" {
+		return fmt.Errorf("bearer challenge without realm")
+	}
+	u, err := url.Parse(realm)
+	if err != nil {
+		return fmt.Errorf("parse token realm: %w", err)
+	}
+	q := u.Query()
+	if svc := ch.params["service"]; svc != "" {
+		q.Set("service", svc)
+	}
+	q.Set("scope", scope)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("create token request: %w", err)
+	}
+	if !c.creds.anonymous() {
+		req.SetBasicAuth(c.creds.Username, c.creds.Password)
+	}
+
+	resp, err := c.http.Do(req) // #nosec G704 -- realm advertised by the configured registry
+	if err != nil {
+		return fmt.Errorf("GET token %s: %w", realm, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET token %s: HTTP %d", realm, resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return fmt.Errorf("decode token response: %w", err)
+	}
+	value := body.Token
+	if value == "" {
+		value = body.AccessToken
+	}
+	if value == "" {
+		return fmt.Errorf("token server returned no token")
+	}
+	lifetime := defaultTokenLifetime
+	if body.ExpiresIn > 0 {
+		lifetime = time.Duration(body.ExpiresIn) * time.Second
+	}
+
+	c.authMu.Lock()
+	c.tokens[scope] = cachedToken{value: value, expires: time.Now().Add(lifetime - tokenExpiryMargin)}
+	c.authMu.Unlock()
+	return nil
+}
+
+// drain discards and closes a response body so the connection can be reused.
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
+
+// LoadDockerConfig reads credentials for registryURL from a docker
+// config.json ("auths" section). Entries may be keyed by bare host or by URL.
+// Credential helpers (credsStore/credHelpers) are not supported.
+func LoadDockerConfig(path, registryURL string) (Credentials, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path from config file, not network input
+	if err != nil {
+		return Credentials{}, fmt.Errorf("read docker config: %w", err)
+	}
+	var dc struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"` // #nosec G117 -- registry credential, not a secret leak
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &dc); err != nil {
+		return Credentials{}, fmt.Errorf("parse docker config: %w", err)
+	}
+
+	host := hostOf(registryURL)
+	for key, entry := range dc.Auths {
+		if hostOf(key) != host {
+			continue
+		}
+		if entry.Auth != "" {
+			raw, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return Credentials{}, fmt.Errorf("decode auth for %s: %w", key, err)
+			}
+			user, pass, ok := strings.Cut(string(raw), ":")
+			if !ok {
+				return Credentials{}, fmt.Errorf("malformed auth for %s", key)
+			}
+			return Credentials{Username: user, Password: pass}, nil
+		}
+		return Credentials{Username: entry.Username, Password: entry.Password}, nil
+	}
+	return Credentials{}, fmt.Errorf("no credentials for %s in %s", host, path)
+}
+
+// hostOf reduces "https://host:port/v1/" or "host:port" to "host:port".
+func hostOf(s string) string {
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	}
+	host, _, _ := strings.Cut(s, "/")
+	return host
+}
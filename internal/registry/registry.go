@@ -1,5 +1,6 @@
 // Package registry checks a Docker registry (Distribution HTTP API v2)
-// for image digest changes.
+// for image digest changes. Basic auth and the bearer token flow
+// (WWW-Authenticate challenge) are handled transparently.
 package registry
 
 import (
@@ -8,6 +9,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,10 +17,19 @@ import (
 type Client struct {
 	baseURL string
 	http    *http.Client
+	creds   Credentials
+
+	// authMu guards tokens and useBasic.
+	// tokens caches bearer tokens keyed by scope ("repository:<name>:pull").
+	// useBasic is set once the registry answered with a Basic challenge.
+	authMu   sync.Mutex
+	tokens   map[string]cachedToken
+	useBasic bool
 }
 
 // NewClient creates a registry client for the given base URL (e.g., http://localhost:5000).
-func NewClient(baseURL string, insecure bool) *Client {
+// creds may be the zero value for anonymous access.
+func NewClient(baseURL string, insecure bool, creds Credentials) *Client {
 	transport := &http.Transport{
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: insecure,
@@ -31,6 +42,8 @@ func NewClient(baseURL string, insecure bool) *Client {
 			Timeout:   10 * time.Second,
 			Transport: transport,
 		},
+		creds:  creds,
+		tokens: make(map[string]cachedToken),
 	}
 }
 
@@ -41,26 +54,30 @@ func (c *Client) RemoteDigest(ctx context.Context, image string) (string, error)
 	name, tag := parseRef(image)
 
 	url := fmt.Sprintf("%s/v2/%s/manifests/%s", c.baseURL, name, tag)
-	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
-	if err != nil {
-		return "", fmt.Errorf("create request: %w", err)
-	}
+	resp, err := c.do(ctx, pullScope(name), func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("create request: %w", err)
+		}
 
-	// Accept both Docker v2 and OCI manifest types so the registry
-	// can return whichever format the image was pushed with.
-	req.Header.Set("Accept", strings.Join([]string{
-		"application/vnd.docker.distribution.manifest.v2+json",
-		"application/vnd.oci.image.manifest.v1+json",
-		"application/vnd.oci.image.index.v1+json",
-		"application/vnd.docker.distribution.manifest.list.v2+json",
-	}, ", "))
-
-	resp, err := c.http.Do(req) // #nosec G704 -- localhost registry only
+		// Accept both Docker v2 and OCI manifest types so the registry
+		// can return whichever format the image was pushed with.
+		req.Header.Set("Accept", strings.Join([]string{
+			"application/vnd.docker.distribution.manifest.v2+json",
+			"application/vnd.oci.image.manifest.v1+json",
+			"application/vnd.oci.image.index.v1+json",
+			"application/vnd.docker.distribution.manifest.list.v2+json",
+		}, ", "))
+		return req, nil
+	})
 	if err != nil {
 		return "", fmt.Errorf("HEAD %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		return "", fmt.Errorf("HEAD %s: unauthorized (check registry credentials)", url)
+	}
 	if resp.StatusCode == http.StatusNotFound {
 		return "", fmt.Errorf("image %s:%s not found in registry", name, tag)
 	}
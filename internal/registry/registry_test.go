@@ -0,0 +1,219 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+const testDigest = "sha256:abc123"
+
+// manifestHandler answers manifest HEAD requests with testDigest when
+// authorized reports true, otherwise with a 401 carrying challenge.
+func manifestHandler(challenge string, authorized func(*http.Request) bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r) {
+			w.Header().Set("WWW-Authenticate", challenge)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", testDigest)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestRemoteDigest_Anonymous(t *testing.T) {
+	srv := httptest.NewServer(manifestHandler("", func(*http.Request) bool { return true }))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, false, Credentials{})
+	got, err := c.RemoteDigest(context.Background(), "app:latest")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != testDigest {
+		t.Errorf("digest: got %q, want %q", got, testDigest)
+	}
+}
+
+func TestRemoteDigest_BasicChallenge(t *testing.T) {
+	srv := httptest.NewServer(manifestHandler(`Basic realm="registry"`, func(r *http.Request) bool {
+		user, pass, ok := r.BasicAuth()
+		return ok && user == "bob" && pass == "hunter2"
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, false, Credentials{Username: "bob", Password: "hunter2"})
+	got, err := c.RemoteDigest(context.Background(), "app:latest")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != testDigest {
+		t.Errorf("digest: got %q, want %q", got, testDigest)
+	}
+}
+
+func TestRemoteDigest_BasicChallengeWithoutCredentials(t *testing.T) {
+	srv := httptest.NewServer(manifestHandler(`Basic realm="registry"`, func(*http.Request) bool { return false }))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, false, Credentials{})
+	if _, err := c.RemoteDigest(context.Background(), "app:latest"); err == nil {
+		t.Fatal("want error without credentials, got nil")
+	}
+}
+
+func TestRemoteDigest_BearerTokenFlowIsCached(t *testing.T) {
+	var tokenCalls atomic.Int32
+	var gotScope, gotService string
+
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokenCalls.Add(1)
+		if user, pass, ok := r.BasicAuth(); !ok || user != "bob" || pass != "hunter2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		gotScope = r.URL.Query().Get("scope")
+		gotService = r.URL.Query().Get("service")
+		_ = json.NewEncoder(w).Encode(map[string]any{"token": "tok-1", "expires_in": 300})
+	}))
+	defer tokenSrv.Close()
+
+	challenge := `Bearer realm="` + tokenSrv.URL + `/token",service="test-registry"`
+	srv := httptest.NewServer(manifestHandler(challenge, func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer tok-1"
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, false, Credentials{Username: "bob", Password: "hunter2"})
+	for i := 0; i < 3; i++ {
+		got, err := c.RemoteDigest(context.Background(), "team/app:1.0")
+		if err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+		if got != testDigest {
+			t.Errorf("call %d: digest: got %q, want %q", i, got, testDigest)
+		}
+	}
+
+	if n := tokenCalls.Load(); n != 1 {
+		t.Errorf("token server calls: got %d, want 1 (token should be cached)", n)
+	}
+	if gotScope != "repository:team/app:pull" {
+		t.Errorf("scope: got %q, want %q", gotScope, "repository:team/app:pull")
+	}
+	if gotService != "test-registry" {
+		t.Errorf("service: got %q, want %q", gotService, "test-registry")
+	}
+}
+
+func TestRemoteDigest_BearerTokenRejected(t *testing.T) {
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer tokenSrv.Close()
+
+	srv := httptest.NewServer(manifestHandler(`Bearer realm="`+tokenSrv.URL+`"`, func(*http.Request) bool { return false }))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, false, Credentials{Username: "bob", Password: "wrong"})
+	if _, err := c.RemoteDigest(context.Background(), "app:latest"); err == nil {
+		t.Fatal("want error when token server rejects credentials, got nil")
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	ch := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:app:pull,push"`)
+	if ch.scheme != "bearer" {
+		t.Errorf("scheme: got %q, want %q", ch.scheme, "bearer")
+	}
+	want := map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:app:pull,push",
+	}
+	for k, v := range want {
+		if ch.params[k] != v {
+			t.Errorf("%s: got %q, want %q", k, ch.params[k], v)
+		}
+	}
+}
+
+func TestLoadDockerConfig(t *testing.T) {
+	auth := base64.StdEncoding.EncodeToString([]byte("bob:hunter2"))
+	path := filepath.Join(t.TempDir(), "config.json")
+	data := `{"auths": {
+		"https://registry.example.com/v1/": {"auth": "` + auth + `"},
+		"other.example.com:5000": {"username": "alice", "password": "s3cret"}
+	}}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url     string
+		want    Credentials
+		wantErr bool
+	}{
+		{"https://registry.example.com", Credentials{Username: "bob", Password: "hunter2"}, false},
+		{"http://other.example.com:5000", Credentials{Username: "alice", Password: "s3cret"}, false},
+		{"https://unknown.example.com", Credentials{}, true},
+	}
+	for _, tt := range tests {
+		got, err := LoadDockerConfig(path, tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestTags_FollowsLinkPagination(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/org/app/tags/list" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</v2/org/app/tags/list?last=1.1.0&n=2>; rel="next"`)
+			json.NewEncoder(w).Encode(map[string]any{"name": "org/app", "tags": []string{"1.0.0", "1.1.0"}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"name": "org/app", "tags": []string{"1.2.0"}})
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, false, Credentials{})
+	got, err := c.Tags(context.Background(), "org/app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"1.0.0", "1.1.0", "1.2.0"}
+	if len(got) != len(want) {
+		t.Fatalf("tags: got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("tags[%d]: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTags_RepositoryNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	c := NewClient(srv.URL, false, Credentials{})
+	if _, err := c.Tags(context.Background(), "missing"); err == nil {
+		t.Error("expected error for unknown repository")
+	}
+}
@@ -0,0 +1,81 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxTagPages bounds Link pagination so a misbehaving registry cannot loop us.
+const maxTagPages = 100
+
+// Tags returns every tag of a repository (name without registry prefix,
+// e.g. "org/app"), following Link pagination of the tags/list endpoint.
+func (c *Client) Tags(ctx context.Context, name string) ([]string, error) {
+	var tags []string
+	next := fmt.Sprintf("%s/v2/%s/tags/list?n=1000", c.baseURL, name)
+
+	for page := 0; next != ""; page++ {
+		if page == maxTagPages {
+			return nil, fmt.Errorf("tags list %s: more than %d pages", name, maxTagPages)
+		}
+		url := next
+		resp, err := c.do(ctx, pullScope(name), func() (*http.Request, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, fmt.Errorf("create request: %w", err)
+			}
+			req.Header.Set("Accept", "application/json")
+			return req, nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("GET %s: %w", url, err)
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+		case http.StatusUnauthorized:
+			resp.Body.Close()
+			return nil, fmt.Errorf("GET %s: unauthorized (check registry credentials)", url)
+		case http.StatusNotFound:
+			resp.Body.Close()
+			return nil, fmt.Errorf("repository %s not found in registry", name)
+		default:
+			resp.Body.Close()
+			return nil, fmt.Errorf("GET %s: HTTP %d", url, resp.StatusCode)
+		}
+
+		var body struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(io.LimitReader(resp.Body, 8<<20)).Decode(&body)
+		link := resp.Header.Get("Link")
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode tags list %s: %w", name, err)
+		}
+		tags = append(tags, body.Tags...)
+		next = c.nextLink(link)
+	}
+	return tags, nil
+}
+
+// nextLink extracts the rel="next" target of a Link header
+// (`</v2/app/tags/list?last=x&n=1000>; rel="next"`), resolved against baseURL.
+func (c *Client) nextLink(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok || !strings.Contains(strings.ReplaceAll(params, " ", ""), `rel="next"`) {
+			continue
+		}
+		target = strings.Trim(strings.TrimSpace(target), "<>")
+		if strings.HasPrefix(target, "/") {
+			return c.baseURL + target
+		}
+		return target
+	}
+	return ""
+}
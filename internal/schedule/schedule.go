@@ -0,0 +1,110 @@
+// Package schedule evaluates weekly deploy windows: weekday sets with a
+// daily HH:MM time range, in the host's local time zone.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a parsed deploy window.
+type Window struct {
+	days       [7]bool // indexed by time.Weekday
+	start, end int     // minutes since midnight; end <= start wraps past midnight
+}
+
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWindow parses a window. days holds three-letter weekday names
+// ("mon".."sun", case-insensitive); empty means every day. start and end are
+// "HH:MM"; an end at or before start spans midnight ("22:00"-"02:00"), and
+// the day refers to the day the window opens.
+func ParseWindow(days []string, start, end string) (Window, error) {
+	var w Window
+	if len(days) == 0 {
+		for i := range w.days {
+			w.days[i] = true
+		}
+	}
+	for _, d := range days {
+		wd, ok := dayNames[strings.ToLower(strings.TrimSpace(d))]
+		if !ok {
+			return Window{}, fmt.Errorf("invalid day %q: use mon, tue, wed, thu, fri, sat, sun", d)
+		}
+		w.days[wd] = true
+	}
+	var err error
+	if w.start, err = parseClock(start); err != nil {
+		return Window{}, fmt.Errorf("start: %w", err)
+	}
+	if w.end, err = parseClock(end); err != nil {
+		return Window{}, fmt.Errorf("end: %w", err)
+	}
+	return w, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains reports whether t falls inside the window.
+func (w Window) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	today := t.Weekday()
+	yesterday := (today + 6) % 7
+	if w.start < w.end {
+		return w.days[today] && m >= w.start && m < w.end
+	}
+	// Wraps past midnight (or spans the whole day when start == end).
+	return (w.days[today] && m >= w.start) || (w.days[yesterday] && m < w.end)
+}
+
+// Open reports whether t falls inside any of the windows.
+// No windows means deploys are always allowed.
+func Open(windows []Window, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	for _, w := range windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns the earliest time at or after t when a window is open.
+// Returns t when a window is already open or no windows are configured, and
+// the zero time when no window ever opens.
+func NextOpen(windows []Window, t time.Time) time.Time {
+	if Open(windows, t) {
+		return t
+	}
+	var next time.Time
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	for _, w := range windows {
+		for offset := 0; offset <= 7; offset++ {
+			day := midnight.AddDate(0, 0, offset)
+			if !w.days[day.Weekday()] {
+				continue
+			}
+			at := day.Add(time.Duration(w.start) * time.Minute)
+			if at.After(t) {
+				if next.IsZero() || at.Before(next) {
+					next = at
+				}
+				break
+			}
+		}
+	}
+	return next
+}
@@ -0,0 +1,75 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// 2026-03-02 is a Monday.
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestWindow_Contains(t *testing.T) {
+	weekdayNights, err := ParseWindow([]string{"mon", "tue", "wed", "thu", "fri"}, "22:00", "02:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sundayMorning, err := ParseWindow([]string{"Sun"}, "06:00", "09:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		w    Window
+		t    time.Time
+		want bool
+	}{
+		{"monday 14:00 closed", weekdayNights, at(2, 14, 0), false},
+		{"monday 22:00 open", weekdayNights, at(2, 22, 0), true},
+		{"tuesday 01:59 open (monday window)", weekdayNights, at(3, 1, 59), true},
+		{"tuesday 02:00 closed", weekdayNights, at(3, 2, 0), false},
+		{"saturday 01:00 open (friday window)", weekdayNights, at(7, 1, 0), true},
+		{"sunday 01:00 closed (no saturday window)", weekdayNights, at(8, 1, 0), false},
+		{"sunday 06:00 open", sundayMorning, at(8, 6, 0), true},
+		{"sunday 09:30 closed", sundayMorning, at(8, 9, 30), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.w.Contains(tt.t); got != tt.want {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNextOpen(t *testing.T) {
+	w, _ := ParseWindow([]string{"sat", "sun"}, "03:00", "05:00")
+	windows := []Window{w}
+
+	if got := NextOpen(nil, at(2, 14, 0)); !got.Equal(at(2, 14, 0)) {
+		t.Errorf("no windows: want now, got %v", got)
+	}
+	if got, want := NextOpen(windows, at(2, 14, 0)), at(7, 3, 0); !got.Equal(want) {
+		t.Errorf("monday: want %v, got %v", want, got)
+	}
+	if got, want := NextOpen(windows, at(7, 4, 0)), at(7, 4, 0); !got.Equal(want) {
+		t.Errorf("inside window: want %v, got %v", want, got)
+	}
+	if got, want := NextOpen(windows, at(8, 6, 0)), at(14, 3, 0); !got.Equal(want) {
+		t.Errorf("sunday after window: want %v, got %v", want, got)
+	}
+}
+
+func TestParseWindow_Errors(t *testing.T) {
+	if _, err := ParseWindow([]string{"monday"}, "01:00", "02:00"); err == nil {
+		t.Error("want error for long day name")
+	}
+	if _, err := ParseWindow(nil, "25:00", "02:00"); err == nil {
+		t.Error("want error for invalid start")
+	}
+	if _, err := ParseWindow(nil, "01:00", "2pm"); err == nil {
+		t.Error("want error for invalid end")
+	}
+}
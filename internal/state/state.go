@@ -0,0 +1,291 @@
+// Package state persists agent state that must survive a restart — blocked
+// digests, suppression maps, healer counters, and per-service deploy history —
+// to a single JSON file. Every change rewrites the file atomically (write to
+// temp, fsync, rename) so a crash leaves either the old or the new state.
+// Disabled (in-memory only) when path is empty.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/logger"
+)
+
+// maxHistory caps the number of deploy records kept per service.
+const maxHistory = 50
+
+// State is the persisted document. Map keys follow the in-memory maps they
+// mirror: "service/image" for per-image state, service name otherwise.
+type State struct {
+	Blocked        map[string]string    `json:"blocked"`         // "service/image" -> digest that caused a rollback
+	NotFound       map[string]string    `json:"not_found"`       // "service/image" -> remote digest with no local match
+	StartAttempted map[string]string    `json:"start_attempted"` // service -> remote digest at last auto-start
+	ComposeHashes  map[string]string    `json:"compose_hashes"`  // service -> SHA-256 of compose files
+	RestartCounts  map[string]int       `json:"restart_counts"`  // service -> consecutive failed heal restarts
+	Exhausted      map[string]bool      `json:"exhausted"`       // service -> heal restarts exhausted
+	History        map[string][]Deploy  `json:"history"`         // service -> deploy records, oldest first
+	Releases       map[string][]Release `json:"releases"`        // "service/image" -> retained digests, oldest first
+	Pinned         map[string]string    `json:"pinned"`          // "service/image" -> digest held by a manual rollback
+	Tags           map[string]string    `json:"tags"`            // "service/image" -> tag selected by a tag policy
+
+	Freeze *Freeze `json:"freeze,omitempty"` // ad-hoc deploy freeze across all services
+}
+
+// Freeze holds every image deploy until Until.
+type Freeze struct {
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Deploy is one entry of a service's deploy history.
+type Deploy struct {
+	Timestamp time.Time `json:"timestamp"`
+	Image     string    `json:"image"` // image entry as configured
+	Event     string    `json:"event"` // "updated" or "rolled_back"
+	OldDigest string    `json:"old_digest,omitempty"`
+	NewDigest string    `json:"new_digest,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// Release is a previously deployed digest kept on the host under a pinned
+// local tag so it can be redeployed without the registry.
+type Release struct {
+	Digest     string    `json:"digest"`
+	Tag        string    `json:"tag"` // local tag pinning the image (e.g. "dockward-0123456789ab")
+	DeployedAt time.Time `json:"deployed_at"`
+}
+
+// newState returns a State with all maps allocated.
+func newState() State {
+	return State{
+		Blocked:        make(map[string]string),
+		NotFound:       make(map[string]string),
+		StartAttempted: make(map[string]string),
+		ComposeHashes:  make(map[string]string),
+		RestartCounts:  make(map[string]int),
+		Exhausted:      make(map[string]bool),
+		History:        make(map[string][]Deploy),
+		Releases:       make(map[string][]Release),
+		Pinned:         make(map[string]string),
+		Tags:           make(map[string]string),
+	}
+}
+
+// Store holds the current State and writes it to disk on every change.
+// A nil Store is safe to use — reads return an empty State, writes are no-ops.
+type Store struct {
+	mu    sync.Mutex
+	path  string // empty = in-memory only
+	state State
+}
+
+// Open loads the state file at path, creating an empty state when it does
+// not exist yet. A file that cannot be parsed is moved aside (".corrupt")
+// and replaced with an empty state rather than blocking startup.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, state: newState()}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 -- path from config, not user input
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("read state %s: %w", path, err)
+	}
+
+	loaded := newState()
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		aside := path + ".corrupt"
+		logger.Printf("[state] parse %s: %v; moving to %s and starting empty", path, err, aside)
+		if rerr := os.Rename(path, aside); rerr != nil {
+			return nil, fmt.Errorf("move corrupt state aside: %w", rerr)
+		}
+		return s, nil
+	}
+	s.state = loaded.withMaps()
+	return s, nil
+}
+
+// withMaps allocates any map left nil by an older or partial state file.
+func (st State) withMaps() State {
+	empty := newState()
+	if st.Blocked == nil {
+		st.Blocked = empty.Blocked
+	}
+	if st.NotFound == nil {
+		st.NotFound = empty.NotFound
+	}
+	if st.StartAttempted == nil {
+		st.StartAttempted = empty.StartAttempted
+	}
+	if st.ComposeHashes == nil {
+		st.ComposeHashes = empty.ComposeHashes
+	}
+	if st.RestartCounts == nil {
+		st.RestartCounts = empty.RestartCounts
+	}
+	if st.Exhausted == nil {
+		st.Exhausted = empty.Exhausted
+	}
+	if st.History == nil {
+		st.History = empty.History
+	}
+	if st.Releases == nil {
+		st.Releases = empty.Releases
+	}
+	if st.Pinned == nil {
+		st.Pinned = empty.Pinned
+	}
+	if st.Tags == nil {
+		st.Tags = empty.Tags
+	}
+	return st
+}
+
+// Snapshot returns a deep copy of the current state.
+func (s *Store) Snapshot() State {
+	if s == nil {
+		return newState()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.clone()
+}
+
+// Update applies fn to the state and writes the result to disk.
+// fn runs under the store lock and must not call back into the Store.
+func (s *Store) Update(fn func(*State)) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.state)
+	return s.save()
+}
+
+// AppendHistory records a deploy for service, dropping the oldest records
+// beyond maxHistory.
+func (s *Store) AppendHistory(service string, d Deploy) error {
+	if d.Timestamp.IsZero() {
+		d.Timestamp = time.Now().UTC()
+	}
+	return s.Update(func(st *State) {
+		h := append(st.History[service], d)
+		if len(h) > maxHistory {
+			h = h[len(h)-maxHistory:]
+		}
+		st.History[service] = h
+	})
+}
+
+// RetainRelease records r as the newest release of key, keeping at most
+// limit releases. A digest already retained is moved to the newest slot.
+// Returns the releases dropped from the list so their local tags can be removed.
+func (s *Store) RetainRelease(key string, r Release, limit int) ([]Release, error) {
+	if r.DeployedAt.IsZero() {
+		r.DeployedAt = time.Now().UTC()
+	}
+	var evicted []Release
+	err := s.Update(func(st *State) {
+		kept := make([]Release, 0, len(st.Releases[key])+1)
+		for _, old := range st.Releases[key] {
+			if old.Digest != r.Digest {
+				kept = append(kept, old)
+			}
+		}
+		kept = append(kept, r)
+		if limit > 0 && len(kept) > limit {
+			evicted = append(evicted, kept[:len(kept)-limit]...)
+			kept = kept[len(kept)-limit:]
+		}
+		st.Releases[key] = kept
+	})
+	return evicted, err
+}
+
+// save writes the state atomically. Caller holds s.mu.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".dockward-state-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName) // #nosec G104 — best-effort cleanup
+		return fmt.Errorf("write temp file: %w", err)
+	}
+	// fsync before rename so a crash cannot leave a renamed but empty file.
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpName) // #nosec G104 — best-effort cleanup
+		return fmt.Errorf("sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName) // #nosec G104 — best-effort cleanup
+		return fmt.Errorf("close temp file: %w", err)
+	}
+	if err := os.Rename(tmpName, s.path); err != nil {
+		os.Remove(tmpName) // #nosec G104 — best-effort cleanup
+		return fmt.Errorf("atomic rename: %w", err)
+	}
+	return nil
+}
+
+// clone deep-copies every map and history slice.
+func (st State) clone() State {
+	out := newState()
+	for k, v := range st.Blocked {
+		out.Blocked[k] = v
+	}
+	for k, v := range st.NotFound {
+		out.NotFound[k] = v
+	}
+	for k, v := range st.StartAttempted {
+		out.StartAttempted[k] = v
+	}
+	for k, v := range st.ComposeHashes {
+		out.ComposeHashes[k] = v
+	}
+	for k, v := range st.RestartCounts {
+		out.RestartCounts[k] = v
+	}
+	for k, v := range st.Exhausted {
+		out.Exhausted[k] = v
+	}
+	for k, v := range st.History {
+		out.History[k] = append([]Deploy(nil), v...)
+	}
+	for k, v := range st.Releases {
+		out.Releases[k] = append([]Release(nil), v...)
+	}
+	for k, v := range st.Pinned {
+		out.Pinned[k] = v
+	}
+	for k, v := range st.Tags {
+		out.Tags[k] = v
+	}
+	if st.Freeze != nil {
+		f := *st.Freeze
+		out.Freeze = &f
+	}
+	return out
+}
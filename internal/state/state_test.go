@@ -0,0 +1,121 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := s.Update(func(st *State) {
+		st.Blocked["web/api:latest"] = "sha256:bad"
+		st.RestartCounts["web"] = 2
+	}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := s.AppendHistory("web", Deploy{Image: "api:latest", Event: "updated", NewDigest: "sha256:new"}); err != nil {
+		t.Fatalf("AppendHistory: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	snap := reopened.Snapshot()
+	if got := snap.Blocked["web/api:latest"]; got != "sha256:bad" {
+		t.Errorf("blocked: want %q, got %q", "sha256:bad", got)
+	}
+	if got := snap.RestartCounts["web"]; got != 2 {
+		t.Errorf("restart count: want 2, got %d", got)
+	}
+	if len(snap.History["web"]) != 1 || snap.History["web"][0].Timestamp.IsZero() {
+		t.Errorf("history: want 1 timestamped record, got %+v", snap.History["web"])
+	}
+}
+
+func TestStore_HistoryIsCapped(t *testing.T) {
+	s, _ := Open("")
+	for i := 0; i < maxHistory+5; i++ {
+		if err := s.AppendHistory("web", Deploy{Image: "api:latest", Event: "updated"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(s.Snapshot().History["web"]); got != maxHistory {
+		t.Errorf("want %d records, got %d", maxHistory, got)
+	}
+}
+
+func TestStore_SnapshotIsCopy(t *testing.T) {
+	s, _ := Open("")
+	_ = s.Update(func(st *State) { st.Blocked["a/b"] = "d1" })
+
+	snap := s.Snapshot()
+	snap.Blocked["a/b"] = "mutated"
+
+	if got := s.Snapshot().Blocked["a/b"]; got != "d1" {
+		t.Errorf("snapshot mutation leaked into store: got %q", got)
+	}
+}
+
+func TestOpen_CorruptFileMovedAside(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if len(s.Snapshot().Blocked) != 0 {
+		t.Error("want empty state after corrupt file")
+	}
+	if _, err := os.Stat(path + ".corrupt"); err != nil {
+		t.Errorf("want corrupt file moved aside: %v", err)
+	}
+}
+
+func TestStore_NilIsSafe(t *testing.T) {
+	var s *Store
+	if err := s.Update(func(st *State) { st.Blocked["a/b"] = "d" }); err != nil {
+		t.Errorf("Update on nil store: %v", err)
+	}
+	if snap := s.Snapshot(); snap.Blocked == nil {
+		t.Error("Snapshot on nil store should return allocated maps")
+	}
+}
+
+func TestStore_RetainRelease(t *testing.T) {
+	s, _ := Open("")
+	key := "web/api:latest"
+	for _, d := range []string{"d1", "d2", "d3"} {
+		if _, err := s.RetainRelease(key, Release{Digest: d, Tag: "t-" + d}, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Re-retaining an existing digest moves it to the newest slot.
+	evicted, err := s.RetainRelease(key, Release{Digest: "d2", Tag: "t-d2"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evicted) != 0 {
+		t.Errorf("want nothing evicted on re-retain, got %+v", evicted)
+	}
+
+	got := s.Snapshot().Releases[key]
+	if len(got) != 2 || got[0].Digest != "d3" || got[1].Digest != "d2" {
+		t.Errorf("want [d3 d2], got %+v", got)
+	}
+
+	evicted, _ = s.RetainRelease(key, Release{Digest: "d4", Tag: "t-d4"}, 2)
+	if len(evicted) != 1 || evicted[0].Digest != "d3" {
+		t.Errorf("want d3 evicted, got %+v", evicted)
+	}
+}
@@ -0,0 +1,227 @@
+// Package tagpolicy selects the tag a service should run from a registry's
+// tag list: the highest semver tag within a constraint ("~1.4", "^2",
+// "1.x") or the highest tag matching a regular expression.
+package tagpolicy
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Policy picks the newest acceptable tag from a list.
+type Policy struct {
+	// semver constraint bounds: lower inclusive, upper exclusive.
+	// upper is nil for "*" (any release).
+	lower, upper *version
+	pattern      *regexp.Regexp
+}
+
+// Parse builds a policy from a semver constraint or a regular expression.
+// Exactly one of semverConstraint and pattern must be set.
+//
+// Constraints:
+//
+//	~1.4   >=1.4.0 <1.5.0   (patch updates)
+//	~1.4.2 >=1.4.2 <1.5.0
+//	^2     >=2.0.0 <3.0.0   (minor and patch updates)
+//	^1.4   >=1.4.0 <2.0.0
+//	^0.3   >=0.3.0 <0.4.0   (0.x minors are breaking)
+//	1.4.x  >=1.4.0 <1.5.0
+//	1.x    >=1.0.0 <2.0.0
+//	*      any release
+//
+// Pre-release tags (1.4.0-rc.1) never match a semver constraint.
+func Parse(semverConstraint, pattern string) (*Policy, error) {
+	switch {
+	case semverConstraint != "" && pattern != "":
+		return nil, fmt.Errorf("semver and pattern are mutually exclusive")
+	case pattern != "":
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		return &Policy{pattern: re}, nil
+	case semverConstraint != "":
+		lower, upper, err := parseConstraint(semverConstraint)
+		if err != nil {
+			return nil, err
+		}
+		return &Policy{lower: lower, upper: upper}, nil
+	default:
+		return nil, fmt.Errorf("semver or pattern is required")
+	}
+}
+
+// Select returns the highest tag accepted by the policy.
+// Returns false when no tag matches.
+func (p *Policy) Select(tags []string) (string, bool) {
+	best := ""
+	var bestV *version
+	for _, tag := range tags {
+		if p.pattern != nil {
+			if p.pattern.MatchString(tag) && (best == "" || naturalLess(best, tag)) {
+				best = tag
+			}
+			continue
+		}
+		v, ok := parseVersion(tag)
+		if !ok || v.pre != "" || !p.accepts(v) {
+			continue
+		}
+		if bestV == nil || bestV.less(v) {
+			best, bestV = tag, v
+		}
+	}
+	return best, best != ""
+}
+
+func (p *Policy) accepts(v *version) bool {
+	if v.less(p.lower) {
+		return false
+	}
+	return p.upper == nil || v.less(p.upper)
+}
+
+// version is a parsed semantic version.
+type version struct {
+	major, minor, patch int
+	pre                 string
+}
+
+var versionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// parseVersion parses "1.4.2", "v1.4.2", "1.4.2-rc.1" and "1.4.2+build".
+func parseVersion(s string) (*version, bool) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, false
+	}
+	v := &version{pre: m[4]}
+	v.major, _ = strconv.Atoi(m[1])
+	v.minor, _ = strconv.Atoi(m[2])
+	v.patch, _ = strconv.Atoi(m[3])
+	return v, true
+}
+
+// less orders by major, minor, patch; a pre-release sorts before its release.
+func (v *version) less(o *version) bool {
+	if v.major != o.major {
+		return v.major < o.major
+	}
+	if v.minor != o.minor {
+		return v.minor < o.minor
+	}
+	if v.patch != o.patch {
+		return v.patch < o.patch
+	}
+	if v.pre == "" || o.pre == "" {
+		return v.pre != "" && o.pre == ""
+	}
+	return naturalLess(v.pre, o.pre)
+}
+
+// parseConstraint returns the [lower, upper) bounds of a constraint.
+func parseConstraint(c string) (*version, *version, error) {
+	c = strings.TrimSpace(c)
+	if c == "*" || c == "x" {
+		return &version{}, nil, nil
+	}
+
+	op := ""
+	if strings.HasPrefix(c, "~") || strings.HasPrefix(c, "^") {
+		op, c = c[:1], c[1:]
+	}
+	c = strings.TrimPrefix(c, "v")
+
+	parts := strings.Split(c, ".")
+	if len(parts) > 3 {
+		return nil, nil, fmt.Errorf("invalid semver constraint %q", op+c)
+	}
+	nums := make([]int, 0, 3)
+	for _, p := range parts {
+		if p == "x" || p == "*" {
+			if op != "" {
+				return nil, nil, fmt.Errorf("invalid semver constraint %q: wildcards cannot be combined with %s", op+c, op)
+			}
+			break
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, nil, fmt.Errorf("invalid semver constraint %q", op+c)
+		}
+		nums = append(nums, n)
+	}
+	if len(nums) == 0 {
+		return nil, nil, fmt.Errorf("invalid semver constraint %q", op+c)
+	}
+	for len(nums) < 3 {
+		nums = append(nums, -1) // unspecified
+	}
+	major, minor, patch := nums[0], nums[1], nums[2]
+	lower := &version{major: major, minor: max(minor, 0), patch: max(patch, 0)}
+
+	switch op {
+	case "^":
+		switch {
+		case major > 0 || minor < 0:
+			return lower, &version{major: major + 1}, nil
+		case minor > 0 || patch < 0:
+			return lower, &version{minor: minor + 1}, nil
+		default:
+			return lower, &version{patch: patch + 1}, nil
+		}
+	case "~":
+		if minor < 0 {
+			return lower, &version{major: major + 1}, nil
+		}
+		return lower, &version{major: major, minor: minor + 1}, nil
+	default:
+		// Exact version or "1.x"/"1.4.x" wildcard.
+		switch {
+		case minor < 0:
+			return lower, &version{major: major + 1}, nil
+		case patch < 0:
+			return lower, &version{major: major, minor: minor + 1}, nil
+		default:
+			return lower, &version{major: major, minor: minor, patch: patch + 1}, nil
+		}
+	}
+}
+
+// naturalLess compares strings with runs of digits compared numerically,
+// so "release-9" < "release-10".
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := isDigit(a[0]), isDigit(b[0])
+		if da && db {
+			na, ra := splitDigits(a)
+			nb, rb := splitDigits(b)
+			ta, tb := strings.TrimLeft(na, "0"), strings.TrimLeft(nb, "0")
+			if len(ta) != len(tb) {
+				return len(ta) < len(tb)
+			}
+			if ta != tb {
+				return ta < tb
+			}
+			a, b = ra, rb
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
@@ -0,0 +1,66 @@
+package tagpolicy
+
+import "testing"
+
+func TestSelect_Semver(t *testing.T) {
+	tags := []string{"latest", "1.3.9", "1.4.0", "1.4.7", "v1.4.10", "1.5.0", "1.5.1-rc.1", "2.0.0", "2.3.1", "3.0.0-beta", "0.3.1", "0.3.4", "0.4.0"}
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"~1.4", "v1.4.10"},
+		{"~1.4.8", "v1.4.10"},
+		{"~1", "1.5.0"},
+		{"^1.4", "1.5.0"},
+		{"^2", "2.3.1"},
+		{"^0.3", "0.3.4"},
+		{"1.4.x", "v1.4.10"},
+		{"1.x", "1.5.0"},
+		{"1.4.7", "1.4.7"},
+		{"*", "2.3.1"},
+		{"^4", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			p, err := Parse(tt.constraint, "")
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got, ok := p.Select(tags)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Errorf("want %q, got %q (ok=%v)", tt.want, got, ok)
+			}
+		})
+	}
+}
+
+func TestSelect_Pattern(t *testing.T) {
+	p, err := Parse("", `^release-\d+$`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	got, ok := p.Select([]string{"release-9", "release-10", "release-2", "release-11-rc", "latest"})
+	if !ok || got != "release-10" {
+		t.Errorf("want release-10, got %q (ok=%v)", got, ok)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name, semver, pattern string
+	}{
+		{"neither", "", ""},
+		{"both", "^1", ".*"},
+		{"bad regex", "", "("},
+		{"bad constraint", "~abc", ""},
+		{"wildcard with operator", "^1.x", ""},
+		{"too many parts", "1.2.3.4", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.semver, tt.pattern); err == nil {
+				t.Error("want error, got nil")
+			}
+		})
+	}
+}
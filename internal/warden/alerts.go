@@ -0,0 +1,132 @@
+package warden
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/audit"
+	"github.com/studiowebux/dockward/internal/logger"
+	"github.com/studiowebux/dockward/internal/notify"
+)
+
+const (
+	alertQueueSize = 256              // events waiting for delivery; newer events are dropped beyond this
+	alertTimeout   = 30 * time.Second // per event, across all its channels
+)
+
+// levelRank orders audit levels for AlertRoute.MinLevel.
+var levelRank = map[string]int{
+	"info":     0,
+	"warning":  1,
+	"error":    2,
+	"critical": 3,
+}
+
+// defaultRoutes apply when notifications.routes is empty: the warden reports
+// the agents it lost and regained, agents notify for themselves.
+var defaultRoutes = []AlertRoute{{Events: []string{"agent_offline", "agent_online"}}}
+
+// alertRouter sends warden events to notification channels according to the
+// configured routes. Events are queued by Route and delivered by Run so that
+// slow channels never block ingest or the heartbeat.
+type alertRouter struct {
+	notifiers []notify.Notifier
+	routes    []AlertRoute
+	queue     chan audit.Entry
+}
+
+// newAlertRouter builds the channels of cfg. Returns nil when no channel is
+// configured.
+func newAlertRouter(cfg WardenNotifications) (*alertRouter, error) {
+	notifiers, err := notify.FromConfig(cfg.Notifications)
+	if err != nil {
+		return nil, err
+	}
+	if len(notifiers) == 0 {
+		return nil, nil
+	}
+	routes := cfg.Routes
+	if len(routes) == 0 {
+		routes = defaultRoutes
+	}
+	for _, n := range notifiers {
+		logger.Printf("warden: notification %s enabled", n.Name())
+	}
+	return &alertRouter{
+		notifiers: notifiers,
+		routes:    routes,
+		queue:     make(chan audit.Entry, alertQueueSize),
+	}, nil
+}
+
+// Route queues e for delivery when any route matches it. Never blocks.
+func (r *alertRouter) Route(e audit.Entry) {
+	if r == nil || len(r.targets(e)) == 0 {
+		return
+	}
+	select {
+	case r.queue <- e:
+	default:
+		logger.Printf("warden: alert queue full, dropping %s for %s/%s", e.Event, e.Machine, e.Service)
+	}
+}
+
+// Run delivers queued events until ctx is cancelled. Blocks.
+func (r *alertRouter) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-r.queue:
+			r.deliver(ctx, e)
+		}
+	}
+}
+
+func (r *alertRouter) deliver(ctx context.Context, e audit.Entry) {
+	ctx, cancel := context.WithTimeout(ctx, alertTimeout)
+	defer cancel()
+	alert := notify.Alert{
+		Machine:   e.Machine,
+		Service:   e.Service,
+		Event:     e.Event,
+		Message:   e.Message,
+		Reason:    e.Reason,
+		OldDigest: e.OldDigest,
+		NewDigest: e.NewDigest,
+		Container: e.Container,
+		Logs:      e.Logs,
+		Timestamp: e.Timestamp,
+		Level:     e.Level,
+	}
+	for _, n := range r.targets(e) {
+		if err := n.Send(ctx, alert); err != nil {
+			logger.Printf("warden: notify %s error: %v", n.Name(), err)
+		}
+	}
+}
+
+// targets returns the channels of every route matching e, each once, in
+// configuration order.
+func (r *alertRouter) targets(e audit.Entry) []notify.Notifier {
+	var out []notify.Notifier
+	for _, route := range r.routes {
+		if !route.match(e) {
+			continue
+		}
+		for _, n := range r.notifiers {
+			if (len(route.Channels) == 0 || slices.Contains(route.Channels, n.Name())) && !slices.Contains(out, n) {
+				out = append(out, n)
+			}
+		}
+	}
+	return out
+}
+
+func (route AlertRoute) match(e audit.Entry) bool {
+	return (len(route.Machines) == 0 || slices.Contains(route.Machines, e.Machine)) &&
+		(len(route.Services) == 0 || slices.Contains(route.Services, e.Service)) &&
+		(len(route.Events) == 0 || slices.Contains(route.Events, e.Event)) &&
+		levelRank[e.Level] >= levelRank[route.MinLevel]
+}
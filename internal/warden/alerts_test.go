@@ -0,0 +1,189 @@
+package warden
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/audit"
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/hub"
+	"github.com/studiowebux/dockward/internal/notify"
+)
+
+// fakeNotifier records the alerts it receives.
+type fakeNotifier struct {
+	name string
+
+	mu     sync.Mutex
+	alerts []notify.Alert
+}
+
+func (f *fakeNotifier) Name() string { return f.name }
+
+func (f *fakeNotifier) Send(_ context.Context, a notify.Alert) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.alerts = append(f.alerts, a)
+	return nil
+}
+
+func (f *fakeNotifier) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.alerts)
+}
+
+func testRouter(routes []AlertRoute, notifiers ...notify.Notifier) *alertRouter {
+	if len(routes) == 0 {
+		routes = defaultRoutes
+	}
+	return &alertRouter{notifiers: notifiers, routes: routes, queue: make(chan audit.Entry, alertQueueSize)}
+}
+
+func names(ns []notify.Notifier) []string {
+	var out []string
+	for _, n := range ns {
+		out = append(out, n.Name())
+	}
+	return out
+}
+
+func TestAlertRouter_DefaultRoutesAgentConnectivityOnly(t *testing.T) {
+	r := testRouter(nil, &fakeNotifier{name: "discord"}, &fakeNotifier{name: "smtp"})
+
+	if got := r.targets(audit.Entry{Machine: "a", Event: "agent_offline", Level: "warning"}); len(got) != 2 {
+		t.Errorf("agent_offline: want all channels, got %v", names(got))
+	}
+	if got := r.targets(audit.Entry{Machine: "a", Service: "web", Event: "rolled_back", Level: "critical"}); len(got) != 0 {
+		t.Errorf("agent events are not notified by default, got %v", names(got))
+	}
+}
+
+func TestAlertRouter_Routes(t *testing.T) {
+	discord := &fakeNotifier{name: "discord"}
+	pager := &fakeNotifier{name: "webhook:pager"}
+	r := testRouter([]AlertRoute{
+		{MinLevel: "warning", Channels: []string{"discord"}},
+		{Machines: []string{"prod-1"}, Services: []string{"api"}, MinLevel: "critical"},
+		{Events: []string{"agent_offline"}, Channels: []string{"discord", "webhook:pager"}},
+	}, discord, pager)
+
+	cases := []struct {
+		name  string
+		entry audit.Entry
+		want  []string
+	}{
+		{"below min level", audit.Entry{Machine: "prod-1", Service: "api", Event: "updated", Level: "info"}, nil},
+		{"warning to discord", audit.Entry{Machine: "dev", Service: "api", Event: "unhealthy", Level: "warning"}, []string{"discord"}},
+		{"critical on prod api to all", audit.Entry{Machine: "prod-1", Service: "api", Event: "critical", Level: "critical"}, []string{"discord", "webhook:pager"}},
+		{"critical elsewhere", audit.Entry{Machine: "prod-2", Service: "api", Event: "critical", Level: "critical"}, []string{"discord"}},
+		{"offline once per channel", audit.Entry{Machine: "dev", Event: "agent_offline", Level: "warning"}, []string{"discord", "webhook:pager"}},
+	}
+	for _, c := range cases {
+		got := names(r.targets(c.entry))
+		if len(got) != len(c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestAlertRouter_RunDelivers(t *testing.T) {
+	n := &fakeNotifier{name: "discord"}
+	r := testRouter(nil, n)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+
+	r.Route(audit.Entry{Machine: "a", Service: "warden", Event: "agent_online", Level: "info"})
+	r.Route(audit.Entry{Machine: "a", Service: "web", Event: "updated", Level: "info"}) // not routed
+
+	deadline := time.Now().Add(2 * time.Second)
+	for n.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n.count() != 1 {
+		t.Fatalf("want 1 alert, got %d", n.count())
+	}
+	if a := n.alerts[0]; a.Machine != "a" || a.Event != "agent_online" {
+		t.Errorf("unexpected alert: %+v", a)
+	}
+}
+
+func TestAlertRouter_NilIsNoop(t *testing.T) {
+	var r *alertRouter
+	r.Route(audit.Entry{Event: "agent_offline"}) // must not panic
+}
+
+func TestHeartbeat_TransitionRoutesAlert(t *testing.T) {
+	n := &fakeNotifier{name: "discord"}
+	cfg := []AgentConfig{{ID: "a"}}
+	hb := NewHeartbeat(NewStore(cfg), hub.NewHub(), cfg)
+	hb.alerts = testRouter(nil, n)
+	hb.online["a"] = true
+
+	hb.transition("a", false)
+
+	select {
+	case e := <-hb.alerts.queue:
+		if e.Event != "agent_offline" || e.Machine != "a" {
+			t.Errorf("unexpected alert entry: %+v", e)
+		}
+	default:
+		t.Fatal("agent_offline was not routed")
+	}
+}
+
+func TestHeartbeat_FirstPollReportsOnlyOffline(t *testing.T) {
+	cfg := []AgentConfig{{ID: "up"}, {ID: "down"}}
+	store := NewStore(cfg)
+	hb := NewHeartbeat(store, hub.NewHub(), cfg)
+
+	hb.transition("up", true)
+	hb.transition("down", false)
+	hb.transition("down", false)
+
+	got := store.Recent(10)
+	if len(got) != 1 || got[0].Machine != "down" || got[0].Event != "agent_offline" {
+		t.Errorf("want a single agent_offline for the agent down at startup, got %+v", got)
+	}
+}
+
+func TestWardenConfigValidation_Routes(t *testing.T) {
+	base := func(routes ...AlertRoute) *WardenConfig {
+		return &WardenConfig{
+			API: WardenAPI{Token: "t"},
+			Notifications: WardenNotifications{
+				Notifications: config.Notifications{
+					Discord:  &config.Discord{WebhookURL: "http://discord"},
+					Webhooks: []config.Webhook{{Name: "pager", URL: "http://pager"}},
+				},
+				Routes: routes,
+			},
+		}
+	}
+	cases := []struct {
+		name  string
+		route AlertRoute
+		ok    bool
+	}{
+		{"valid", AlertRoute{MinLevel: "critical", Channels: []string{"discord", "webhook:pager"}}, true},
+		{"bad level", AlertRoute{MinLevel: "fatal"}, false},
+		{"unknown channel", AlertRoute{Channels: []string{"smtp"}}, false},
+		{"unknown webhook", AlertRoute{Channels: []string{"webhook:other"}}, false},
+	}
+	for _, c := range cases {
+		if err := base(c.route).validate(); (err == nil) != c.ok {
+			t.Errorf("%s: validate() = %v, want ok=%v", c.name, err, c.ok)
+		}
+	}
+}
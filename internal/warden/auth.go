@@ -0,0 +1,55 @@
+package warden
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// authCookie is set by the dashboard so later requests (including
+// EventSource, which cannot send headers) stay authenticated.
+const authCookie = "token"
+
+// principal is an authenticated warden user.
+type principal struct {
+	Name  string // acting user recorded in audit entries
+	Role  string
+	Token string // as presented, so the dashboard can reuse it
+}
+
+// allows reports whether p holds role; an operator holds every role.
+func (p principal) allows(role string) bool {
+	return p.Role == RoleOperator || p.Role == role
+}
+
+// authenticate matches the caller's token against api.token (the admin) and
+// api.tokens. The token is read from "Authorization: Bearer", the ?token=
+// query parameter, or the auth cookie, in that order.
+// Every candidate is compared in constant time to prevent timing attacks.
+func (s *Server) authenticate(r *http.Request) (principal, bool) {
+	token := bearerToken(r)
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		if c, err := r.Cookie(authCookie); err == nil {
+			token = c.Value
+		}
+	}
+	if token == "" {
+		return principal{}, false
+	}
+
+	var match principal
+	found := false
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.API.Token)) == 1 {
+		match = principal{Name: adminName, Role: RoleOperator, Token: token}
+		found = true
+	}
+	for _, t := range s.cfg.API.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t.Token)) == 1 {
+			match = principal{Name: t.Name, Role: t.Role, Token: token}
+			found = true
+		}
+	}
+	return match, found
+}
@@ -1,32 +1,83 @@
 // Package warden implements the central aggregator that collects audit entries
-// from multiple agents, stores them in a ring buffer, fans out via SSE, and
-// serves a multi-machine dashboard.
+// from multiple agents, stores them in a ring buffer and an optional persistent
+// event log, fans out via SSE, serves a multi-machine dashboard, and proxies
+// operator actions to agents.
 package warden
 
 import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/studiowebux/dockward/internal/config"
 )
 
 // WardenConfig is the top-level warden configuration.
 type WardenConfig struct {
-	API    WardenAPI     `json:"api"`
-	Agents []AgentConfig `json:"agents"`
+	API           WardenAPI           `json:"api"`
+	Events        EventsConfig        `json:"events"`
+	Notifications WardenNotifications `json:"notifications"`
+	Agents        []AgentConfig       `json:"agents"`
+}
+
+// WardenNotifications configures warden-side alerting: the agent's
+// notification channels plus routing rules.
+type WardenNotifications struct {
+	config.Notifications
+	Routes []AlertRoute `json:"routes,omitempty"` // empty = agent_offline/agent_online to every channel
+}
+
+// AlertRoute selects events to notify and the channels they go to. Empty
+// fields match everything. Every matching route delivers; a channel gets each
+// event at most once.
+type AlertRoute struct {
+	Machines []string `json:"machines,omitempty"`  // agent IDs
+	Services []string `json:"services,omitempty"`  // service names
+	Events   []string `json:"events,omitempty"`    // event names, e.g. "agent_offline", "rolled_back"
+	MinLevel string   `json:"min_level,omitempty"` // info, warning, error or critical (default: info)
+	Channels []string `json:"channels,omitempty"`  // "discord", "smtp" or "webhook:<name>"; empty = all
+}
+
+// EventsConfig defines the persistent event store.
+type EventsConfig struct {
+	Dir           string `json:"dir"`            // directory of the event log segments; empty = ring buffer only
+	RetentionDays int    `json:"retention_days"` // delete segments older than this (default: 30)
+	MaxSizeMB     int    `json:"max_size_mb"`    // delete the oldest segments beyond this total size (default: 1024)
 }
 
 // WardenAPI defines the HTTP server settings for the warden.
 type WardenAPI struct {
-	Port      string `json:"port"`       // default "8080"
-	Token     string `json:"token"`      // bearer token for browser auth; $ENV_VAR expansion supported
-	StatePath string `json:"state_path"` // path to persist the event ring buffer; empty = disabled
+	Port      string        `json:"port"`             // default "8080"
+	Token     string        `json:"token"`            // admin token, full access; $ENV_VAR expansion supported
+	Tokens    []WardenToken `json:"tokens,omitempty"` // additional named tokens with a role
+	StatePath string        `json:"state_path"`       // path to persist the event ring buffer; empty = disabled
+}
+
+// Warden token roles, as on the agent API. An operator can do everything a
+// viewer can.
+const (
+	RoleViewer   = "viewer"   // dashboard, SSE stream and event queries
+	RoleOperator = "operator" // viewer + actions proxied to agents and agent config reads
+)
+
+// adminName is the actor recorded for requests made with api.token.
+const adminName = "admin"
+
+// WardenToken grants a role on the warden dashboard and API.
+type WardenToken struct {
+	Name  string `json:"name"`  // acting user recorded in audit entries
+	Token string `json:"token"` // bearer token; $ENV_VAR expansion supported
+	Role  string `json:"role"`  // "viewer" or "operator"
 }
 
 // AgentConfig describes one monitored agent.
 type AgentConfig struct {
-	ID    string `json:"id"`    // display name shown in the warden UI
-	URL   string `json:"url"`   // agent base URL used for heartbeat polling (GET /health)
-	Token string `json:"token"` // token the agent uses when POSTing to /ingest
+	ID       string `json:"id"`                  // display name shown in the warden UI
+	URL      string `json:"url"`                 // agent base URL used for heartbeat polling and proxied actions
+	Token    string `json:"token"`               // token the agent uses when POSTing to /ingest
+	APIToken string `json:"api_token,omitempty"` // operator token for the agent API; empty = agent auth disabled
 }
 
 // LoadWarden reads and parses a warden JSON config file.
@@ -49,8 +100,21 @@ func LoadWarden(path string) (*WardenConfig, error) {
 
 	// Expand environment variables.
 	cfg.API.Token = os.ExpandEnv(cfg.API.Token)
+	for i := range cfg.API.Tokens {
+		cfg.API.Tokens[i].Token = os.ExpandEnv(cfg.API.Tokens[i].Token)
+		if cfg.API.Tokens[i].Token == "" {
+			return nil, fmt.Errorf("validate warden config: api.tokens[%d] %q: token is empty after environment expansion", i, cfg.API.Tokens[i].Name)
+		}
+	}
+	for i := range cfg.Notifications.Webhooks {
+		cfg.Notifications.Webhooks[i].URL = os.ExpandEnv(cfg.Notifications.Webhooks[i].URL)
+		for k, v := range cfg.Notifications.Webhooks[i].Headers {
+			cfg.Notifications.Webhooks[i].Headers[k] = os.ExpandEnv(v)
+		}
+	}
 	for i := range cfg.Agents {
 		cfg.Agents[i].Token = os.ExpandEnv(cfg.Agents[i].Token)
+		cfg.Agents[i].APIToken = os.ExpandEnv(cfg.Agents[i].APIToken)
 		cfg.Agents[i].URL = os.ExpandEnv(cfg.Agents[i].URL)
 	}
 
@@ -61,16 +125,52 @@ func (c *WardenConfig) setDefaults() {
 	if c.API.Port == "" {
 		c.API.Port = "8080"
 	}
+	if c.Events.RetentionDays <= 0 {
+		c.Events.RetentionDays = 30
+	}
+	if c.Events.MaxSizeMB <= 0 {
+		c.Events.MaxSizeMB = 1024
+	}
 }
 
 func (c *WardenConfig) validate() error {
 	if c.API.Token == "" {
 		return fmt.Errorf("api.token is required")
 	}
+	names := map[string]bool{adminName: true}
+	for i, t := range c.API.Tokens {
+		if t.Name == "" {
+			return fmt.Errorf("api.tokens[%d]: name is required", i)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("api.tokens[%d] %q is a duplicate or reserved name", i, t.Name)
+		}
+		names[t.Name] = true
+		if t.Token == "" {
+			return fmt.Errorf("api.tokens[%d] %q: token is required", i, t.Name)
+		}
+		if t.Role != RoleViewer && t.Role != RoleOperator {
+			return fmt.Errorf("api.tokens[%d] %q: role must be %q or %q, got %q", i, t.Name, RoleViewer, RoleOperator, t.Role)
+		}
+	}
+	if c.Events.Dir != "" && !filepath.IsAbs(c.Events.Dir) {
+		return fmt.Errorf("events.dir must be an absolute path: %q", c.Events.Dir)
+	}
+	if err := c.Notifications.validate(); err != nil {
+		return err
+	}
+	ids := make(map[string]bool, len(c.Agents))
 	for i, a := range c.Agents {
 		if a.ID == "" {
 			return fmt.Errorf("agents[%d]: id is required", i)
 		}
+		if strings.Contains(a.ID, "/") {
+			return fmt.Errorf("agents[%d] %q: id must not contain '/'", i, a.ID)
+		}
+		if ids[a.ID] {
+			return fmt.Errorf("agents[%d] %q is a duplicate id", i, a.ID)
+		}
+		ids[a.ID] = true
 		if a.URL == "" {
 			return fmt.Errorf("agents[%d] %q: url is required", i, a.ID)
 		}
@@ -80,3 +180,26 @@ func (c *WardenConfig) validate() error {
 	}
 	return nil
 }
+
+// validate checks that every route has a known level and only names
+// configured channels.
+func (n WardenNotifications) validate() error {
+	channels := map[string]bool{"discord": n.Discord != nil, "smtp": n.SMTP != nil}
+	for i, wh := range n.Webhooks {
+		if wh.Name == "" {
+			return fmt.Errorf("notifications.webhooks[%d]: name is required", i)
+		}
+		channels["webhook:"+wh.Name] = true
+	}
+	for i, r := range n.Routes {
+		if _, ok := levelRank[r.MinLevel]; r.MinLevel != "" && !ok {
+			return fmt.Errorf("notifications.routes[%d]: min_level must be info, warning, error or critical, got %q", i, r.MinLevel)
+		}
+		for _, ch := range r.Channels {
+			if !channels[ch] {
+				return fmt.Errorf("notifications.routes[%d]: channel %q is not configured", i, ch)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package warden
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/audit"
+	"github.com/studiowebux/dockward/internal/logger"
+)
+
+const (
+	proxyTimeout     = 30 * time.Second // per proxied agent request
+	maxProxyResponse = 1 << 20          // bytes of agent response relayed to the caller
+)
+
+// agentAction describes an agent API call the warden proxies.
+type agentAction struct {
+	method  string
+	service string // "required", "optional" or "" (none)
+}
+
+// agentActions are the agent endpoints reachable through
+// /api/agents/<id>/<action>[/<service>]. All require the operator role, as
+// they do on the agent.
+var agentActions = map[string]agentAction{
+	"trigger":  {method: http.MethodPost, service: "optional"},
+	"redeploy": {method: http.MethodPost, service: "required"},
+	"unblock":  {method: http.MethodPost, service: "required"},
+	"config":   {method: http.MethodGet},
+}
+
+// serviceNameRe matches the service names the agent API accepts.
+var serviceNameRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// handleAgentAction handles /api/agents/<id>/<action>[/<service>]: it forwards
+// the action to the agent's API with the agent's api_token and relays the
+// response. Requires an operator token. Every attempt by an authenticated
+// user is recorded centrally as an "agent_action" event naming the user.
+func (s *Server) handleAgentAction(w http.ResponseWriter, r *http.Request) {
+	p, ok := s.authenticate(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/api/agents/"), "/", 3)
+	if len(parts) < 2 {
+		http.NotFound(w, r)
+		return
+	}
+	id, name := parts[0], parts[1]
+	service := ""
+	if len(parts) == 3 {
+		service = parts[2]
+	}
+
+	action, known := agentActions[name]
+	if !known {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != action.method {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch {
+	case action.service == "" && service != "":
+		http.NotFound(w, r)
+		return
+	case action.service == "required" && service == "":
+		http.Error(w, "service name required", http.StatusBadRequest)
+		return
+	case service != "" && !serviceNameRe.MatchString(service):
+		http.Error(w, "invalid service name: must match ^[a-zA-Z0-9_-]{1,64}$", http.StatusBadRequest)
+		return
+	}
+
+	agent, found := s.agent(id)
+	if !found {
+		http.Error(w, "unknown agent", http.StatusNotFound)
+		return
+	}
+
+	what := name
+	switch {
+	case service != "":
+		what += " " + service
+	case name == "trigger":
+		what += " (all services)"
+	}
+
+	if !p.allows(RoleOperator) {
+		s.auditAction(agent.ID, service, fmt.Sprintf("Rejected %s on %s by %s", what, agent.ID, p.Name),
+			fmt.Sprintf("role %q requires %q", p.Role, RoleOperator), "warning")
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	target := agent.URL + "/" + name
+	if service != "" {
+		target += "/" + service
+	}
+	status, header, body, err := s.forward(r, agent, action.method, target)
+	if err != nil {
+		logger.Printf("warden: %s on %s by %s failed: %v", what, agent.ID, p.Name, err)
+		s.auditAction(agent.ID, service, fmt.Sprintf("Proxied %s on %s by %s failed", what, agent.ID, p.Name),
+			err.Error(), "warning")
+		http.Error(w, "agent unreachable", http.StatusBadGateway)
+		return
+	}
+
+	logger.Printf("warden: %s on %s by %s: %d", what, agent.ID, p.Name, status)
+	if status >= 200 && status < 300 {
+		s.auditAction(agent.ID, service, fmt.Sprintf("Proxied %s on %s by %s", what, agent.ID, p.Name), "", "info")
+	} else {
+		s.auditAction(agent.ID, service, fmt.Sprintf("Proxied %s on %s by %s failed", what, agent.ID, p.Name),
+			fmt.Sprintf("agent returned %d: %s", status, firstLine(body)), "warning")
+	}
+
+	if ct := header.Get("Content-Type"); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body) // #nosec G104 -- client may have gone away
+}
+
+// agent returns the configured agent with the given ID.
+func (s *Server) agent(id string) (AgentConfig, bool) {
+	for _, a := range s.cfg.Agents {
+		if a.ID == id {
+			return a, true
+		}
+	}
+	return AgentConfig{}, false
+}
+
+// forward issues one request to an agent's API, authenticated with the
+// agent's api_token, and returns its status, headers and (truncated) body.
+// Agent redirects are not followed.
+func (s *Server) forward(r *http.Request, agent AgentConfig, method, target string) (int, http.Header, []byte, error) {
+	req, err := http.NewRequestWithContext(r.Context(), method, target, nil)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if agent.APIToken != "" {
+		req.Header.Set("Authorization", "Bearer "+agent.APIToken)
+	}
+
+	resp, err := s.agentClient.Do(req) // #nosec G704 -- agent URL from config; path built from validated segments
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProxyResponse))
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("read agent response: %w", err)
+	}
+	return resp.StatusCode, resp.Header, body, nil
+}
+
+// auditAction records a proxied action in the warden's event store and
+// broadcasts it to dashboards.
+func (s *Server) auditAction(machine, service, message, reason, level string) {
+	s.ingest(audit.Entry{
+		Machine: machine,
+		Service: service,
+		Event:   "agent_action",
+		Message: message,
+		Reason:  reason,
+		Level:   level,
+	})
+}
+
+// newAgentClient returns the HTTP client used for proxied agent requests.
+func newAgentClient() *http.Client {
+	return &http.Client{
+		Timeout: proxyTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// firstLine returns the first line of an agent error body for audit reasons.
+func firstLine(b []byte) string {
+	line, _, _ := bytes.Cut(bytes.TrimSpace(b), []byte("\n"))
+	if len(line) > 200 {
+		line = line[:200]
+	}
+	return string(line)
+}
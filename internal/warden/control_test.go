@@ -0,0 +1,216 @@
+package warden
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// controlServer returns a test server whose agent-1 points at a fake agent API,
+// with a viewer ("ops-ro") and an operator ("alice") token.
+func controlServer(t *testing.T, agent http.HandlerFunc) *Server {
+	t.Helper()
+	ts := httptest.NewServer(agent)
+	t.Cleanup(ts.Close)
+
+	s := testServer()
+	s.cfg.API.Tokens = []WardenToken{
+		{Name: "ops-ro", Token: "viewer-token", Role: RoleViewer},
+		{Name: "alice", Token: "alice-token", Role: RoleOperator},
+	}
+	s.cfg.Agents[0].URL = ts.URL
+	s.cfg.Agents[0].APIToken = "agent-api-token"
+	return s
+}
+
+func agentRequest(method, path, token string) *http.Request {
+	r := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func TestAgentAction_ForwardsAndAudits(t *testing.T) {
+	var gotPath, gotAuth string
+	s := controlServer(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"triggered"}`))
+	})
+
+	w := httptest.NewRecorder()
+	s.handleAgentAction(w, agentRequest(http.MethodPost, "/api/agents/agent-1/redeploy/myapp", "alice-token"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if gotPath != "/redeploy/myapp" {
+		t.Errorf("agent path = %q, want /redeploy/myapp", gotPath)
+	}
+	if gotAuth != "Bearer agent-api-token" {
+		t.Errorf("agent auth = %q, want the agent api_token", gotAuth)
+	}
+	if !strings.Contains(w.Body.String(), "triggered") {
+		t.Errorf("agent response not relayed: %s", w.Body.String())
+	}
+
+	recent := s.store.Recent(1)
+	if len(recent) != 1 {
+		t.Fatalf("want 1 audit entry, got %d", len(recent))
+	}
+	e := recent[0]
+	if e.Event != "agent_action" || e.Machine != "agent-1" || e.Service != "myapp" || e.Level != "info" {
+		t.Errorf("unexpected audit entry: %+v", e)
+	}
+	if !strings.Contains(e.Message, "by alice") {
+		t.Errorf("audit message does not name the user: %q", e.Message)
+	}
+}
+
+func TestAgentAction_AdminTokenActsAsAdmin(t *testing.T) {
+	s := controlServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	s.handleAgentAction(w, agentRequest(http.MethodPost, "/api/agents/agent-1/trigger", "warden-token"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	if msg := s.store.Recent(1)[0].Message; !strings.Contains(msg, "all services") || !strings.Contains(msg, "by admin") {
+		t.Errorf("unexpected audit message: %q", msg)
+	}
+}
+
+func TestAgentAction_ViewerForbidden(t *testing.T) {
+	called := false
+	s := controlServer(t, func(w http.ResponseWriter, r *http.Request) { called = true })
+
+	w := httptest.NewRecorder()
+	s.handleAgentAction(w, agentRequest(http.MethodPost, "/api/agents/agent-1/unblock/myapp", "viewer-token"))
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("want 403, got %d", w.Code)
+	}
+	if called {
+		t.Error("agent must not be called for a viewer")
+	}
+	if e := s.store.Recent(1); len(e) != 1 || e[0].Level != "warning" || !strings.Contains(e[0].Message, "ops-ro") {
+		t.Errorf("rejection not audited: %+v", e)
+	}
+}
+
+func TestAgentAction_Unauthorized(t *testing.T) {
+	s := controlServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, token := range []string{"", "agent-token-1", "wrong"} {
+		w := httptest.NewRecorder()
+		s.handleAgentAction(w, agentRequest(http.MethodPost, "/api/agents/agent-1/trigger", token))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("token %q: want 401, got %d", token, w.Code)
+		}
+	}
+	if n := len(s.store.Recent(10)); n != 0 {
+		t.Errorf("unauthenticated requests must not be audited, got %d entries", n)
+	}
+}
+
+func TestAgentAction_BadRequests(t *testing.T) {
+	s := controlServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	cases := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodPost, "/api/agents/agent-1/rollback/myapp", http.StatusNotFound},
+		{http.MethodPost, "/api/agents/nope/trigger", http.StatusNotFound},
+		{http.MethodGet, "/api/agents/agent-1/trigger", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/agents/agent-1/config", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/api/agents/agent-1/redeploy", http.StatusBadRequest},
+		{http.MethodPost, "/api/agents/agent-1/redeploy/a/b", http.StatusBadRequest},
+		{http.MethodGet, "/api/agents/agent-1/config/x", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		s.handleAgentAction(w, agentRequest(c.method, c.path, "alice-token"))
+		if w.Code != c.want {
+			t.Errorf("%s %s: want %d, got %d", c.method, c.path, c.want, w.Code)
+		}
+	}
+}
+
+func TestAgentAction_AgentFailure(t *testing.T) {
+	s := controlServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	})
+
+	w := httptest.NewRecorder()
+	s.handleAgentAction(w, agentRequest(http.MethodGet, "/api/agents/agent-1/config", "alice-token"))
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("want agent status 403 relayed, got %d", w.Code)
+	}
+	e := s.store.Recent(1)[0]
+	if e.Level != "warning" || !strings.Contains(e.Reason, "403") {
+		t.Errorf("failure not audited: %+v", e)
+	}
+}
+
+func TestAgentAction_AgentUnreachable(t *testing.T) {
+	s := testServer()
+	s.cfg.Agents[0].URL = "http://127.0.0.1:1"
+
+	w := httptest.NewRecorder()
+	s.handleAgentAction(w, agentRequest(http.MethodPost, "/api/agents/agent-1/trigger", "warden-token"))
+
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("want 502, got %d", w.Code)
+	}
+}
+
+func TestAuthenticate_Sources(t *testing.T) {
+	s := controlServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	r := httptest.NewRequest(http.MethodGet, "/?token=viewer-token", nil)
+	if p, ok := s.authenticate(r); !ok || p.Name != "ops-ro" || p.allows(RoleOperator) {
+		t.Errorf("query token: got %+v, %v", p, ok)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: authCookie, Value: "alice-token"})
+	if p, ok := s.authenticate(r); !ok || p.Name != "alice" || !p.allows(RoleViewer) {
+		t.Errorf("cookie token: got %+v, %v", p, ok)
+	}
+}
+
+func TestWardenConfigValidation_Tokens(t *testing.T) {
+	base := func() *WardenConfig {
+		return &WardenConfig{API: WardenAPI{Token: "t"}}
+	}
+	cases := []struct {
+		name   string
+		tokens []WardenToken
+		ok     bool
+	}{
+		{"valid", []WardenToken{{Name: "alice", Token: "a", Role: RoleOperator}}, true},
+		{"missing name", []WardenToken{{Token: "a", Role: RoleViewer}}, false},
+		{"reserved name", []WardenToken{{Name: adminName, Token: "a", Role: RoleViewer}}, false},
+		{"duplicate", []WardenToken{{Name: "a", Token: "1", Role: RoleViewer}, {Name: "a", Token: "2", Role: RoleViewer}}, false},
+		{"missing token", []WardenToken{{Name: "a", Role: RoleViewer}}, false},
+		{"bad role", []WardenToken{{Name: "a", Token: "1", Role: "root"}}, false},
+	}
+	for _, c := range cases {
+		cfg := base()
+		cfg.API.Tokens = c.tokens
+		if err := cfg.validate(); (err == nil) != c.ok {
+			t.Errorf("%s: validate() = %v, want ok=%v", c.name, err, c.ok)
+		}
+	}
+
+	cfg := base()
+	cfg.Agents = []AgentConfig{{ID: "a/b", URL: "http://a", Token: "t"}}
+	if err := cfg.validate(); err == nil {
+		t.Error("agent id containing '/' must be rejected")
+	}
+}
@@ -0,0 +1,414 @@
+package warden
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/audit"
+	"github.com/studiowebux/dockward/internal/logger"
+)
+
+const (
+	segmentSize   = 16 << 20 // bytes; the active segment is closed beyond this
+	segmentPrefix = "events-"
+	segmentSuffix = ".jsonl"
+
+	defaultEventLimit = 100
+	maxEventLimit     = 1000
+)
+
+// blockEvents is the number of events per index block of a segment.
+var blockEvents int64 = 1024
+
+// Event is an audit entry with the sequence number assigned by the event log.
+// IDs increase in ingest order and serve as pagination cursors.
+type Event struct {
+	ID int64 `json:"id"`
+	audit.Entry
+}
+
+// EventFilter selects events in a query. Empty fields match everything.
+type EventFilter struct {
+	Machine string
+	Service string
+	Event   string
+	Level   string
+	Since   time.Time // inclusive; zero = unbounded
+	Until   time.Time // exclusive; zero = unbounded
+	Before  int64     // only events with a smaller ID; 0 = from the newest
+	Limit   int
+}
+
+func (f EventFilter) match(e Event) bool {
+	return (f.Before == 0 || e.ID < f.Before) &&
+		(f.Machine == "" || e.Machine == f.Machine) &&
+		(f.Service == "" || e.Service == f.Service) &&
+		(f.Event == "" || e.Entry.Event == f.Event) &&
+		(f.Level == "" || e.Level == f.Level) &&
+		(f.Since.IsZero() || !e.Timestamp.Before(f.Since)) &&
+		(f.Until.IsZero() || e.Timestamp.Before(f.Until))
+}
+
+// segment is one JSON Lines file of the event log. Its name carries the ID
+// of its first event, so segments sort and skip by ID without being read.
+type segment struct {
+	first int64
+	path  string
+	// blocks index the segment so a query reads only the blocks its cursor
+	// and time range can match. Closed segments found at startup are indexed
+	// by the first query that reads them.
+	blocks  []block
+	indexed bool
+}
+
+// block is a run of up to blockEvents consecutive events of a segment.
+type block struct {
+	first            int64 // ID of its first event
+	offset           int64 // byte offset of its first event
+	minTime, maxTime time.Time
+}
+
+// add indexes an event stored at offset.
+func (s *segment) add(ev Event, offset int64) {
+	n := len(s.blocks)
+	if n == 0 || ev.ID-s.blocks[n-1].first >= blockEvents {
+		s.blocks = append(s.blocks, block{first: ev.ID, offset: offset, minTime: ev.Timestamp, maxTime: ev.Timestamp})
+		return
+	}
+	b := &s.blocks[n-1]
+	if ev.Timestamp.Before(b.minTime) {
+		b.minTime = ev.Timestamp
+	}
+	if ev.Timestamp.After(b.maxTime) {
+		b.maxTime = ev.Timestamp
+	}
+}
+
+// EventLog is the warden's persistent, append-only event store: a directory
+// of JSON Lines segments. Segments older than maxAge, and the oldest beyond
+// maxBytes in total, are deleted by Prune.
+type EventLog struct {
+	dir      string
+	maxAge   time.Duration
+	maxBytes int64
+
+	mu       sync.Mutex
+	segments []segment // oldest first; the last one is active
+	file     *os.File  // active segment, opened for append
+	size     int64     // bytes in the active segment
+	nextID   int64
+}
+
+// OpenEventLog opens (or creates) the event log in dir and resumes numbering
+// after the last stored event.
+func OpenEventLog(dir string, maxAge time.Duration, maxBytes int64) (*EventLog, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create event dir: %w", err)
+	}
+	l := &EventLog{dir: dir, maxAge: maxAge, maxBytes: maxBytes, nextID: 1}
+
+	names, err := filepath.Glob(filepath.Join(dir, segmentPrefix+"*"+segmentSuffix))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		id := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(name), segmentPrefix), segmentSuffix)
+		first, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			continue // not a segment
+		}
+		l.segments = append(l.segments, segment{first: first, path: name})
+	}
+	sort.Slice(l.segments, func(i, j int) bool { return l.segments[i].first < l.segments[j].first })
+
+	if n := len(l.segments); n > 0 {
+		last := &l.segments[n-1]
+		l.nextID = last.first
+		err := scanSegment(last.path, 0, -1, func(ev Event, offset int64) {
+			last.add(ev, offset)
+			l.nextID = ev.ID + 1
+		})
+		if err != nil {
+			return nil, err
+		}
+		last.indexed = true
+	}
+	if err := l.openActive(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// openActive opens the last segment for append, or starts one at nextID.
+// Caller holds l.mu (or has exclusive access).
+func (l *EventLog) openActive() error {
+	if len(l.segments) == 0 {
+		l.segments = append(l.segments, segment{
+			first:   l.nextID,
+			path:    filepath.Join(l.dir, fmt.Sprintf("%s%016d%s", segmentPrefix, l.nextID, segmentSuffix)),
+			indexed: true,
+		})
+	}
+	path := l.segments[len(l.segments)-1].path
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) // #nosec G304 -- path built from configured dir
+	if err != nil {
+		return fmt.Errorf("open event segment: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("stat event segment: %w", err)
+	}
+	l.file = f
+	l.size = info.Size()
+	if l.size > 0 && !endsWithNewline(path, l.size) {
+		// A crash tore the last line; terminate it so the next event is not merged into it.
+		n, _ := f.Write([]byte{'\n'})
+		l.size += int64(n)
+	}
+	return nil
+}
+
+func endsWithNewline(path string, size int64) bool {
+	f, err := os.Open(path) // #nosec G304 -- path built from configured dir
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	b := make([]byte, 1)
+	if _, err := f.ReadAt(b, size-1); err != nil {
+		return true
+	}
+	return b[0] == '\n'
+}
+
+// Append stores entry under the next ID, starting a new segment when the
+// active one is full.
+func (l *EventLog) Append(entry audit.Entry) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return 0, fmt.Errorf("event log closed")
+	}
+	if l.size >= segmentSize {
+		l.file.Close()
+		l.segments = append(l.segments, segment{
+			first:   l.nextID,
+			path:    filepath.Join(l.dir, fmt.Sprintf("%s%016d%s", segmentPrefix, l.nextID, segmentSuffix)),
+			indexed: true,
+		})
+		if err := l.openActive(); err != nil {
+			l.file = nil
+			return 0, err
+		}
+	}
+
+	ev := Event{ID: l.nextID, Entry: entry}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return 0, fmt.Errorf("marshal event: %w", err)
+	}
+	offset := l.size
+	n, err := l.file.Write(append(data, '\n'))
+	l.size += int64(n)
+	if err != nil {
+		return 0, fmt.Errorf("write event: %w", err)
+	}
+	l.segments[len(l.segments)-1].add(ev, offset)
+	l.nextID++
+	return ev.ID, nil
+}
+
+// Query returns up to f.Limit events matching f, newest first, and the cursor
+// for the next page (0 when there are no older events). Only the index blocks
+// the cursor and time range can match are read.
+func (l *EventLog) Query(f EventFilter) ([]Event, int64, error) {
+	if f.Limit <= 0 {
+		f.Limit = defaultEventLimit
+	}
+	l.mu.Lock()
+	segments := make([]segment, len(l.segments))
+	for i, seg := range l.segments {
+		seg.blocks = append([]block(nil), seg.blocks...) // the active one grows
+		segments[i] = seg
+	}
+	activeSize := l.size
+	l.mu.Unlock()
+
+	var out []Event
+	for i := len(segments) - 1; i >= 0; i-- {
+		seg := segments[i]
+		if f.Before != 0 && seg.first >= f.Before {
+			continue
+		}
+		if !f.Since.IsZero() && i < len(segments)-1 {
+			// Segments are only written while active: one last modified
+			// before Since holds no event ingested after it.
+			if info, err := os.Stat(seg.path); err == nil && info.ModTime().Before(f.Since) {
+				break
+			}
+		}
+		end := int64(-1)
+		if i == len(segments)-1 {
+			end = activeSize // ignore a line being appended concurrently
+		}
+		if !seg.indexed {
+			err := scanSegment(seg.path, 0, end, seg.add)
+			if os.IsNotExist(err) {
+				continue // pruned meanwhile
+			}
+			if err != nil {
+				return nil, 0, err
+			}
+			l.setBlocks(seg.first, seg.blocks)
+		}
+		for k := len(seg.blocks) - 1; k >= 0; k-- {
+			b := seg.blocks[k]
+			if (f.Before != 0 && b.first >= f.Before) ||
+				(!f.Until.IsZero() && !b.minTime.Before(f.Until)) ||
+				(!f.Since.IsZero() && b.maxTime.Before(f.Since)) {
+				continue
+			}
+			stop := end
+			if k+1 < len(seg.blocks) {
+				stop = seg.blocks[k+1].offset
+			}
+			var events []Event
+			err := scanSegment(seg.path, b.offset, stop, func(ev Event, _ int64) { events = append(events, ev) })
+			if os.IsNotExist(err) {
+				break // pruned meanwhile
+			}
+			if err != nil {
+				return nil, 0, err
+			}
+			for j := len(events) - 1; j >= 0; j-- {
+				if !f.match(events[j]) {
+					continue
+				}
+				if len(out) == f.Limit {
+					return out, out[len(out)-1].ID, nil
+				}
+				out = append(out, events[j])
+			}
+		}
+	}
+	return out, 0, nil
+}
+
+// setBlocks stores the index built for the closed segment starting at first.
+func (l *EventLog) setBlocks(first int64, blocks []block) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.segments {
+		if l.segments[i].first == first && !l.segments[i].indexed {
+			l.segments[i].blocks, l.segments[i].indexed = blocks, true
+			return
+		}
+	}
+}
+
+// Recent returns the last n events, oldest first.
+func (l *EventLog) Recent(n int) ([]Event, error) {
+	events, _, err := l.Query(EventFilter{Limit: n})
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, err
+}
+
+// scanSegment decodes the events stored between the byte offsets from and to
+// of a segment (to the end when to is negative) and passes each to fn with
+// its offset. Undecodable lines are skipped; lines are not length-limited.
+func scanSegment(path string, from, to int64, fn func(ev Event, offset int64)) error {
+	f, err := os.Open(path) // #nosec G304 -- path built from configured dir
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(from, io.SeekStart); err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	var r io.Reader = f
+	if to >= 0 {
+		r = io.LimitReader(f, to-from)
+	}
+	offset := from
+	br := bufio.NewReader(r)
+	for {
+		raw, err := br.ReadBytes('\n')
+		at := offset
+		offset += int64(len(raw))
+		var ev Event
+		if line := bytes.TrimSpace(raw); len(line) > 0 && json.Unmarshal(line, &ev) == nil && ev.ID != 0 {
+			fn(ev, at)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+	}
+}
+
+// Prune deletes closed segments last written before now-maxAge, then the
+// oldest closed segments until the log fits in maxBytes. The active segment
+// is never deleted.
+func (l *EventLog) Prune(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var total int64
+	sizes := make([]int64, len(l.segments))
+	mtimes := make([]time.Time, len(l.segments))
+	for i, seg := range l.segments {
+		if info, err := os.Stat(seg.path); err == nil {
+			sizes[i] = info.Size()
+			mtimes[i] = info.ModTime()
+			total += sizes[i]
+		}
+	}
+
+	removed := 0
+	for i := 0; i < len(l.segments)-1; i++ {
+		expired := l.maxAge > 0 && mtimes[i].Before(now.Add(-l.maxAge))
+		oversize := l.maxBytes > 0 && total > l.maxBytes
+		if !expired && !oversize {
+			break
+		}
+		if err := os.Remove(l.segments[i].path); err != nil && !os.IsNotExist(err) {
+			logger.Printf("warden: prune %s: %v", l.segments[i].path, err)
+			break
+		}
+		total -= sizes[i]
+		removed++
+	}
+	if removed > 0 {
+		l.segments = append([]segment(nil), l.segments[removed:]...)
+		logger.Printf("warden: pruned %d event segment(s)", removed)
+	}
+}
+
+// Close closes the active segment.
+func (l *EventLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
@@ -0,0 +1,233 @@
+package warden
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/audit"
+)
+
+func openTestLog(t *testing.T, dir string) *EventLog {
+	t.Helper()
+	l, err := OpenEventLog(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("OpenEventLog: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func TestEventLog_QueryFiltersAndPages(t *testing.T) {
+	l := openTestLog(t, t.TempDir())
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		machine := "ovh-01"
+		if i%2 == 1 {
+			machine = "ovh-02"
+		}
+		e := audit.Entry{Timestamp: base.Add(time.Duration(i) * time.Minute), Machine: machine, Service: "web", Event: "updated", Level: "info", Message: fmt.Sprint(i)}
+		if _, err := l.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, next, err := l.Query(EventFilter{Machine: "ovh-01", Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 3 || page[0].Message != "8" || page[2].Message != "4" || next != page[2].ID {
+		t.Fatalf("first page = %+v, next %d", page, next)
+	}
+	page, next, _ = l.Query(EventFilter{Machine: "ovh-01", Limit: 3, Before: next})
+	if len(page) != 2 || page[0].Message != "2" || page[1].Message != "0" || next != 0 {
+		t.Fatalf("last page = %+v, next %d", page, next)
+	}
+
+	page, _, _ = l.Query(EventFilter{Since: base.Add(3 * time.Minute), Until: base.Add(5 * time.Minute)})
+	if len(page) != 2 || page[0].Message != "4" || page[1].Message != "3" {
+		t.Fatalf("time range = %+v", page)
+	}
+}
+
+func TestEventLog_ResumesIDsAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+	l, err := OpenEventLog(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Append(makeEntry("a"))
+	l.Append(makeEntry("b"))
+	l.Close()
+
+	// Simulate a torn write at the end of the segment.
+	seg := filepath.Join(dir, fmt.Sprintf("%s%016d%s", segmentPrefix, 1, segmentSuffix))
+	f, _ := os.OpenFile(seg, os.O_APPEND|os.O_WRONLY, 0o600)
+	f.WriteString(`{"id":3,"serv`)
+	f.Close()
+
+	l = openTestLog(t, dir)
+	id, err := l.Append(makeEntry("c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 3 {
+		t.Errorf("want id 3 after reopen, got %d", id)
+	}
+	recent, _ := l.Recent(10)
+	if len(recent) != 3 || recent[0].Message != "a" || recent[2].Message != "c" {
+		t.Errorf("recent = %+v", recent)
+	}
+}
+
+func TestEventLog_QueryAcrossIndexBlocks(t *testing.T) {
+	defer func(n int64) { blockEvents = n }(blockEvents)
+	blockEvents = 3
+
+	dir := t.TempDir()
+	l, err := OpenEventLog(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 12; i++ {
+		if i == 9 {
+			// Start a second segment by hand, as Append does when the first is full.
+			l.size = segmentSize
+		}
+		l.Append(audit.Entry{Timestamp: base.Add(time.Duration(i) * time.Minute), Service: "web", Message: fmt.Sprint(i)})
+	}
+	l.Close()
+
+	// The closed segment is indexed by the first query that reads it.
+	l = openTestLog(t, dir)
+	if l.segments[0].indexed || !l.segments[1].indexed || len(l.segments[1].blocks) != 2 {
+		t.Fatalf("after reopen: segments = %+v", l.segments)
+	}
+
+	page, next, err := l.Query(EventFilter{Before: 11, Limit: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 4 || page[0].Message != "10" || page[3].Message != "7" || next != 7 {
+		t.Fatalf("page = %+v, next %d", page, next)
+	}
+	if !l.segments[0].indexed || len(l.segments[0].blocks) != 3 {
+		t.Errorf("closed segment not indexed: %+v", l.segments[0])
+	}
+
+	page, _, _ = l.Query(EventFilter{Since: base.Add(4 * time.Minute), Until: base.Add(10 * time.Minute)})
+	if len(page) != 6 || page[0].Message != "9" || page[5].Message != "4" {
+		t.Errorf("time range = %+v", page)
+	}
+}
+
+func TestEventLog_LongLines(t *testing.T) {
+	dir := t.TempDir()
+	l, err := OpenEventLog(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Append(audit.Entry{Message: "long", Output: strings.Repeat("x", 2<<20)})
+	l.Append(makeEntry("next"))
+	l.Close()
+
+	l = openTestLog(t, dir)
+	recent, err := l.Recent(10)
+	if err != nil {
+		t.Fatalf("Recent: %v", err)
+	}
+	if len(recent) != 2 || recent[0].Message != "long" || recent[1].Message != "next" {
+		t.Errorf("recent = %d events", len(recent))
+	}
+}
+
+func TestEventLog_PruneBySizeKeepsActiveSegment(t *testing.T) {
+	dir := t.TempDir()
+	l := openTestLog(t, dir)
+	l.Append(makeEntry("old"))
+
+	// Start a second segment by hand, as Append does when the first is full.
+	l.mu.Lock()
+	l.size = segmentSize
+	l.mu.Unlock()
+	l.Append(makeEntry("new"))
+
+	l.maxBytes = 1
+	l.Prune(time.Now())
+
+	if len(l.segments) != 1 {
+		t.Fatalf("want only the active segment left, got %d", len(l.segments))
+	}
+	recent, _ := l.Recent(10)
+	if len(recent) != 1 || recent[0].Message != "new" {
+		t.Errorf("recent after prune = %+v", recent)
+	}
+}
+
+func TestHandleEvents(t *testing.T) {
+	s := testServer()
+	s.events = openTestLog(t, t.TempDir())
+	s.store.log = s.events
+	s.store.Append(audit.Entry{Machine: "agent-1", Service: "web", Level: "info", Message: "first"})
+	s.store.Append(audit.Entry{Machine: "agent-2", Service: "web", Level: "warning", Message: "second"})
+
+	tests := []struct {
+		name     string
+		query    string
+		auth     string
+		wantCode int
+		wantMsgs []string
+	}{
+		{"unauthorized", "", "", http.StatusUnauthorized, nil},
+		{"all newest first", "", "Bearer warden-token", http.StatusOK, []string{"second", "first"}},
+		{"filter level", "?level=info", "Bearer warden-token", http.StatusOK, []string{"first"}},
+		{"bad limit", "?limit=0", "Bearer warden-token", http.StatusBadRequest, nil},
+		{"bad since", "?since=yesterday", "Bearer warden-token", http.StatusBadRequest, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/events"+tc.query, nil)
+			if tc.auth != "" {
+				r.Header.Set("Authorization", tc.auth)
+			}
+			w := httptest.NewRecorder()
+			s.handleEvents(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d, got %d", tc.wantCode, w.Code)
+			}
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+			var page eventsPage
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Events) != len(tc.wantMsgs) {
+				t.Fatalf("want %d events, got %+v", len(tc.wantMsgs), page.Events)
+			}
+			for i, msg := range tc.wantMsgs {
+				if page.Events[i].Message != msg {
+					t.Errorf("event %d: want %q, got %q", i, msg, page.Events[i].Message)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleEvents_DisabledWithoutStore(t *testing.T) {
+	s := testServer()
+	r := httptest.NewRequest(http.MethodGet, "/api/events?token=warden-token", nil)
+	w := httptest.NewRecorder()
+	s.handleEvents(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("want 404 without events.dir, got %d", w.Code)
+	}
+}
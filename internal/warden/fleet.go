@@ -0,0 +1,175 @@
+package warden
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/logger"
+)
+
+// maxStatusResponse is the number of bytes of an agent's GET /status decoded.
+const maxStatusResponse = 4 << 20
+
+// ServiceStatus is the part of an agent's GET /status service entry the
+// warden keeps for the fleet view. Field names match the agent's JSON.
+type ServiceStatus struct {
+	Name          string        `json:"name"`
+	Status        string        `json:"status"` // agent's summary word: ok, unhealthy, blocked, errored, ...
+	Healthy       *bool         `json:"healthy,omitempty"`
+	Deploying     bool          `json:"deploying"`
+	Blocked       string        `json:"blocked,omitempty"`
+	Errored       string        `json:"errored,omitempty"`
+	NotFound      string        `json:"not_found,omitempty"`
+	Images        []ImageStatus `json:"images,omitempty"`
+	HasStats      bool          `json:"has_stats"`
+	CPUPercent    float64       `json:"cpu_percent,omitempty"`
+	MemoryPercent float64       `json:"memory_percent,omitempty"`
+	MemoryUsageMB float64       `json:"memory_usage_mb,omitempty"`
+	MemoryLimitMB float64       `json:"memory_limit_mb,omitempty"`
+}
+
+// ImageStatus is a deployed image as reported by an agent.
+type ImageStatus struct {
+	Image  string `json:"image"`
+	Digest string `json:"digest"`
+	Short  string `json:"short"`
+}
+
+// Fleet is the machine × service matrix served by GET /api/fleet.
+type Fleet struct {
+	Machines []FleetMachine `json:"machines"`
+	Services []FleetService `json:"services"`
+}
+
+// FleetMachine is one column of the fleet matrix.
+type FleetMachine struct {
+	ID       string    `json:"id"`
+	Online   bool      `json:"online"`
+	StatusAt time.Time `json:"status_at,omitzero"` // when the services were last fetched
+	Error    string    `json:"error,omitempty"`    // last status fetch error
+}
+
+// FleetService is one row of the fleet matrix.
+type FleetService struct {
+	Name string `json:"name"`
+	// Drift is set when machines running the service report different
+	// deployed digests.
+	Drift    bool                     `json:"drift"`
+	Machines map[string]ServiceStatus `json:"machines"` // by agent ID; absent = not configured there
+}
+
+// Fleet builds the fleet matrix from the last status of every agent.
+// Machines and services are sorted by name.
+func (s *Store) Fleet() Fleet {
+	agents := s.AgentStates()
+	sort.Slice(agents, func(i, j int) bool { return agents[i].ID < agents[j].ID })
+
+	f := Fleet{Machines: make([]FleetMachine, 0, len(agents)), Services: []FleetService{}}
+	rows := make(map[string]*FleetService)
+	for _, a := range agents {
+		f.Machines = append(f.Machines, FleetMachine{
+			ID:       a.ID,
+			Online:   a.Online,
+			StatusAt: a.StatusAt,
+			Error:    a.StatusError,
+		})
+		for _, svc := range a.Services {
+			row, ok := rows[svc.Name]
+			if !ok {
+				row = &FleetService{Name: svc.Name, Machines: make(map[string]ServiceStatus)}
+				rows[svc.Name] = row
+			}
+			row.Machines[a.ID] = svc
+		}
+	}
+	for _, row := range rows {
+		row.Drift = digestDrift(row.Machines)
+		f.Services = append(f.Services, *row)
+	}
+	sort.Slice(f.Services, func(i, j int) bool { return f.Services[i].Name < f.Services[j].Name })
+	return f
+}
+
+// digestDrift reports whether machines running a service are on different
+// digests. Machines that report no digest yet are ignored.
+func digestDrift(machines map[string]ServiceStatus) bool {
+	seen := ""
+	for _, svc := range machines {
+		var digests []string
+		for _, img := range svc.Images {
+			if img.Digest != "" {
+				digests = append(digests, img.Digest)
+			}
+		}
+		if len(digests) == 0 {
+			continue
+		}
+		sort.Strings(digests)
+		key := strings.Join(digests, ",")
+		if seen == "" {
+			seen = key
+		} else if key != seen {
+			return true
+		}
+	}
+	return false
+}
+
+// pollStatus fetches an agent's GET /status, authenticated with its
+// api_token, and records the services in the store. On failure the previous
+// services are kept and the error is recorded.
+func (hb *Heartbeat) pollStatus(ctx context.Context, a AgentConfig) {
+	services, err := hb.fetchStatus(ctx, a)
+	prev := hb.store.SetAgentStatus(a.ID, services, err)
+	if err != nil && err.Error() != prev {
+		logger.Printf("warden: status %s: %v", a.ID, err)
+	}
+}
+
+func (hb *Heartbeat) fetchStatus(ctx context.Context, a AgentConfig) ([]ServiceStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL+"/status", nil)
+	if err != nil {
+		return nil, err
+	}
+	if a.APIToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.APIToken)
+	}
+	resp, err := hb.http.Do(req) // #nosec G704 -- URL from config, not user input
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET /status: status %d", resp.StatusCode)
+	}
+	var body struct {
+		Services []ServiceStatus `json:"services"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxStatusResponse)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode /status: %w", err)
+	}
+	return body.Services, nil
+}
+
+// handleFleet handles GET /api/fleet: the machine × service matrix built from
+// each agent's last /status. Requires a warden token of any role.
+func (s *Server) handleFleet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := s.authenticate(r); !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.store.Fleet()); err != nil {
+		logger.Printf("warden: write fleet: %v", err)
+	}
+}
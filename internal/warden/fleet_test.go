@@ -0,0 +1,161 @@
+package warden
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/hub"
+)
+
+func TestStore_Fleet_Matrix(t *testing.T) {
+	s := NewStore([]AgentConfig{{ID: "b"}, {ID: "a"}, {ID: "c"}})
+	s.SetAgentStatus("a", []ServiceStatus{
+		{Name: "web", Status: "ok", Images: []ImageStatus{{Digest: "sha256:1"}}},
+		{Name: "db", Status: "ok"},
+	}, nil)
+	s.SetAgentStatus("b", []ServiceStatus{
+		{Name: "web", Status: "blocked", Images: []ImageStatus{{Digest: "sha256:2"}}},
+	}, nil)
+
+	f := s.Fleet()
+
+	if len(f.Machines) != 3 || f.Machines[0].ID != "a" || f.Machines[2].ID != "c" {
+		t.Fatalf("machines not sorted: %+v", f.Machines)
+	}
+	if len(f.Services) != 2 || f.Services[0].Name != "db" || f.Services[1].Name != "web" {
+		t.Fatalf("services not sorted: %+v", f.Services)
+	}
+	web := f.Services[1]
+	if !web.Drift {
+		t.Error("web runs different digests on a and b: want drift")
+	}
+	if web.Machines["b"].Status != "blocked" {
+		t.Errorf("want b/web blocked, got %+v", web.Machines["b"])
+	}
+	if _, ok := web.Machines["c"]; ok {
+		t.Error("c does not run web")
+	}
+	if f.Services[0].Drift {
+		t.Error("db runs on one machine only: want no drift")
+	}
+}
+
+func TestDigestDrift(t *testing.T) {
+	img := func(d ...string) []ImageStatus {
+		var out []ImageStatus
+		for _, x := range d {
+			out = append(out, ImageStatus{Digest: x})
+		}
+		return out
+	}
+	cases := []struct {
+		name string
+		m    map[string]ServiceStatus
+		want bool
+	}{
+		{"same", map[string]ServiceStatus{"a": {Images: img("d1")}, "b": {Images: img("d1")}}, false},
+		{"different", map[string]ServiceStatus{"a": {Images: img("d1")}, "b": {Images: img("d2")}}, true},
+		{"order ignored", map[string]ServiceStatus{"a": {Images: img("d1", "d2")}, "b": {Images: img("d2", "d1")}}, false},
+		{"unknown ignored", map[string]ServiceStatus{"a": {Images: img("d1")}, "b": {}}, false},
+	}
+	for _, c := range cases {
+		if got := digestDrift(c.m); got != c.want {
+			t.Errorf("%s: digestDrift = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestStore_SetAgentStatus_ErrorKeepsServices(t *testing.T) {
+	s := NewStore([]AgentConfig{{ID: "a"}})
+	s.SetAgentStatus("a", []ServiceStatus{{Name: "web"}}, nil)
+
+	prev := s.SetAgentStatus("a", nil, errors.New("status 401"))
+
+	if prev != "" {
+		t.Errorf("want empty previous error, got %q", prev)
+	}
+	a := s.AgentStates()[0]
+	if len(a.Services) != 1 || a.StatusError != "status 401" {
+		t.Errorf("want services kept and error recorded, got %+v", a)
+	}
+}
+
+func TestHeartbeat_PollStatus(t *testing.T) {
+	var gotAuth string
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"uptime_seconds":5,"services":[{"name":"web","status":"ok","has_stats":true,"cpu_percent":12.5,"images":[{"image":"web:latest","digest":"sha256:1","short":"sha256:1"}]}]}`))
+	}))
+	defer agent.Close()
+
+	cfg := AgentConfig{ID: "a", URL: agent.URL, APIToken: "viewer"}
+	store := NewStore([]AgentConfig{cfg})
+	hb := NewHeartbeat(store, nil, []AgentConfig{cfg})
+
+	hb.pollStatus(context.Background(), cfg)
+
+	if gotAuth != "Bearer viewer" {
+		t.Errorf("want agent api_token sent, got %q", gotAuth)
+	}
+	a := store.AgentStates()[0]
+	if len(a.Services) != 1 || a.Services[0].CPUPercent != 12.5 || a.StatusAt.IsZero() {
+		t.Errorf("status not recorded: %+v", a)
+	}
+}
+
+func TestHeartbeat_PollsAgentsConcurrently(t *testing.T) {
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/status" {
+			time.Sleep(time.Second)
+		}
+		_, _ = w.Write([]byte(`{"services":[]}`))
+	}))
+	defer agent.Close()
+
+	var agents []AgentConfig
+	for _, id := range []string{"a", "b", "c"} {
+		agents = append(agents, AgentConfig{ID: id, URL: agent.URL})
+	}
+	store := NewStore(agents)
+	hb := NewHeartbeat(store, hub.NewHub(), agents)
+
+	start := time.Now()
+	hb.pollAll(context.Background())
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("pollAll took %s; slow agents should be polled concurrently", elapsed)
+	}
+	for _, a := range store.AgentStates() {
+		if !a.Online || a.StatusAt.IsZero() {
+			t.Errorf("agent %s not polled: %+v", a.ID, a)
+		}
+	}
+}
+
+func TestHandleFleet(t *testing.T) {
+	s := testServer()
+	s.store.SetAgentStatus("agent-1", []ServiceStatus{{Name: "web", Status: "ok"}}, nil)
+
+	w := httptest.NewRecorder()
+	s.handleFleet(w, httptest.NewRequest(http.MethodGet, "/api/fleet", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("want 401 without token, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleFleet(w, agentRequest(http.MethodGet, "/api/fleet", "warden-token"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	var f Fleet
+	if err := json.Unmarshal(w.Body.Bytes(), &f); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Machines) != 2 || len(f.Services) != 1 || f.Services[0].Machines["agent-1"].Status != "ok" {
+		t.Errorf("unexpected fleet: %+v", f)
+	}
+}
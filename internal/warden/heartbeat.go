@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"github.com/studiowebux/dockward/internal/logger"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/studiowebux/dockward/internal/audit"
@@ -15,27 +16,29 @@ const heartbeatInterval = 30 * time.Second
 
 // Heartbeat polls each configured agent's GET /health endpoint every 30 seconds
 // and emits synthetic audit entries on state transitions (online → offline, offline → online).
+// An agent's state is unknown until its first poll: an agent already down at
+// startup is reported offline, one already up is not reported.
+// Online agents are also asked for GET /status to keep the fleet view current.
+// Agents are polled concurrently, so a slow one does not delay the others.
 type Heartbeat struct {
 	store  *Store
 	hub    *hub.Hub
 	agents []AgentConfig
 	http   *http.Client
-	// online tracks the last known online state per agent ID.
-	online map[string]bool
+	alerts *alertRouter // nil = no warden notifications
+	// online tracks the last known online state per agent ID; absent = unknown.
+	online   map[string]bool
+	onlineMu sync.Mutex
 }
 
 // NewHeartbeat creates a Heartbeat ready to run.
 func NewHeartbeat(store *Store, h *hub.Hub, agents []AgentConfig) *Heartbeat {
-	online := make(map[string]bool, len(agents))
-	for _, a := range agents {
-		online[a.ID] = false // assume offline until first successful check
-	}
 	return &Heartbeat{
 		store:  store,
 		hub:    h,
 		agents: agents,
 		http:   &http.Client{Timeout: 5 * time.Second},
-		online: online,
+		online: make(map[string]bool, len(agents)),
 	}
 }
 
@@ -58,9 +61,15 @@ func (hb *Heartbeat) Run(ctx context.Context) {
 }
 
 func (hb *Heartbeat) pollAll(ctx context.Context) {
+	var wg sync.WaitGroup
 	for _, a := range hb.agents {
-		hb.poll(ctx, a)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hb.poll(ctx, a)
+		}()
 	}
+	wg.Wait()
 }
 
 func (hb *Heartbeat) poll(ctx context.Context, a AgentConfig) {
@@ -77,19 +86,25 @@ func (hb *Heartbeat) poll(ctx context.Context, a AgentConfig) {
 	}
 	_ = resp.Body.Close() // #nosec G104 -- health check; body content is irrelevant
 
-	hb.transition(a.ID, resp.StatusCode == http.StatusOK)
+	online := resp.StatusCode == http.StatusOK
+	hb.transition(a.ID, online)
+	if online {
+		hb.pollStatus(ctx, a)
+	}
 }
 
 // transition handles an online/offline state change for an agent.
 // Emits a synthetic audit entry only when the state changes.
 func (hb *Heartbeat) transition(id string, nowOnline bool) {
-	wasOnline := hb.online[id]
+	hb.onlineMu.Lock()
+	wasOnline, known := hb.online[id]
 	hb.online[id] = nowOnline
+	hb.onlineMu.Unlock()
 
 	hb.store.SetAgentState(id, nowOnline)
 
-	if wasOnline == nowOnline {
-		return // no change; nothing to emit
+	if (known && wasOnline == nowOnline) || (!known && nowOnline) {
+		return // no change, or up from the start; nothing to emit
 	}
 
 	event := "agent_online"
@@ -111,6 +126,7 @@ func (hb *Heartbeat) transition(id string, nowOnline bool) {
 	}
 
 	hb.store.Append(entry)
+	hb.alerts.Route(entry)
 
 	data, err := json.Marshal(entry)
 	if err != nil {
@@ -3,6 +3,7 @@ package warden
 import (
 	"crypto/subtle"
 	"encoding/json"
+	"fmt"
 	"io"
 	"github.com/studiowebux/dockward/internal/logger"
 	"net/http"
@@ -27,9 +28,8 @@ func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20)) // 1 MB limit
+	body, err := readBody(w, r, maxEntryBytes)
 	if err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
 
@@ -39,11 +39,91 @@ func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.ingest(entry)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+const (
+	// maxEntryBytes caps one entry, sent to /ingest or within a batch.
+	maxEntryBytes = 1 << 20
+	// maxBatch caps the entries accepted by one /ingest/batch request.
+	maxBatch = 500
+	// maxBatchBytes caps the body of one /ingest/batch request.
+	maxBatchBytes = 8 << 20
+)
+
+// readBody reads a request body of at most limit bytes. A larger body is
+// answered with 413, so the agent can split it rather than drop it; the
+// error tells the caller a response was written.
+func readBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		http.Error(w, fmt.Sprintf("request too large: at most %d bytes", limit), http.StatusRequestEntityTooLarge)
+		return nil, fmt.Errorf("body over %d bytes", limit)
+	}
+	return body, nil
+}
+
+// handleIngestBatch handles POST /ingest/batch: a JSON array of entries that
+// an agent's push queue delivers in one request. Authenticated like /ingest.
+// Entries are appended and broadcast in array order; returns 204.
+func (s *Server) handleIngestBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := bearerToken(r)
+	if !s.validAgentToken(token) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := readBody(w, r, maxBatchBytes)
+	if err != nil {
+		return
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		http.Error(w, "bad request: invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(raw) > maxBatch {
+		http.Error(w, fmt.Sprintf("batch too large: at most %d entries", maxBatch), http.StatusRequestEntityTooLarge)
+		return
+	}
+	entries := make([]audit.Entry, len(raw))
+	for i, data := range raw {
+		if len(data) > maxEntryBytes {
+			http.Error(w, fmt.Sprintf("entry %d too large: at most %d bytes", i, maxEntryBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err := json.Unmarshal(data, &entries[i]); err != nil {
+			http.Error(w, "bad request: invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	for _, entry := range entries {
+		s.ingest(entry)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ingest appends entry to the store, broadcasts it to SSE clients and hands it
+// to the alert router.
+func (s *Server) ingest(entry audit.Entry) {
 	if entry.Timestamp.IsZero() {
 		entry.Timestamp = time.Now().UTC()
 	}
 
 	s.store.Append(entry)
+	s.alerts.Route(entry)
 
 	data, err := json.Marshal(entry)
 	if err != nil {
@@ -51,8 +131,6 @@ func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
 	} else {
 		s.hub.Broadcast(data)
 	}
-
-	w.WriteHeader(http.StatusNoContent)
 }
 
 // bearerToken extracts the token from "Authorization: Bearer <token>".
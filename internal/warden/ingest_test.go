@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -26,6 +27,8 @@ func testServer() *Server {
 		cfg:   cfg,
 		store: NewStore(cfg.Agents),
 		hub:   hub.NewHub(),
+
+		agentClient: newAgentClient(),
 	}
 }
 
@@ -149,3 +152,63 @@ func TestHandleIngest_BroadcastsToSSEClients(t *testing.T) {
 		t.Error("expected broadcast message in SSE channel")
 	}
 }
+
+func TestHandleIngestBatch_AppendsInOrder(t *testing.T) {
+	s := testServer()
+	entries := []audit.Entry{
+		{Service: "svc", Event: "updated", Message: "first"},
+		{Service: "svc", Event: "healthy", Message: "second"},
+	}
+	body, _ := json.Marshal(entries)
+
+	r := httptest.NewRequest(http.MethodPost, "/ingest/batch", bytes.NewReader(body))
+	r.Header.Set("Authorization", "Bearer agent-token-1")
+	w := httptest.NewRecorder()
+
+	s.handleIngestBatch(w, r)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("want 204, got %d", w.Code)
+	}
+	stored := s.store.Recent(2)
+	if len(stored) != 2 || stored[0].Message != "second" || stored[1].Message != "first" {
+		t.Fatalf("want entries stored in batch order, got %+v", stored)
+	}
+	if stored[1].Timestamp.IsZero() {
+		t.Error("Timestamp should be set automatically")
+	}
+}
+
+func TestHandleIngestBatch_Rejects(t *testing.T) {
+	tooMany, _ := json.Marshal(make([]audit.Entry, maxBatch+1))
+	largeEntry, _ := json.Marshal([]audit.Entry{{Service: "svc"}, {Service: "svc", Output: strings.Repeat("x", maxEntryBytes)}})
+	tests := []struct {
+		name     string
+		authHdr  string
+		body     []byte
+		wantCode int
+	}{
+		{"missing token", "", []byte("[]"), http.StatusUnauthorized},
+		{"single entry object", "Bearer agent-token-1", []byte(`{"service":"svc"}`), http.StatusBadRequest},
+		{"too many entries", "Bearer agent-token-1", tooMany, http.StatusRequestEntityTooLarge},
+		{"entry over 1 MB", "Bearer agent-token-1", largeEntry, http.StatusRequestEntityTooLarge},
+		{"body over 8 MB", "Bearer agent-token-1", bytes.Repeat([]byte(" "), maxBatchBytes+1), http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := testServer()
+			r := httptest.NewRequest(http.MethodPost, "/ingest/batch", bytes.NewReader(tc.body))
+			if tc.authHdr != "" {
+				r.Header.Set("Authorization", tc.authHdr)
+			}
+			w := httptest.NewRecorder()
+
+			s.handleIngestBatch(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Errorf("want %d, got %d", tc.wantCode, w.Code)
+			}
+		})
+	}
+}
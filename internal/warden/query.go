@@ -0,0 +1,99 @@
+package warden
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/logger"
+)
+
+// eventsPage is the GET /api/events response body.
+type eventsPage struct {
+	Events     []Event `json:"events"`
+	NextCursor string  `json:"next_cursor,omitempty"` // pass as ?cursor= for the next (older) page
+}
+
+// handleEvents handles GET /api/events: stored events, newest first, filtered
+// by machine, service, event, level and since/until (RFC 3339), paged with
+// limit and cursor. Requires a warden token of any role (Bearer header,
+// cookie or ?token=) and the persistent event store (events.dir).
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, ok := s.authenticate(r); !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.events == nil {
+		http.Error(w, "event store disabled: set events.dir", http.StatusNotFound)
+		return
+	}
+
+	f, err := parseEventFilter(r)
+	if err != nil {
+		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, next, err := s.events.Query(f)
+	if err != nil {
+		logger.Printf("warden: query events: %v", err)
+		http.Error(w, "query failed", http.StatusInternalServerError)
+		return
+	}
+	page := eventsPage{Events: events}
+	if page.Events == nil {
+		page.Events = []Event{}
+	}
+	if next != 0 {
+		page.NextCursor = strconv.FormatInt(next, 10)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(page); err != nil {
+		logger.Printf("warden: write events: %v", err)
+	}
+}
+
+// parseEventFilter reads the query parameters of GET /api/events.
+func parseEventFilter(r *http.Request) (EventFilter, error) {
+	q := r.URL.Query()
+	f := EventFilter{
+		Machine: q.Get("machine"),
+		Service: q.Get("service"),
+		Event:   q.Get("event"),
+		Level:   q.Get("level"),
+		Limit:   defaultEventLimit,
+	}
+	var err error
+	if v := q.Get("since"); v != "" {
+		if f.Since, err = time.Parse(time.RFC3339, v); err != nil {
+			return f, fmt.Errorf("invalid since")
+		}
+	}
+	if v := q.Get("until"); v != "" {
+		if f.Until, err = time.Parse(time.RFC3339, v); err != nil {
+			return f, fmt.Errorf("invalid until")
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxEventLimit {
+			return f, fmt.Errorf("invalid limit")
+		}
+		f.Limit = n
+	}
+	if v := q.Get("cursor"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 {
+			return f, fmt.Errorf("invalid cursor")
+		}
+		f.Before = n
+	}
+	return f, nil
+}
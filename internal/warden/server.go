@@ -11,31 +11,60 @@ import (
 	"github.com/studiowebux/dockward/internal/saferun"
 )
 
-const shutdownTimeout = 5 * time.Second
+const (
+	shutdownTimeout = 5 * time.Second
+	pruneInterval   = time.Hour // how often event log retention is applied
+)
 
-// Server wires together the store, SSE hub, heartbeat poller, and HTTP server.
+// Server wires together the store, SSE hub, heartbeat poller, agent proxy,
+// alert router and HTTP server.
 type Server struct {
 	cfg       *WardenConfig
 	store     *Store
 	hub       *hub.Hub
 	heartbeat *Heartbeat
+	events    *EventLog // nil when events.dir is not set
 	server    *http.Server
+
+	agentClient *http.Client // proxied agent actions
+	alerts      *alertRouter // nil when no notification channel is configured
 }
 
 // NewServer creates a fully wired warden Server from cfg.
-// If cfg.API.StatePath is set, the ring buffer is loaded from disk immediately.
-func NewServer(cfg *WardenConfig) *Server {
+// With cfg.Events.Dir set, the event log is opened and seeds the ring buffer;
+// otherwise, if cfg.API.StatePath is set, the ring buffer is loaded from disk.
+func NewServer(cfg *WardenConfig) (*Server, error) {
 	store := NewStore(cfg.Agents)
-	store.LoadState(cfg.API.StatePath)
+	var events *EventLog
+	if cfg.Events.Dir != "" {
+		var err error
+		events, err = OpenEventLog(cfg.Events.Dir,
+			time.Duration(cfg.Events.RetentionDays)*24*time.Hour,
+			int64(cfg.Events.MaxSizeMB)<<20)
+		if err != nil {
+			return nil, fmt.Errorf("open event log: %w", err)
+		}
+		store.AttachLog(events)
+	} else {
+		store.LoadState(cfg.API.StatePath)
+	}
+	alerts, err := newAlertRouter(cfg.Notifications)
+	if err != nil {
+		return nil, fmt.Errorf("notifications: %w", err)
+	}
 	h := hub.NewHub()
 	hb := NewHeartbeat(store, h, cfg.Agents)
+	hb.alerts = alerts
 
 	mux := http.NewServeMux()
 	s := &Server{
-		cfg:       cfg,
-		store:     store,
-		hub:       h,
-		heartbeat: hb,
+		cfg:         cfg,
+		store:       store,
+		hub:         h,
+		heartbeat:   hb,
+		events:      events,
+		agentClient: newAgentClient(),
+		alerts:      alerts,
 		server: &http.Server{
 			Addr:              fmt.Sprintf(":%s", cfg.API.Port),
 			Handler:           mux,
@@ -44,17 +73,27 @@ func NewServer(cfg *WardenConfig) *Server {
 	}
 
 	mux.HandleFunc("/ingest", s.handleIngest)
+	mux.HandleFunc("/ingest/batch", s.handleIngestBatch)
 	mux.HandleFunc("/events", s.handleSSE)
+	mux.HandleFunc("/api/events", s.handleEvents)
+	mux.HandleFunc("/api/agents/", s.handleAgentAction)
+	mux.HandleFunc("/api/fleet", s.handleFleet)
 	mux.HandleFunc("/health", s.handleHealth)
 	mux.HandleFunc("/", s.handleUI)
 
-	return s
+	return s, nil
 }
 
 // Run starts the heartbeat goroutine and HTTP server.
 // Blocks until ctx is cancelled, then performs a graceful shutdown.
 func (s *Server) Run(ctx context.Context) {
 	saferun.RunWithRecovery("warden-heartbeat", ctx, s.heartbeat.Run)
+	if s.events != nil {
+		saferun.RunWithRecovery("warden-prune", ctx, s.pruneEvents)
+	}
+	if s.alerts != nil {
+		saferun.RunWithRecovery("warden-alerts", ctx, s.alerts.Run)
+	}
 
 	saferun.Go("warden-server", func() {
 		logger.Printf("warden: listening on %s", s.server.Addr)
@@ -72,9 +111,29 @@ func (s *Server) Run(ctx context.Context) {
 	if err := s.server.Shutdown(shutCtx); err != nil {
 		logger.Printf("warden: shutdown error: %v", err)
 	}
+	if s.events != nil {
+		if err := s.events.Close(); err != nil {
+			logger.Printf("warden: close event log: %v", err)
+		}
+	}
 	logger.Printf("warden: stopped")
 }
 
+// pruneEvents applies event log retention on start and every pruneInterval.
+func (s *Server) pruneEvents(ctx context.Context) {
+	s.events.Prune(time.Now())
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.events.Prune(now)
+		}
+	}
+}
+
 // handleHealth returns 200 OK — used when a warden itself is monitored upstream.
 func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	w.WriteHeader(http.StatusOK)
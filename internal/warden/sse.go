@@ -1,7 +1,6 @@
 package warden
 
 import (
-	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,7 +10,8 @@ import (
 )
 
 // handleSSE serves the GET /events SSE stream.
-// Authenticates via ?token= query parameter (EventSource cannot set headers).
+// Authenticates via ?token= query parameter or the dashboard cookie
+// (EventSource cannot set headers).
 // Replays the last 50 events on connect, then streams live events.
 func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -19,7 +19,7 @@ func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(s.cfg.API.Token)) != 1 {
+	if _, ok := s.authenticate(r); !ok {
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
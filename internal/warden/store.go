@@ -12,22 +12,28 @@ import (
 
 const ringSize = 200
 
-// AgentState tracks connectivity for one agent.
+// AgentState tracks connectivity and the last reported services for one agent.
 type AgentState struct {
 	ID       string
 	URL      string
 	LastSeen time.Time
 	Online   bool
+
+	Services    []ServiceStatus // from the agent's last successful GET /status
+	StatusAt    time.Time       // when Services was fetched
+	StatusError string          // last GET /status error; empty after a success
 }
 
 // Store holds the event ring buffer and per-agent connectivity state.
 // Pattern: ring buffer (fixed-size array with wrap-around write pointer).
+// With an EventLog attached, every appended entry is also persisted there.
 type Store struct {
 	mu     sync.RWMutex
 	events [ringSize]audit.Entry
 	head   int // next write index
 	count  int // total entries stored (max ringSize)
 	agents map[string]*AgentState
+	log    *EventLog // nil = ring buffer only
 }
 
 // NewStore creates a Store pre-populated with AgentState entries from cfg.
@@ -44,11 +50,23 @@ func NewStore(agents []AgentConfig) *Store {
 	return s
 }
 
-// Append adds an entry to the ring buffer, overwriting the oldest entry when full.
+// Append adds an entry to the ring buffer, overwriting the oldest entry when
+// full, and to the event log when one is attached. Both are written under
+// the same lock so the log stores entries in ring order.
 func (s *Store) Append(e audit.Entry) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.log != nil {
+		if _, err := s.log.Append(e); err != nil {
+			logger.Printf("warden: event log append: %v", err)
+		}
+	}
+	s.appendRing(e)
+}
+
+// appendRing adds an entry to the ring buffer only. Caller holds s.mu.
+func (s *Store) appendRing(e audit.Entry) {
 	s.events[s.head] = e
 	s.head = (s.head + 1) % ringSize
 	if s.count < ringSize {
@@ -78,6 +96,24 @@ func (s *Store) Recent(n int) []audit.Entry {
 	return out
 }
 
+// AttachLog persists appended entries to log and seeds the ring buffer with
+// its most recent events.
+func (s *Store) AttachLog(log *EventLog) {
+	recent, err := log.Recent(ringSize)
+	if err != nil {
+		logger.Printf("warden: load recent events: %v", err)
+	}
+	s.mu.Lock()
+	for _, ev := range recent {
+		s.appendRing(ev.Entry)
+	}
+	s.log = log
+	s.mu.Unlock()
+	if len(recent) > 0 {
+		logger.Printf("warden: restored %d event(s) from the event log", len(recent))
+	}
+}
+
 // SetAgentState updates connectivity status for the given agent ID.
 // LastSeen is always set to now; Online is set to the provided value.
 func (s *Store) SetAgentState(id string, online bool) {
@@ -90,6 +126,28 @@ func (s *Store) SetAgentState(id string, online bool) {
 	}
 }
 
+// SetAgentStatus records the result of a GET /status poll for the given agent.
+// On error the previous services are kept. Returns the previous error so the
+// caller can log only changes.
+func (s *Store) SetAgentStatus(id string, services []ServiceStatus, err error) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.agents[id]
+	if !ok {
+		return ""
+	}
+	prev := a.StatusError
+	if err != nil {
+		a.StatusError = err.Error()
+		return prev
+	}
+	a.Services = services
+	a.StatusAt = time.Now().UTC()
+	a.StatusError = ""
+	return prev
+}
+
 // LoadState reads a previously saved ring buffer from path and appends the
 // entries into the store. No-op when path is empty or the file does not exist.
 func (s *Store) LoadState(path string) {
@@ -108,9 +166,11 @@ func (s *Store) LoadState(path string) {
 		logger.Printf("warden: parse state %s: %v", path, err)
 		return
 	}
+	s.mu.Lock()
 	for _, e := range entries {
-		s.Append(e)
+		s.appendRing(e)
 	}
+	s.mu.Unlock()
 	logger.Printf("warden: restored %d event(s) from %s", len(entries), path)
 }
 
@@ -2,6 +2,7 @@ package warden
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -164,3 +165,32 @@ func TestStore_LoadState_EmptyPathIsNoop(t *testing.T) {
 	s := NewStore(nil)
 	s.LoadState("") // should be a no-op
 }
+
+func TestStore_AppendKeepsLogInRingOrder(t *testing.T) {
+	s := NewStore(nil)
+	s.AttachLog(openTestLog(t, t.TempDir()))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Append(makeEntry(fmt.Sprint(i)))
+		}()
+	}
+	wg.Wait()
+
+	ring := s.Recent(50)
+	logged, _, err := s.log.Query(EventFilter{Limit: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != len(ring) {
+		t.Fatalf("log has %d events, ring %d", len(logged), len(ring))
+	}
+	for i := range ring {
+		if logged[i].Message != ring[i].Message {
+			t.Fatalf("event %d: log %q, ring %q", i, logged[i].Message, ring[i].Message)
+		}
+	}
+}
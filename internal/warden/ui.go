@@ -1,7 +1,6 @@
 package warden
 
 import (
-	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -40,6 +39,23 @@ header span{color:#8b949e;font-size:12px}
 .card.online .card-status{color:#3fb950}
 .card.offline .card-status{color:#f85149}
 .card-seen{font-size:11px;color:#8b949e;margin-top:2px}
+.act{display:flex;gap:4px;margin-top:6px;align-items:center}
+.act input,.act select,.act button{background:#161b22;color:#c9d1d9;border:1px solid #30363d;border-radius:4px;padding:2px 4px;font-size:11px;font-family:monospace}
+.act input{width:90px}
+.act button{cursor:pointer}
+.act a{color:#79c0ff;font-size:11px}
+.fleet{padding:8px 16px;border-bottom:1px solid #21262d;overflow-x:auto}
+.fleet table{width:auto}
+.fleet th,.fleet td{padding:3px 12px;vertical-align:top}
+.fleet th.off{color:#f85149}
+.fleet td{font-size:11px;line-height:1.5}
+.fleet .na{color:#484f58}
+.fleet .dig{color:#8b949e}
+.fleet tr.drift .dig{color:#e3b341;font-weight:600}
+.fleet tr.drift td.name::after{content:" drift";color:#e3b341;font-size:10px}
+.st-ok{color:#3fb950}
+.st-deploying,.st-pending,.st-unknown{color:#79c0ff}
+.st-unhealthy,.st-degraded,.st-exhausted,.st-errored,.st-blocked,.st-not_found{color:#f85149}
 .controls{padding:8px 16px;border-bottom:1px solid #21262d;display:flex;gap:8px;align-items:center}
 .controls label{color:#8b949e;font-size:11px}
 .controls select{background:#161b22;color:#c9d1d9;border:1px solid #30363d;border-radius:4px;padding:3px 6px;font-size:11px;font-family:monospace}
@@ -58,12 +74,13 @@ td{padding:5px 16px;font-size:12px;white-space:nowrap}
 .level-critical{color:#f85149;font-weight:600}
 .msg{color:#c9d1d9;white-space:normal;max-width:500px}
 .tbl-wrap{overflow-y:auto;max-height:calc(100vh - 220px)}
+#older{display:block;margin:8px auto;background:#161b22;color:#c9d1d9;border:1px solid #30363d;border-radius:4px;padding:4px 12px;font-size:11px;font-family:monospace;cursor:pointer}
 </style>
 </head>
 <body>
 <header>
   <h1>Warden</h1>
-  <span>{{.Hostname}} &mdash; up {{.Uptime}}</span>
+  <span>{{.Hostname}} &mdash; up {{.Uptime}} &mdash; {{.User}}</span>
 </header>
 <div class="agents">
 {{range .Agents}}
@@ -71,9 +88,22 @@ td{padding:5px 16px;font-size:12px;white-space:nowrap}
     <div class="card-id">{{.ID}}</div>
     <div class="card-status">{{if .Online}}online{{else}}offline{{end}}</div>
     <div class="card-seen">last seen: {{formatTime .LastSeen}}</div>
+    {{if $.Operator}}
+    <form class="act" data-agent="{{.ID}}" onsubmit="return act(this)">
+      <input name="service" placeholder="service (all)">
+      <select name="action">
+        <option value="trigger">trigger</option>
+        <option value="redeploy">redeploy</option>
+        <option value="unblock">unblock</option>
+      </select>
+      <button type="submit">run</button>
+      <a href="/api/agents/{{.ID}}/config" target="_blank" rel="noopener">config</a>
+    </form>
+    {{end}}
   </div>
 {{end}}
 </div>
+<div class="fleet"><table id="fleet"></table></div>
 <div class="controls">
   <label>machine</label>
   <select id="f-machine" onchange="applyFilter()">
@@ -108,14 +138,17 @@ td{padding:5px 16px;font-size:12px;white-space:nowrap}
 {{end}}
 </tbody>
 </table>
+<button id="older" style="display:none" onclick="loadPage(false)">load older</button>
 </div>
 <script>
 const token = {{.Token}};
-let fMachine = '', fLevel = '';
+const paged = {{.History}}; // persistent event store: filter and page via /api/events
+let fMachine = '', fLevel = '', cursor = '';
 
 function applyFilter() {
   fMachine = document.getElementById('f-machine').value;
   fLevel   = document.getElementById('f-level').value;
+  if (paged) { loadPage(true); return; }
   document.querySelectorAll('#feed tr').forEach(row => {
     const m = row.dataset.machine || '';
     const l = row.dataset.level   || '';
@@ -126,19 +159,27 @@ function applyFilter() {
   });
 }
 
-function addRow(e) {
+function esc(s) {
+  return String(s || '').replace(/[&<>"']/g, c => '&#' + c.charCodeAt(0) + ';');
+}
+
+function makeRow(e) {
   const ts  = e.timestamp ? new Date(e.timestamp).toISOString().replace('T',' ').slice(0,19)+' UTC' : '';
   const row = document.createElement('tr');
   row.dataset.machine = e.machine || '';
   row.dataset.level   = e.level   || '';
   row.innerHTML =
-    '<td class="ts">'                  + ts                      + '</td>' +
-    '<td class="machine">'             + (e.machine  || '') + '</td>' +
-    '<td class="svc">'                 + (e.service  || '') + '</td>' +
-    '<td>'                             + (e.event    || '') + '</td>' +
-    '<td class="level-'+(e.level||'')+'">' + (e.level || '') + '</td>' +
-    '<td class="msg">'                 + (e.message  || '') + '</td>';
+    '<td class="ts">'                  + ts                 + '</td>' +
+    '<td class="machine">'             + esc(e.machine)     + '</td>' +
+    '<td class="svc">'                 + esc(e.service)     + '</td>' +
+    '<td>'                             + esc(e.event)       + '</td>' +
+    '<td class="level-'+esc(e.level)+'">' + esc(e.level)    + '</td>' +
+    '<td class="msg">'                 + esc(e.message)     + '</td>';
+  return row;
+}
 
+function addRow(e) {
+  const row = makeRow(e);
   const show =
     (fMachine === '' || row.dataset.machine === fMachine) &&
     (fLevel   === '' || row.dataset.level   === fLevel);
@@ -146,9 +187,73 @@ function addRow(e) {
 
   const feed = document.getElementById('feed');
   feed.insertBefore(row, feed.firstChild);
-  // Keep at most 500 rows in the DOM.
-  while (feed.children.length > 500) feed.removeChild(feed.lastChild);
+  // Keep at most 500 rows in the DOM; older history is paged in on demand.
+  if (!paged) while (feed.children.length > 500) feed.removeChild(feed.lastChild);
+}
+
+// loadPage fetches a page of stored events for the current filter: the newest
+// page replacing the feed (reset), or the next older page appended to it.
+function loadPage(reset) {
+  const p = new URLSearchParams({limit: '200'});
+  if (fMachine) p.set('machine', fMachine);
+  if (fLevel)   p.set('level', fLevel);
+  if (!reset && cursor) p.set('cursor', cursor);
+  fetch('/api/events?' + p, {credentials: 'same-origin'})
+    .then(r => r.ok ? r.json() : Promise.reject(r.status))
+    .then(page => {
+      const feed = document.getElementById('feed');
+      if (reset) feed.innerHTML = '';
+      page.events.forEach(e => feed.appendChild(makeRow(e)));
+      cursor = page.next_cursor || '';
+      document.getElementById('older').style.display = cursor ? '' : 'none';
+    })
+    .catch(() => { document.getElementById('status').textContent = 'history unavailable'; });
+}
+if (paged) loadPage(true);
+
+// act runs an action on an agent through the warden; the outcome is audited
+// centrally and shows up in the feed.
+function act(form) {
+  const svc = form.service.value.trim();
+  const action = form.action.value;
+  if (action !== 'trigger' && !svc) { document.getElementById('status').textContent = action + ' needs a service'; return false; }
+  let url = '/api/agents/' + encodeURIComponent(form.dataset.agent) + '/' + action;
+  if (svc) url += '/' + encodeURIComponent(svc);
+  fetch(url, {method: 'POST', credentials: 'same-origin'})
+    .then(r => r.text().then(t => { document.getElementById('status').textContent = form.dataset.agent + ': ' + action + ' ' + (r.ok ? 'ok' : r.status + ' ' + t.trim()); }))
+    .catch(() => { document.getElementById('status').textContent = form.dataset.agent + ': ' + action + ' failed'; });
+  return false;
+}
+
+// loadFleet renders the machine × service matrix from /api/fleet. Rows whose
+// machines report different digests are flagged as drift.
+function loadFleet() {
+  fetch('/api/fleet', {credentials: 'same-origin'})
+    .then(r => r.ok ? r.json() : Promise.reject(r.status))
+    .then(f => {
+      const tbl = document.getElementById('fleet');
+      if (!f.services.length) { tbl.innerHTML = '<tr><td class="na">no service status reported yet</td></tr>'; return; }
+      let html = '<thead><tr><th>Service</th>' + f.machines.map(m =>
+        '<th class="' + (m.online ? '' : 'off') + '" title="' + esc(m.error || (m.status_at ? 'status at ' + m.status_at : '')) + '">' + esc(m.id) + '</th>').join('') + '</tr></thead><tbody>';
+      f.services.forEach(s => {
+        html += '<tr class="' + (s.drift ? 'drift' : '') + '"><td class="name svc">' + esc(s.name) + '</td>';
+        f.machines.forEach(m => {
+          const c = s.machines[m.id];
+          if (!c) { html += '<td class="na">&ndash;</td>'; return; }
+          const why = c.blocked ? 'blocked ' + c.blocked : (c.errored || c.not_found || '');
+          html += '<td title="' + esc(why) + '"><span class="st-' + esc(c.status) + '">' + esc(c.status) + '</span>';
+          (c.images || []).forEach(i => { html += '<br><span class="dig" title="' + esc(i.image + ' ' + i.digest) + '">' + esc(i.short) + '</span>'; });
+          if (c.has_stats) html += '<br>cpu ' + (c.cpu_percent || 0).toFixed(1) + '% mem ' + (c.memory_percent || 0).toFixed(1) + '%';
+          html += '</td>';
+        });
+        html += '</tr>';
+      });
+      tbl.innerHTML = html + '</tbody>';
+    })
+    .catch(() => {});
 }
+loadFleet();
+setInterval(loadFleet, 30000);
 
 const es = new EventSource('/events?token=' + encodeURIComponent(token));
 es.onopen    = () => { document.getElementById('status').textContent = 'live'; };
@@ -165,25 +270,30 @@ type dashboardData struct {
 	Token    template.JS // JS-safe encoded token string (JSON-quoted)
 	Agents   []AgentState
 	Recent   interface{}
+	History  bool   // persistent event store enabled; the UI pages through /api/events
+	User     string // acting user, shown in the header
+	Operator bool   // user may run agent actions
 }
 
 var startTime = time.Now()
 
 // handleUI serves the warden multi-machine dashboard.
-// Requires the warden API token as a cookie named "token" or as a query param
-// "token" (the query param is accepted to make direct browser navigation easy
-// during setup; cookies are preferred in production behind nginx).
+// Requires a warden token (api.token or api.tokens) as a cookie named "token"
+// or as a query param "token" (the query param is accepted to make direct
+// browser navigation easy during setup; cookies are preferred in production
+// behind nginx). Agent actions are only offered to operators.
 func (s *Server) handleUI(w http.ResponseWriter, r *http.Request) {
-	if !s.uiAuth(r) {
-		http.SetCookie(w, &http.Cookie{Name: "token", Value: "", MaxAge: -1, Path: "/"})
+	p, ok := s.authenticate(r)
+	if !ok {
+		http.SetCookie(w, &http.Cookie{Name: authCookie, Value: "", MaxAge: -1, Path: "/"})
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
 
 	// Set auth cookie so subsequent requests (including SSE EventSource) work.
 	http.SetCookie(w, &http.Cookie{
-		Name:     "token",
-		Value:    s.cfg.API.Token,
+		Name:     authCookie,
+		Value:    p.Token,
 		Path:     "/",
 		HttpOnly: true,
 		SameSite: http.SameSiteStrictMode,
@@ -194,13 +304,16 @@ func (s *Server) handleUI(w http.ResponseWriter, r *http.Request) {
 	agents := s.store.AgentStates()
 	sort.Slice(agents, func(i, j int) bool { return agents[i].ID < agents[j].ID })
 
-	tokenJSON, _ := json.Marshal(s.cfg.API.Token)
+	tokenJSON, _ := json.Marshal(p.Token)
 	data := dashboardData{
 		Hostname: hostname,
 		Uptime:   formatUptime(time.Since(startTime)),
 		Token:    template.JS(tokenJSON), // #nosec -- JSON-encoded string is safe for JS context
 		Agents:   agents,
 		Recent:   s.store.Recent(200),
+		History:  s.events != nil,
+		User:     p.Name,
+		Operator: p.allows(RoleOperator),
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -209,21 +322,6 @@ func (s *Server) handleUI(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// uiAuth validates the warden token from query param or cookie.
-// Uses constant-time comparison to prevent timing attacks.
-func (s *Server) uiAuth(r *http.Request) bool {
-	want := []byte(s.cfg.API.Token)
-	if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), want) == 1 {
-		return true
-	}
-	if c, err := r.Cookie("token"); err == nil {
-		if subtle.ConstantTimeCompare([]byte(c.Value), want) == 1 {
-			return true
-		}
-	}
-	return false
-}
-
 func formatUptime(d time.Duration) string {
 	d = d.Round(time.Second)
 	h := int(d.Hours())
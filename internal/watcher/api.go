@@ -17,7 +17,9 @@ import (
 	"github.com/studiowebux/dockward/internal/docker"
 	"github.com/studiowebux/dockward/internal/hub"
 	"github.com/studiowebux/dockward/internal/logger"
+	"github.com/studiowebux/dockward/internal/push"
 	"github.com/studiowebux/dockward/internal/saferun"
+	"github.com/studiowebux/dockward/internal/state"
 )
 
 // API exposes HTTP endpoints for triggering updates, health, and metrics.
@@ -29,16 +31,28 @@ type API struct {
 	audit          *audit.Logger
 	hub            *hub.Hub
 	dockerHealth   *docker.HealthChecker
+	pushQueue      *push.Queue // nil when push is disabled
 	configWarnings []string // Invalid services from config validation
 	configPath     string   // Path to config file for write-back on mutations
+	tokens         []config.APIToken // api.tokens; empty = authentication disabled
 	servers        []*http.Server // one per listen address, all share the same mux
 
+	// authMu guards authFails — the open audit window of each remote address
+	// and path with rejected requests (see auditAuthFailure).
+	authMu    sync.Mutex
+	authFails map[string]authFailures
+
 	// statusMu guards statusSubs — the set of channels notified when service
 	// state changes (audit entry written → status should be re-pushed to SSE).
 	statusMu   sync.Mutex
 	statusSubs map[chan struct{}]struct{}
 }
 
+// SetPushQueue reports the warden push outbox in /health and /metrics.
+func (a *API) SetPushQueue(q *push.Queue) {
+	a.pushQueue = q
+}
+
 // subscribeStatus returns a channel that receives a signal whenever service
 // state changes and the UI should refresh.  Buffer of 1 so the broadcaster
 // never blocks.
@@ -159,6 +173,10 @@ func NewAPI(updater *Updater, healer *Healer, metrics *Metrics, monitor *Monitor
 		}
 	}
 
+	updater.cfg.RLock()
+	tokens := append([]config.APIToken(nil), updater.cfg.API.Tokens...)
+	updater.cfg.RUnlock()
+
 	api := &API{
 		updater:        updater,
 		healer:         healer,
@@ -168,6 +186,7 @@ func NewAPI(updater *Updater, healer *Healer, metrics *Metrics, monitor *Monitor
 		dockerHealth:   dockerHealth,
 		configWarnings: configWarnings,
 		configPath:     configPath,
+		tokens:         tokens,
 		hub:            h,
 		statusSubs:     make(map[chan struct{}]struct{}),
 		servers:        servers,
@@ -177,38 +196,52 @@ func NewAPI(updater *Updater, healer *Healer, metrics *Metrics, monitor *Monitor
 	// immediate status push to all connected SSE clients.
 	bc.onNotify = api.notifyStatus
 
+	// Role shorthands: viewers may read state, operators may also mutate it.
+	// /health stays unauthenticated so liveness probes and the warden heartbeat work.
+	view := func(h http.HandlerFunc) http.HandlerFunc { return api.requireRole(config.RoleViewer, h) }
+	operate := func(h http.HandlerFunc) http.HandlerFunc { return api.requireRole(config.RoleOperator, h) }
+
 	// POST endpoints with request body limits and timeouts
-	mux.HandleFunc("/trigger", limitRequestBody(withTimeout(api.handleTriggerAll, defaultTimeout), maxRequestBodySize))
-	mux.HandleFunc("/trigger/", limitRequestBody(withTimeout(api.handleTriggerService, defaultTimeout), maxRequestBodySize))
-	mux.HandleFunc("/unblock/", limitRequestBody(withTimeout(api.handleUnblockPost, defaultTimeout), maxRequestBodySize))
-	mux.HandleFunc("/redeploy/", limitRequestBody(withTimeout(api.handleForceRedeploy, defaultTimeout), maxRequestBodySize))
+	mux.HandleFunc("/trigger", operate(limitRequestBody(withTimeout(api.handleTriggerAll, defaultTimeout), maxRequestBodySize)))
+	mux.HandleFunc("/trigger/", operate(limitRequestBody(withTimeout(api.handleTriggerService, defaultTimeout), maxRequestBodySize)))
+	mux.HandleFunc("/unblock/", operate(limitRequestBody(withTimeout(api.handleUnblockPost, defaultTimeout), maxRequestBodySize)))
+	mux.HandleFunc("/redeploy/", operate(limitRequestBody(withTimeout(api.handleForceRedeploy, defaultTimeout), maxRequestBodySize)))
+	mux.HandleFunc("/rollback/", operate(limitRequestBody(withTimeout(api.handleRollbackTo, defaultTimeout), maxRequestBodySize)))
+	mux.HandleFunc("/freeze", operate(limitRequestBody(withTimeout(api.handleFreeze, defaultTimeout), maxRequestBodySize)))
+	mux.HandleFunc("/unpin/", operate(limitRequestBody(withTimeout(api.handleUnpin, defaultTimeout), maxRequestBodySize)))
+	mux.HandleFunc("/approve/", operate(limitRequestBody(withTimeout(api.handleApprove, defaultTimeout), maxRequestBodySize)))
+	mux.HandleFunc("/reject/", operate(limitRequestBody(withTimeout(api.handleReject, defaultTimeout), maxRequestBodySize)))
 
 	// GET endpoints with timeouts (no body limits needed)
-	mux.HandleFunc("/blocked", withTimeout(api.handleListBlocked, defaultTimeout))
-	mux.HandleFunc("/blocked/", withTimeout(api.handleUnblockService, defaultTimeout))
-	mux.HandleFunc("/not-found", withTimeout(api.handleListNotFound, defaultTimeout))
-	mux.HandleFunc("/errored", withTimeout(api.handleListErrored, defaultTimeout))
-	mux.HandleFunc("/status", withTimeout(api.handleStatusAll, defaultTimeout))
-	mux.HandleFunc("/status/", withTimeout(api.handleStatusService, defaultTimeout))
+	mux.HandleFunc("/blocked", view(withTimeout(api.handleListBlocked, defaultTimeout)))
+	mux.HandleFunc("/blocked/", operate(withTimeout(api.handleUnblockService, defaultTimeout)))
+	mux.HandleFunc("/not-found", view(withTimeout(api.handleListNotFound, defaultTimeout)))
+	mux.HandleFunc("/errored", view(withTimeout(api.handleListErrored, defaultTimeout)))
+	mux.HandleFunc("/state", view(withTimeout(api.handleState, defaultTimeout)))
+	mux.HandleFunc("/history/", view(withTimeout(api.handleHistory, defaultTimeout)))
+	mux.HandleFunc("/pending", view(withTimeout(api.handlePending, defaultTimeout)))
+	mux.HandleFunc("/status", view(withTimeout(api.handleStatusAll, defaultTimeout)))
+	mux.HandleFunc("/status/", view(withTimeout(api.handleStatusService, defaultTimeout)))
 	mux.HandleFunc("/health", withTimeout(api.handleHealth, defaultTimeout))
-	mux.HandleFunc("/metrics", withTimeout(api.handleMetrics, defaultTimeout))
-	mux.HandleFunc("/audit", withTimeout(api.handleAudit, defaultTimeout))
-	mux.HandleFunc("/ui", withTimeout(api.handleUI, defaultTimeout))
-	mux.HandleFunc("/command-preview/", withTimeout(api.handleCommandPreview, defaultTimeout))
+	mux.HandleFunc("/metrics", view(withTimeout(api.handleMetrics, defaultTimeout)))
+	mux.HandleFunc("/audit", view(withTimeout(api.handleAudit, defaultTimeout)))
+	mux.HandleFunc("/ui", view(withTimeout(api.handleUI, defaultTimeout)))
+	mux.HandleFunc("/command-preview/", view(withTimeout(api.handleCommandPreview, defaultTimeout)))
 
 	// SSE endpoints - no timeout (long-lived connections)
-	mux.HandleFunc("/ui/events", withTimeout(api.handleUIEvents, sseTimeout))
-	mux.HandleFunc("/ui/stream", withTimeout(api.handleUIStream, sseTimeout))
+	mux.HandleFunc("/ui/events", view(withTimeout(api.handleUIEvents, sseTimeout)))
+	mux.HandleFunc("/ui/stream", view(withTimeout(api.handleUIStream, sseTimeout)))
 
 	// Config API — read and mutate the running config, persisted to disk.
 	// /config/download and /config/services/ must be registered before /config
-	// so the more-specific patterns take precedence.
-	mux.HandleFunc("/config/download", withTimeout(api.handleConfigDownload, defaultTimeout))
-	mux.HandleFunc("/config/services/", limitRequestBody(withTimeout(api.handleConfigService, defaultTimeout), maxRequestBodySize))
-	mux.HandleFunc("/config/registry", limitRequestBody(withTimeout(api.handlePutRegistry, defaultTimeout), maxRequestBodySize))
-	mux.HandleFunc("/config/monitor", limitRequestBody(withTimeout(api.handlePutMonitor, defaultTimeout), maxRequestBodySize))
-	mux.HandleFunc("/config/notifications", limitRequestBody(withTimeout(api.handlePutNotifications, defaultTimeout), maxRequestBodySize))
-	mux.HandleFunc("/config", withTimeout(api.handleGetConfig, defaultTimeout))
+	// so the more-specific patterns take precedence. The config contains
+	// credentials, so even reads require the operator role.
+	mux.HandleFunc("/config/download", operate(withTimeout(api.handleConfigDownload, defaultTimeout)))
+	mux.HandleFunc("/config/services/", operate(limitRequestBody(withTimeout(api.handleConfigService, defaultTimeout), maxRequestBodySize)))
+	mux.HandleFunc("/config/registry", operate(limitRequestBody(withTimeout(api.handlePutRegistry, defaultTimeout), maxRequestBodySize)))
+	mux.HandleFunc("/config/monitor", operate(limitRequestBody(withTimeout(api.handlePutMonitor, defaultTimeout), maxRequestBodySize)))
+	mux.HandleFunc("/config/notifications", operate(limitRequestBody(withTimeout(api.handlePutNotifications, defaultTimeout), maxRequestBodySize)))
+	mux.HandleFunc("/config", operate(withTimeout(api.handleGetConfig, defaultTimeout)))
 
 	return api
 }
@@ -377,11 +410,22 @@ func (a *API) handleListErrored(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, a.updater.ErroredServices())
 }
 
+// GET /state - persisted agent state: blocked digests, suppression maps,
+// healer counters, and per-service deploy history
+func (a *API) handleState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, a.updater.State())
+}
+
 // statusResponse is the top-level wrapper returned by GET /status.
 type statusResponse struct {
 	UptimeSeconds int64           `json:"uptime_seconds"`
 	LastPoll      *time.Time      `json:"last_poll,omitempty"`
 	PollCount     int64           `json:"poll_count"`
+	Freeze        *state.Freeze   `json:"freeze,omitempty"` // active deploy freeze
 	Services      []serviceStatus `json:"services"`
 }
 
@@ -421,13 +465,15 @@ type ContainerInfo struct {
 // for programmatic consumers that need granular state.
 type serviceStatus struct {
 	Name       string `json:"name"`
-	Status     string `json:"status"` // ok | deploying | degraded | exhausted | blocked | not_found | errored | unhealthy | unknown
+	Status     string `json:"status"` // ok | deploying | pending | degraded | exhausted | blocked | not_found | errored | unhealthy | unknown
 	AutoUpdate bool   `json:"auto_update"`
 	AutoStart  bool   `json:"auto_start"`
 	AutoHeal   bool   `json:"auto_heal"`
 	Healthy    *bool  `json:"healthy,omitempty"`
 	Deploying  bool   `json:"deploying"`
 	Blocked    string `json:"blocked,omitempty"`
+	Pending    *PendingDeploy `json:"pending,omitempty"` // update held by deploy windows, a freeze or require_approval
+	Pinned     string `json:"pinned,omitempty"` // digest held by a manual rollback
 	NotFound   string `json:"not_found,omitempty"`
 	Errored    string `json:"errored,omitempty"`
 	Degraded   bool   `json:"degraded"`
@@ -448,6 +494,8 @@ type serviceStatus struct {
 	MemoryPercent float64 `json:"memory_percent,omitempty"`
 	MemoryUsageMB float64 `json:"memory_usage_mb,omitempty"`
 	MemoryLimitMB float64 `json:"memory_limit_mb,omitempty"`
+	// Raised CPU and memory alerts, per container.
+	ResourceAlerts []ResourceAlertStatus `json:"resource_alerts,omitempty"`
 	// Check timing information
 	LastCheck   *time.Time `json:"last_check,omitempty"`
 	NextCheck   *time.Time `json:"next_check,omitempty"`
@@ -473,6 +521,7 @@ func (a *API) handleStatusAll(w http.ResponseWriter, r *http.Request) {
 	resp := statusResponse{
 		UptimeSeconds: meta.UptimeSeconds,
 		PollCount:     meta.PollCount,
+		Freeze:        a.updater.ActiveFreeze(),
 		Services:      services,
 	}
 	if !meta.LastPoll.IsZero() {
@@ -511,6 +560,8 @@ func (a *API) handleStatusService(w http.ResponseWriter, r *http.Request) {
 // stateSnap holds a point-in-time snapshot of all state maps.
 type stateSnap struct {
 	blocked        map[string]string
+	pinned         map[string]string
+	pending        map[string]PendingDeploy
 	notFound       map[string]string
 	errored        map[string]string
 	degraded       map[string]bool
@@ -520,6 +571,7 @@ type stateSnap struct {
 	counters       map[string]ServiceCounters
 	stats          map[string]ServiceStats
 	containerStats map[string]ContainerStats
+	resourceAlerts map[string][]ResourceAlertStatus
 	deployed       map[string]DeployedInfo
 	containers     map[string][]ContainerInfo
 }
@@ -527,6 +579,8 @@ type stateSnap struct {
 func (a *API) stateSnapshot(ctx context.Context) stateSnap {
 	snap := stateSnap{
 		blocked:       a.updater.BlockedDigests(),
+		pinned:        a.updater.PinnedDigests(),
+		pending:       a.updater.PendingDeploys(),
 		notFound:      a.updater.NotFoundServices(),
 		errored:       a.updater.ErroredServices(),
 		degraded:      a.healer.DegradedServices(),
@@ -538,6 +592,7 @@ func (a *API) stateSnapshot(ctx context.Context) stateSnap {
 	if a.monitor != nil {
 		snap.stats = a.monitor.StatsSnapshot()
 		snap.containerStats = a.monitor.ContainerStatsSnapshot()
+		snap.resourceAlerts = a.monitor.ResourceAlerts()
 	}
 	snap.deployed = a.updater.DeployedInfos()
 
@@ -585,12 +640,16 @@ func (a *API) buildServiceStatus(svc config.Service, snap stateSnap) serviceStat
 		AutoHeal:   svc.AutoHeal,
 		Deploying:  a.updater.IsDeploying(svc.Name),
 		Blocked:    firstValueByPrefix(snap.blocked, prefix),
+		Pinned:     firstValueByPrefix(snap.pinned, prefix),
 		NotFound:   firstValueByPrefix(snap.notFound, prefix),
 		Errored:    snap.errored[svc.Name],
 		Degraded:   snap.degraded[svc.Name],
 		Exhausted:  snap.exhausted[svc.Name],
 		Restarts:   snap.restartCounts[svc.Name],
 	}
+	if p, ok := snap.pending[svc.Name]; ok {
+		s.Pending = &p
+	}
 	if h, ok := snap.healthGauges[svc.Name]; ok {
 		s.Healthy = &h
 	}
@@ -607,6 +666,7 @@ func (a *API) buildServiceStatus(svc config.Service, snap stateSnap) serviceStat
 		s.MemoryUsageMB = st.MemoryUsageMB
 		s.MemoryLimitMB = st.MemoryLimitMB
 	}
+	s.ResourceAlerts = snap.resourceAlerts[svc.Name]
 	for k, d := range snap.deployed {
 		if strings.HasPrefix(k, prefix) {
 			sizeMB := d.Size / (1024 * 1024)
@@ -633,7 +693,7 @@ func (a *API) buildServiceStatus(svc config.Service, snap stateSnap) serviceStat
 }
 
 // synthesizeStatus derives a single human-readable status word from service state.
-// Priority order: exhausted > degraded > errored > blocked > not_found > deploying > ok/unhealthy/unknown.
+// Priority order: exhausted > degraded > errored > blocked > not_found > deploying > pending > ok/unhealthy/unknown.
 func synthesizeStatus(s serviceStatus) string {
 	switch {
 	case s.Exhausted:
@@ -648,6 +708,8 @@ func synthesizeStatus(s serviceStatus) string {
 		return "not_found"
 	case s.Deploying:
 		return "deploying"
+	case s.Pending != nil:
+		return "pending"
 	case s.Healthy != nil && *s.Healthy:
 		return "ok"
 	case s.Healthy != nil && !*s.Healthy:
@@ -731,6 +793,9 @@ func (a *API) handleHealth(w http.ResponseWriter, _ *http.Request) {
 	if dockerStatus.LastError != "" {
 		response["components"].(map[string]interface{})["docker"].(map[string]interface{})["last_error"] = dockerStatus.LastError
 	}
+	if a.pushQueue != nil {
+		response["components"].(map[string]interface{})["push"] = a.pushQueue.Status()
+	}
 
 	w.WriteHeader(statusCode)
 	writeJSON(w, response)
@@ -743,6 +808,18 @@ func (a *API) handleMetrics(w http.ResponseWriter, _ *http.Request) {
 	out += "# HELP watcher_active_deploys Number of services currently deploying\n"
 	out += "# TYPE watcher_active_deploys gauge\n"
 	out += fmt.Sprintf("watcher_active_deploys %d\n", a.updater.DeployingCount())
+	if a.pushQueue != nil {
+		ps := a.pushQueue.Status()
+		out += "# HELP watcher_push_queue_depth Audit entries waiting for delivery to the warden\n"
+		out += "# TYPE watcher_push_queue_depth gauge\n"
+		out += fmt.Sprintf("watcher_push_queue_depth %d\n", ps.Depth)
+		out += "# HELP watcher_push_delivered_total Audit entries delivered to the warden\n"
+		out += "# TYPE watcher_push_delivered_total counter\n"
+		out += fmt.Sprintf("watcher_push_delivered_total %d\n", ps.Delivered)
+		out += "# HELP watcher_push_dropped_total Audit entries dropped from a full queue or rejected by the warden\n"
+		out += "# TYPE watcher_push_dropped_total counter\n"
+		out += fmt.Sprintf("watcher_push_dropped_total %d\n", ps.Dropped)
+	}
 	if _, err := w.Write([]byte(out)); err != nil {
 		logger.Printf("[api] metrics write error: %v", err)
 	}
@@ -984,7 +1061,7 @@ const dataStarHTML = `<!DOCTYPE html>
     .badge.unknown { background:var(--surface2); color:var(--text-dim); }
     .badge.unhealthy, .badge.degraded, .badge.exhausted, .badge.errored { background:var(--error); color:var(--error-text); }
     .badge.deploying { background:var(--info); color:var(--info-text); }
-    .badge.blocked, .badge.not_found { background:var(--warning); color:var(--warning-text); }
+    .badge.blocked, .badge.not_found, .badge.pending { background:var(--warning); color:var(--warning-text); }
     .badge.info { background:var(--info); color:var(--info-text); }
     .badge.warning { background:var(--warning); color:var(--warning-text); }
     .badge.error, .badge.critical { background:var(--error); color:var(--error-text); }
@@ -1026,6 +1103,8 @@ const dataStarHTML = `<!DOCTYPE html>
 
     /* Event detail */
     .ev-detail { font-size:0.8rem; color:var(--text-faint); margin-top:2px; }
+    .ev-logs summary { font-size:0.8rem; color:var(--text-dim); cursor:pointer; margin-top:3px; }
+    .ev-logs .ev-output { max-height:20rem; }
     .ev-output { font-size:0.8rem; color:var(--text-faint); margin-top:3px; white-space:pre-wrap; word-break:break-all; background:var(--surface); border-radius:3px; padding:4px 6px; max-height:6rem; overflow-y:auto; }
 
     /* Stats */
@@ -1088,6 +1167,7 @@ const dataStarHTML = `<!DOCTYPE html>
       <span class="dot wait" id="conn-dot"></span>
       <span id="conn-label">Connecting...</span>
       <span id="updated"></span>
+      <span class="badge warning" id="freeze" style="display:none"></span>
     </div>
   </header>
 
@@ -1243,6 +1323,7 @@ const dataStarHTML = `<!DOCTYPE html>
         <div class="form-group form-check"><input id="svc-auto-heal" type="checkbox"><label class="form-label" for="svc-auto-heal">Auto Heal</label></div>
         <div class="form-group form-check"><input id="svc-auto-start" type="checkbox"><label class="form-label" for="svc-auto-start">Auto Start</label></div>
         <div class="form-group form-check"><input id="svc-compose-watch" type="checkbox"><label class="form-label" for="svc-compose-watch">Compose Watch</label></div>
+        <div class="form-group form-check"><input id="svc-require-approval" type="checkbox"><label class="form-label" for="svc-require-approval">Require Approval</label></div>
         <div class="form-group form-check"><input id="svc-silent" type="checkbox"><label class="form-label" for="svc-silent">Silent</label></div>
       </div>
       <div class="field-group-label">Timing</div>
@@ -1413,6 +1494,12 @@ const dataStarHTML = `<!DOCTYPE html>
       // Status
       html += '<td><span class="badge ' + esc(s.status) + '">' + esc(s.status) + '</span>';
       if (s.errored) html += ' <span class="tip-block" data-tip="' + esc(s.errored) + '">&#9888;</span>';
+      if (s.pinned) html += ' <span class="badge" data-tip="Pinned to ' + esc(shortSha(s.pinned)) + '">pinned</span>';
+      if (s.pending) {
+        var pendTip = s.pending.reason === 'approval' ? 'Awaiting approval' : (s.pending.reason === 'freeze' ? 'Held by deploy freeze' : 'Held outside deploy window');
+        if (s.pending.until) pendTip += ' until ' + new Date(s.pending.until).toLocaleString();
+        html += ' <span class="tip-block" data-tip="' + esc(pendTip) + '">&#9203;</span>';
+      }
       html += '</td>';
 
       // Config flags
@@ -1444,6 +1531,16 @@ const dataStarHTML = `<!DOCTYPE html>
       if (s.blocked) {
         html += '<button class="btn" onclick="unblockSvc(\'' + esc(s.name) + '\')">Unblock</button>';
       }
+      if (s.images && s.images.length) {
+        html += '<button class="btn" onclick="rollbackSvc(\'' + esc(s.name) + '\')">History</button>';
+      }
+      if (s.pinned) {
+        html += '<button class="btn" onclick="unpinSvc(\'' + esc(s.name) + '\')">Unpin</button>';
+      }
+      if (s.pending && s.pending.reason === 'approval') {
+        html += '<button class="btn" onclick="approveSvc(\'' + esc(s.name) + '\')">Approve</button>';
+        html += '<button class="btn" onclick="rejectSvc(\'' + esc(s.name) + '\')">Reject</button>';
+      }
       html += '</div></td>';
 
       html += '</tr>';
@@ -1490,6 +1587,9 @@ const dataStarHTML = `<!DOCTYPE html>
       if (e.output) {
         html += '<div class="ev-output">' + esc(e.output) + '</div>';
       }
+      if (e.logs) {
+        html += '<details class="ev-logs"><summary>Container logs</summary><div class="ev-output">' + esc(e.logs) + '</div></details>';
+      }
       html += '</td>';
       html += '</tr>';
     }
@@ -1516,6 +1616,14 @@ const dataStarHTML = `<!DOCTYPE html>
       try {
         var data = JSON.parse(e.data);
         renderServices(data.services || []);
+        var fz = document.getElementById('freeze');
+        if (data.freeze) {
+          fz.textContent = 'Frozen until ' + new Date(data.freeze.until).toLocaleString();
+          fz.setAttribute('data-tip', data.freeze.reason || 'Deploy freeze');
+          fz.style.display = '';
+        } else {
+          fz.style.display = 'none';
+        }
         setConn('connected');
         setUpdated();
       } catch(err) {
@@ -1563,6 +1671,46 @@ const dataStarHTML = `<!DOCTYPE html>
     fetch('/unblock/' + encodeURIComponent(name), { method: 'POST' });
   };
 
+  // History: list retained digests, redeploy the chosen one (pinned until unpinned).
+  window.rollbackSvc = function(name) {
+    fetch('/history/' + encodeURIComponent(name))
+      .then(function(r) { return r.json(); })
+      .then(function(h) {
+        var choices = [];
+        var lines = [];
+        (h.images || []).forEach(function(im) {
+          (im.releases || []).forEach(function(rel) {
+            var mark = rel.digest === im.current ? ' (current)' : (rel.digest === im.pinned ? ' (pinned)' : '');
+            choices.push(rel.digest);
+            lines.push(choices.length + ') ' + im.image + ' ' + shortSha(rel.digest) + ' ' + new Date(rel.deployed_at).toLocaleString() + mark);
+          });
+        });
+        if (!choices.length) { alert('No retained digests for ' + name + ' yet.'); return; }
+        var pick = prompt('Roll ' + name + ' back to:\n' + lines.join('\n') + '\n\nEnter number:');
+        var idx = parseInt(pick, 10) - 1;
+        if (isNaN(idx) || idx < 0 || idx >= choices.length) return;
+        if (!confirm('Redeploy ' + name + ' at ' + shortSha(choices[idx]) + '? Updates stay held until unpinned.')) return;
+        fetch('/rollback/' + encodeURIComponent(name) + '?digest=' + encodeURIComponent(choices[idx]), { method: 'POST' })
+          .then(function(r) { if (!r.ok) r.text().then(function(t) { alert('Rollback failed: ' + t); }); });
+      });
+  };
+
+  window.unpinSvc = function(name) {
+    fetch('/unpin/' + encodeURIComponent(name), { method: 'POST' });
+  };
+
+  // Approval gate: deploy or block the update held for approval.
+  window.approveSvc = function(name) {
+    if (!confirm('Deploy the pending update of ' + name + '?')) return;
+    fetch('/approve/' + encodeURIComponent(name), { method: 'POST' })
+      .then(function(r) { if (!r.ok) r.text().then(function(t) { alert('Approve failed: ' + t); }); });
+  };
+  window.rejectSvc = function(name) {
+    if (!confirm('Reject and block the pending update of ' + name + '?')) return;
+    fetch('/reject/' + encodeURIComponent(name), { method: 'POST' })
+      .then(function(r) { if (!r.ok) r.text().then(function(t) { alert('Reject failed: ' + t); }); });
+  };
+
   // ---- theme toggle ----
   function updateThemeBtn() {
     var cur = document.documentElement.getAttribute('data-theme') || 'dark';
@@ -1650,6 +1798,7 @@ const dataStarHTML = `<!DOCTYPE html>
     document.getElementById('svc-auto-heal').checked = false;
     document.getElementById('svc-auto-start').checked = false;
     document.getElementById('svc-compose-watch').checked = false;
+    document.getElementById('svc-require-approval').checked = false;
     document.getElementById('svc-silent').checked = false;
     document.getElementById('svc-health-grace').value = 60;
     document.getElementById('svc-heal-cooldown').value = 300;
@@ -1673,6 +1822,7 @@ const dataStarHTML = `<!DOCTYPE html>
         document.getElementById('svc-auto-heal').checked = !!svc.auto_heal;
         document.getElementById('svc-auto-start').checked = !!svc.auto_start;
         document.getElementById('svc-compose-watch').checked = !!svc.compose_watch;
+        document.getElementById('svc-require-approval').checked = !!svc.require_approval;
         document.getElementById('svc-silent').checked = !!svc.silent;
         document.getElementById('svc-health-grace').value = svc.health_grace || 60;
         document.getElementById('svc-heal-cooldown').value = svc.heal_cooldown || 300;
@@ -1695,7 +1845,12 @@ const dataStarHTML = `<!DOCTYPE html>
     var name = editingServiceName || document.getElementById('svc-name').value.trim();
     if (!name) { showCfgMsg('Service name is required', 'error'); return; }
     var splitLines = function(v) { return v.split('\n').map(function(s){return s.trim();}).filter(Boolean); };
-    var svc = {
+    // Start from the existing entry so fields without a form input survive the edit.
+    var existing = {};
+    for (var i = 0; currentConfig && i < (currentConfig.services || []).length; i++) {
+      if (currentConfig.services[i].name === name) { existing = currentConfig.services[i]; break; }
+    }
+    var svc = Object.assign({}, existing, {
       name: name,
       images: splitLines(document.getElementById('svc-images').value),
       compose_files: splitLines(document.getElementById('svc-compose-files').value),
@@ -1706,13 +1861,14 @@ const dataStarHTML = `<!DOCTYPE html>
       auto_heal: document.getElementById('svc-auto-heal').checked,
       auto_start: document.getElementById('svc-auto-start').checked,
       compose_watch: document.getElementById('svc-compose-watch').checked,
+      require_approval: document.getElementById('svc-require-approval').checked,
       silent: document.getElementById('svc-silent').checked,
       health_grace: parseInt(document.getElementById('svc-health-grace').value) || 60,
       heal_cooldown: parseInt(document.getElementById('svc-heal-cooldown').value) || 300,
       heal_max_restarts: parseInt(document.getElementById('svc-heal-max-restarts').value) || 3,
       cpu_threshold: parseFloat(document.getElementById('svc-cpu-threshold').value) || 0,
       memory_threshold: parseFloat(document.getElementById('svc-memory-threshold').value) || 0
-    };
+    });
     fetch('/config/services/' + encodeURIComponent(name), {
       method: 'PUT',
       headers: {'Content-Type': 'application/json'},
@@ -1732,11 +1888,11 @@ const dataStarHTML = `<!DOCTYPE html>
   };
 
   window.saveRegistry = function() {
-    var reg = {
+    var reg = Object.assign({}, (currentConfig && currentConfig.registry) || {}, {
       url: document.getElementById('reg-url').value.trim(),
       poll_interval: parseInt(document.getElementById('reg-interval').value) || 300,
       insecure: document.getElementById('reg-insecure').checked
-    };
+    });
     fetch('/config/registry', {method:'PUT', headers:{'Content-Type':'application/json'}, body:JSON.stringify(reg)})
       .then(function(r) { if (!r.ok) return r.text().then(function(t) { throw new Error(t); }); return r.json(); })
       .then(function() { showCfgMsg('Registry saved', 'ok'); loadConfig(); })
@@ -1837,7 +1993,7 @@ func (a *API) handleForceRedeploy(w http.ResponseWriter, r *http.Request) {
 	saferun.Go("force-redeploy-"+found.Name, func() {
 		ctx := context.Background()
 		a.updater.tryStartDeploy(svcCopy.Name)
-		composeOut, err := compose.Up(ctx, a.updater.cfg.Runtime, svcCopy.ComposeFiles, svcCopy.ComposeProject, svcCopy.EnvFile)
+		composeOut, err := compose.Up(ctx, a.updater.cfg.Runtime, svcCopy.ComposeFiles, svcCopy.ComposeProject, svcCopy.EnvFile, a.updater.composeEnv(svcCopy, nil)...)
 		if err != nil {
 			a.updater.clearDeploying(svcCopy.Name)
 			logger.Printf("[api] ERROR: force redeploy failed for %s: %v", svcCopy.Name, err)
@@ -1917,6 +2073,9 @@ func (a *API) handleCommandPreview(w http.ResponseWriter, r *http.Request) {
 	if svc.EnvFile != "" {
 		cmd += fmt.Sprintf(" # (with env from %s)", svc.EnvFile)
 	}
+	if env := a.updater.composeEnv(svc, nil); len(env) > 0 {
+		cmd = strings.Join(env, " ") + " " + cmd
+	}
 
 	writeJSON(w, map[string]string{
 		"service": serviceName,
@@ -0,0 +1,83 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/studiowebux/dockward/internal/audit"
+	"github.com/studiowebux/dockward/internal/logger"
+)
+
+// GET /pending - updates held by require_approval, deploy windows or a freeze
+func (a *API) handlePending(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, a.updater.PendingDeploys())
+}
+
+// POST /approve/<service> - deploy the update held for approval
+func (a *API) handleApprove(w http.ResponseWriter, r *http.Request) {
+	a.handleApproval(w, r, "/approve/", true)
+}
+
+// POST /reject/<service> - drop the update held for approval and block its digests
+func (a *API) handleReject(w http.ResponseWriter, r *http.Request) {
+	a.handleApproval(w, r, "/reject/", false)
+}
+
+func (a *API) handleApproval(w http.ResponseWriter, r *http.Request, prefix string, approve bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	serviceName := validateServiceName(strings.TrimPrefix(r.URL.Path, prefix))
+	if serviceName == "" {
+		http.Error(w, "invalid service name: must match ^[a-zA-Z0-9_-]{1,64}$", http.StatusBadRequest)
+		return
+	}
+	svc, ok := a.findService(serviceName)
+	if !ok {
+		http.Error(w, "service not found", http.StatusNotFound)
+		return
+	}
+
+	var (
+		p   PendingDeploy
+		err error
+	)
+	event, status := "approved", "approved"
+	if approve {
+		p, err = a.updater.Approve(svc)
+	} else {
+		p, err = a.updater.Reject(svc)
+		event, status = "rejected", "rejected"
+	}
+	if errors.Is(err, ErrNoPendingApproval) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	for img, digest := range p.Images {
+		msg := fmt.Sprintf("Update of %s to %s approved via API", img, shortDigest(digest))
+		if !approve {
+			msg = fmt.Sprintf("Update of %s to %s rejected via API; digest blocked", img, shortDigest(digest))
+		}
+		logger.Printf("[api] %s: %s", serviceName, msg)
+		if werr := a.audit.Write(audit.Entry{
+			Service:   serviceName,
+			Event:     event,
+			Message:   msg,
+			Level:     "info",
+			OldDigest: p.Current[img],
+			NewDigest: digest,
+		}); werr != nil {
+			logger.Printf("[api] ERROR: audit write error: %v", werr)
+		}
+	}
+	writeJSON(w, map[string]string{"status": status, "service": serviceName})
+}
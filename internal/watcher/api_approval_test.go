@@ -0,0 +1,101 @@
+package watcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/notify"
+	"github.com/studiowebux/dockward/internal/state"
+)
+
+func approvalAPI() *API {
+	st, _ := state.Open("")
+	cfg := &config.Config{Services: []config.Service{{Name: "web", Images: []string{"api:latest"}, RequireApproval: true}}}
+	api := testAPI(nil)
+	api.updater = NewUpdater(cfg, nil, nil, notify.NewDispatcher(), NewMetrics(), nil, st)
+	return api
+}
+
+func TestHandleApproval_Errors(t *testing.T) {
+	api := approvalAPI()
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		approve bool
+		want    int
+	}{
+		{"wrong method", http.MethodGet, "/approve/web", true, http.StatusMethodNotAllowed},
+		{"invalid name", http.MethodPost, "/approve/../etc", true, http.StatusBadRequest},
+		{"unknown service", http.MethodPost, "/approve/nope", true, http.StatusNotFound},
+		{"nothing to approve", http.MethodPost, "/approve/web", true, http.StatusConflict},
+		{"nothing to reject", http.MethodPost, "/reject/web", false, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.approve {
+				api.handleApprove(w, req)
+			} else {
+				api.handleReject(w, req)
+			}
+			if w.Code != tt.want {
+				t.Errorf("want %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandleReject_BlocksDigest(t *testing.T) {
+	api := approvalAPI()
+	u := api.updater
+	svc := u.cfg.Services[0]
+	u.holdDeploy(context.Background(), svc, []imageChange{{Image: "api:latest", OldDigest: digestA, NewDigest: digestB}}, holdApproval, time.Time{})
+
+	w := httptest.NewRecorder()
+	api.handlePending(w, httptest.NewRequest(http.MethodGet, "/pending", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("pending: want 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	api.handleReject(w, httptest.NewRequest(http.MethodPost, "/reject/web", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("reject: want 200, got %d", w.Code)
+	}
+	if got := u.BlockedDigests()["web/api:latest"]; got != digestB {
+		t.Errorf("want %s blocked, got %q", digestB, got)
+	}
+	if got := u.state.Snapshot().Blocked["web/api:latest"]; got != digestB {
+		t.Errorf("want blocked digest persisted, got %q", got)
+	}
+	if len(u.PendingDeploys()) != 0 {
+		t.Error("want pending cleared after reject")
+	}
+}
+
+func TestIsApproved(t *testing.T) {
+	u := approvalAPI().updater
+	changed := []imageChange{{Image: "api:latest", NewDigest: digestB}}
+	if u.isApproved("web", changed) {
+		t.Error("want unapproved before approval")
+	}
+
+	u.approved["web"] = map[string]string{"api:latest": digestB}
+	if !u.isApproved("web", changed) {
+		t.Error("want approved digest accepted")
+	}
+	if u.isApproved("web", []imageChange{{Image: "api:latest", NewDigest: digestA}}) {
+		t.Error("want a different digest to need a new approval")
+	}
+
+	u.clearPending("web")
+	if u.isApproved("web", changed) {
+		t.Error("want approval cleared with the pending entry")
+	}
+}
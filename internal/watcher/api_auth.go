@@ -0,0 +1,164 @@
+package watcher
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/audit"
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/logger"
+)
+
+// authCookie is set after a successful ?token= login so the web UI (including
+// EventSource, which cannot send headers) stays authenticated.
+const authCookie = "dockward_token"
+
+// roleRank orders roles so an operator satisfies any viewer requirement.
+var roleRank = map[string]int{
+	config.RoleViewer:   1,
+	config.RoleOperator: 2,
+}
+
+// requireRole wraps h so it only runs for requests presenting a token whose
+// role is at least role. The token is read from "Authorization: Bearer",
+// the ?token= query parameter, or the auth cookie, in that order.
+// Authentication is disabled entirely when api.tokens is empty.
+func (a *API) requireRole(role string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(a.tokens) == 0 {
+			h(w, r)
+			return
+		}
+
+		token, fromQuery := requestToken(r)
+		tok, ok := a.matchToken(token)
+		if !ok {
+			a.auditAuthFailure(r, "", "missing or invalid token")
+			http.SetCookie(w, &http.Cookie{Name: authCookie, Value: "", MaxAge: -1, Path: "/"})
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if roleRank[tok.Role] < roleRank[role] {
+			a.auditAuthFailure(r, tok.Name, fmt.Sprintf("role %q requires %q", tok.Role, role))
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		if fromQuery {
+			http.SetCookie(w, &http.Cookie{
+				Name:     authCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+		}
+		h(w, r)
+	}
+}
+
+// requestToken extracts the caller's token. fromQuery reports whether it came
+// from the ?token= parameter so the caller can persist it in a cookie.
+func requestToken(r *http.Request) (token string, fromQuery bool) {
+	if hdr := r.Header.Get("Authorization"); strings.HasPrefix(hdr, "Bearer ") {
+		return strings.TrimPrefix(hdr, "Bearer "), false
+	}
+	if t := r.URL.Query().Get("token"); t != "" {
+		return t, true
+	}
+	if c, err := r.Cookie(authCookie); err == nil {
+		return c.Value, false
+	}
+	return "", false
+}
+
+// matchToken returns the configured token equal to token.
+// Every candidate is compared in constant time to prevent timing attacks.
+func (a *API) matchToken(token string) (config.APIToken, bool) {
+	var match config.APIToken
+	found := false
+	if token == "" {
+		return match, false
+	}
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Secret()), []byte(token)) == 1 {
+			match = t
+			found = true
+		}
+	}
+	return match, found
+}
+
+// authAuditWindow is how long repeated rejections of one remote address on
+// one path are folded into a single auth_failed audit entry.
+var authAuditWindow = time.Minute
+
+// authFailures is the audit window of one remote address and path.
+type authFailures struct {
+	until      time.Time
+	suppressed int // rejections not audited since the window opened
+}
+
+// auditAuthFailure records a rejected request. name is the matched token name
+// when the token was valid but lacked the required role. Only the first
+// rejection of a remote address on a path per authAuditWindow is audited;
+// the rest are logged in verbose mode and counted in the next entry, so a
+// client polling without a token does not flood the audit log and the
+// warden.
+func (a *API) auditAuthFailure(r *http.Request, name, reason string) {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	who := remote
+	if name != "" {
+		who = fmt.Sprintf("token %q from %s", name, remote)
+	}
+	audited, suppressed := a.authWindow(remote+" "+r.URL.Path, time.Now())
+	if !audited {
+		debugf("[api] auth failed: %s %s by %s: %s", r.Method, r.URL.Path, who, reason)
+		return
+	}
+	logger.Printf("[api] auth failed: %s %s by %s: %s", r.Method, r.URL.Path, who, reason)
+	msg := fmt.Sprintf("Rejected %s %s by %s", r.Method, r.URL.Path, who)
+	if suppressed > 0 {
+		msg += fmt.Sprintf(" (%d more since the last entry)", suppressed)
+	}
+	if werr := a.audit.Write(audit.Entry{
+		Event:   "auth_failed",
+		Message: msg,
+		Reason:  reason,
+		Level:   "warning",
+	}); werr != nil {
+		logger.Printf("[api] ERROR: audit write error: %v", werr)
+	}
+}
+
+// authWindow reports whether a rejection for key should be audited, opening
+// a new window when it should, and how many rejections the previous window
+// suppressed.
+func (a *API) authWindow(key string, now time.Time) (audited bool, suppressed int) {
+	a.authMu.Lock()
+	defer a.authMu.Unlock()
+	if a.authFails == nil {
+		a.authFails = make(map[string]authFailures)
+	}
+	f, ok := a.authFails[key]
+	if ok && now.Before(f.until) {
+		f.suppressed++
+		a.authFails[key] = f
+		return false, 0
+	}
+	// Drop the expired windows so the map only holds active clients.
+	for k, other := range a.authFails {
+		if !now.Before(other.until) {
+			delete(a.authFails, k)
+		}
+	}
+	a.authFails[key] = authFailures{until: now.Add(authAuditWindow)}
+	return true, f.suppressed
+}
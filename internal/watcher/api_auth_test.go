@@ -0,0 +1,171 @@
+package watcher
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/audit"
+	"github.com/studiowebux/dockward/internal/config"
+)
+
+func okHandler(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestRequireRole_DisabledWithoutTokens(t *testing.T) {
+	api := testAPI(nil)
+	req := httptest.NewRequest(http.MethodPost, "/trigger", nil)
+	w := httptest.NewRecorder()
+
+	api.requireRole(config.RoleOperator, okHandler)(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("want 200, got %d", w.Code)
+	}
+}
+
+func TestRequireRole_Roles(t *testing.T) {
+	api := testAPI(nil)
+	api.tokens = []config.APIToken{
+		{Name: "dash", Token: "view-token", Role: config.RoleViewer},
+		{Name: "ci", Token: "ops-token", Role: config.RoleOperator},
+	}
+
+	tests := []struct {
+		name  string
+		role  string
+		token string
+		want  int
+	}{
+		{"no token", config.RoleViewer, "", http.StatusUnauthorized},
+		{"wrong token", config.RoleViewer, "nope", http.StatusUnauthorized},
+		{"viewer reads", config.RoleViewer, "view-token", http.StatusOK},
+		{"viewer mutates", config.RoleOperator, "view-token", http.StatusForbidden},
+		{"operator reads", config.RoleViewer, "ops-token", http.StatusOK},
+		{"operator mutates", config.RoleOperator, "ops-token", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/status", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+
+			api.requireRole(tt.role, okHandler)(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("want %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestRequireRole_QueryTokenSetsCookie(t *testing.T) {
+	api := testAPI(nil)
+	api.tokens = []config.APIToken{{Name: "dash", Token: "view-token", Role: config.RoleViewer}}
+
+	req := httptest.NewRequest(http.MethodGet, "/ui?token=view-token", nil)
+	w := httptest.NewRecorder()
+	api.requireRole(config.RoleViewer, okHandler)(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != authCookie {
+		t.Fatalf("want %s cookie, got %v", authCookie, cookies)
+	}
+
+	// The cookie alone must authenticate follow-up requests (EventSource).
+	req = httptest.NewRequest(http.MethodGet, "/ui/events", nil)
+	req.AddCookie(cookies[0])
+	w = httptest.NewRecorder()
+	api.requireRole(config.RoleViewer, okHandler)(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("want 200 with cookie, got %d", w.Code)
+	}
+}
+
+func TestRequireRole_FailureIsAudited(t *testing.T) {
+	al, err := audit.New(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer al.Close()
+
+	api := testAPI(al)
+	api.tokens = []config.APIToken{{Name: "dash", Token: "view-token", Role: config.RoleViewer}}
+
+	req := httptest.NewRequest(http.MethodPost, "/redeploy/web", nil)
+	req.Header.Set("Authorization", "Bearer view-token")
+	api.requireRole(config.RoleOperator, okHandler)(httptest.NewRecorder(), req)
+
+	entries, err := al.Recent(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("want 1 audit entry, got %d", len(entries))
+	}
+	if entries[0].Event != "auth_failed" || entries[0].Level != "warning" {
+		t.Errorf("want auth_failed/warning, got %s/%s", entries[0].Event, entries[0].Level)
+	}
+}
+
+func TestRequireRole_RepeatedFailuresAuditedOncePerWindow(t *testing.T) {
+	al, err := audit.New(filepath.Join(t.TempDir(), "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer al.Close()
+
+	api := testAPI(al)
+	api.tokens = []config.APIToken{{Name: "dash", Token: "view-token", Role: config.RoleViewer}}
+	reject := func(path, remote string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remote
+		api.requireRole(config.RoleViewer, okHandler)(httptest.NewRecorder(), req)
+	}
+
+	for range 5 {
+		reject("/status", "10.0.0.1:1234")
+	}
+	reject("/ui/events", "10.0.0.1:1234")
+	reject("/status", "10.0.0.2:1234")
+	entries, err := al.Recent(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("want one entry per remote address and path, got %d", len(entries))
+	}
+
+	// Expire the window: the next rejection is audited with the suppressed count.
+	api.authMu.Lock()
+	f := api.authFails["10.0.0.1 /status"]
+	f.until = time.Now().Add(-time.Second)
+	api.authFails["10.0.0.1 /status"] = f
+	api.authMu.Unlock()
+	reject("/status", "10.0.0.1:1234")
+
+	entries, err = al.Recent(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("want a new entry after the window, got %d", len(entries))
+	}
+	var found bool
+	for _, e := range entries {
+		found = found || strings.Contains(e.Message, "(4 more since the last entry)")
+	}
+	if !found {
+		t.Errorf("want the suppressed count in the new entry, got %+v", entries)
+	}
+}
@@ -9,27 +9,40 @@ import (
 	"github.com/studiowebux/dockward/internal/logger"
 )
 
-// GET /config — return the current in-memory config as JSON.
+// GET /config — return the current in-memory config as JSON, secrets redacted.
 func (a *API) handleGetConfig(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 	a.updater.cfg.RLock()
-	defer a.updater.cfg.RUnlock()
-	writeJSON(w, a.updater.cfg)
+	cfg, err := a.updater.cfg.Redacted()
+	a.updater.cfg.RUnlock()
+	if err != nil {
+		logger.Printf("[api] config redact error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, cfg)
 }
 
-// GET /config/download — download the current in-memory config as a JSON file attachment.
+// GET /config/download — download the current in-memory config as a JSON file
+// attachment, secrets redacted.
 func (a *API) handleConfigDownload(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 	a.updater.cfg.RLock()
-	defer a.updater.cfg.RUnlock()
+	cfg, err := a.updater.cfg.Redacted()
+	a.updater.cfg.RUnlock()
+	if err != nil {
+		logger.Printf("[api] config redact error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
 
-	data, err := json.MarshalIndent(a.updater.cfg, "", "  ")
+	data, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		logger.Printf("[api] config download marshal error: %v", err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
@@ -58,6 +71,10 @@ func (a *API) handlePutRegistry(w http.ResponseWriter, r *http.Request) {
 	a.updater.cfg.Lock()
 	defer a.updater.cfg.Unlock()
 
+	// GET /config redacts the password; sending it back keeps the current one.
+	if reg.Password == config.RedactedSecret {
+		reg.Password = a.updater.cfg.Registry.Password
+	}
 	a.updater.cfg.Registry = reg
 	a.updater.cfg.ApplyDefaults()
 	if err := a.updater.cfg.Save(a.configPath); err != nil {
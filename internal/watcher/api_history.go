@@ -0,0 +1,127 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/studiowebux/dockward/internal/audit"
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/logger"
+)
+
+// digestPattern matches a full sha256 image digest.
+var digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// findService returns the configured service with the given name.
+func (a *API) findService(name string) (config.Service, bool) {
+	for _, svc := range a.updater.cfg.SnapshotServices() {
+		if svc.Name == name {
+			return svc, true
+		}
+	}
+	return config.Service{}, false
+}
+
+// GET /history/<service> - retained releases and deploy records
+func (a *API) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	serviceName := validateServiceName(strings.TrimPrefix(r.URL.Path, "/history/"))
+	if serviceName == "" {
+		http.Error(w, "invalid service name: must match ^[a-zA-Z0-9_-]{1,64}$", http.StatusBadRequest)
+		return
+	}
+	svc, ok := a.findService(serviceName)
+	if !ok {
+		http.Error(w, "service not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, a.updater.History(svc))
+}
+
+// POST /rollback/<service>?digest=sha256:... - redeploy a retained digest and pin it
+func (a *API) handleRollbackTo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	serviceName := validateServiceName(strings.TrimPrefix(r.URL.Path, "/rollback/"))
+	if serviceName == "" {
+		http.Error(w, "invalid service name: must match ^[a-zA-Z0-9_-]{1,64}$", http.StatusBadRequest)
+		return
+	}
+	digest := r.URL.Query().Get("digest")
+	if !digestPattern.MatchString(digest) {
+		http.Error(w, "invalid digest: must be sha256:<64 hex>", http.StatusBadRequest)
+		return
+	}
+	svc, ok := a.findService(serviceName)
+	if !ok {
+		http.Error(w, "service not found", http.StatusNotFound)
+		return
+	}
+	if len(svc.ComposeFiles) == 0 && !svc.Standalone() {
+		writeJSON(w, map[string]string{"status": "error", "message": "no compose files configured"})
+		return
+	}
+
+	if err := a.updater.RollbackTo(r.Context(), svc, digest); err != nil {
+		switch {
+		case errors.Is(err, ErrReleaseNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, ErrDeployInProgress):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			logger.Printf("[api] ERROR: rollback %s to %s: %v", serviceName, shortDigest(digest), err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	logger.Printf("[api] manual rollback: %s -> %s", serviceName, shortDigest(digest))
+	if werr := a.audit.Write(audit.Entry{
+		Service:   serviceName,
+		Event:     "manual_rollback",
+		Message:   fmt.Sprintf("Rollback to retained digest %s requested", shortDigest(digest)),
+		Level:     "info",
+		NewDigest: digest,
+	}); werr != nil {
+		logger.Printf("[api] ERROR: audit write error: %v", werr)
+	}
+	writeJSON(w, map[string]string{"status": "rolling_back", "service": serviceName, "digest": digest})
+}
+
+// POST /unpin/<service> - release pinned digests so updates resume
+func (a *API) handleUnpin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	serviceName := validateServiceName(strings.TrimPrefix(r.URL.Path, "/unpin/"))
+	if serviceName == "" {
+		http.Error(w, "invalid service name: must match ^[a-zA-Z0-9_-]{1,64}$", http.StatusBadRequest)
+		return
+	}
+
+	if !a.updater.UnpinService(serviceName) {
+		writeJSON(w, map[string]string{"status": "not_pinned", "service": serviceName})
+		return
+	}
+	if werr := a.audit.Write(audit.Entry{
+		Service: serviceName,
+		Event:   "unpinned",
+		Message: "Pinned digest released via API; updates resume on next poll",
+		Level:   "info",
+	}); werr != nil {
+		logger.Printf("[api] ERROR: audit write error: %v", werr)
+	}
+	writeJSON(w, map[string]string{"status": "unpinned", "service": serviceName})
+}
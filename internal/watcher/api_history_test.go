@@ -0,0 +1,129 @@
+package watcher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/registry"
+	"github.com/studiowebux/dockward/internal/state"
+)
+
+const (
+	digestA = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	digestB = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+// historyAPI builds an API around an updater with one service "web" whose
+// image "api:latest" has two retained releases (A then B).
+func historyAPI(t *testing.T) *API {
+	t.Helper()
+	st, _ := state.Open("")
+	for _, d := range []string{digestA, digestB} {
+		if _, err := st.RetainRelease("web/api:latest", state.Release{Digest: d, Tag: releaseTag(d)}, 5); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := &config.Config{Registry: config.Registry{URL: "http://localhost:5000"}, Services: []config.Service{{
+		Name:           "web",
+		Images:         []string{"api:latest"},
+		ComposeFiles:   []string{"/srv/web/compose.yml"},
+		ComposeProject: "web",
+	}}}
+	api := testAPI(nil)
+	api.updater = NewUpdater(cfg, nil, map[string]*registry.Client{
+		config.DefaultRegistryName: registry.NewClient("http://localhost:5000", false, registry.Credentials{}),
+	}, nil, NewMetrics(), nil, st)
+	return api
+}
+
+func TestHandleHistory_NewestFirst(t *testing.T) {
+	api := historyAPI(t)
+	w := httptest.NewRecorder()
+	api.handleHistory(w, httptest.NewRequest(http.MethodGet, "/history/web", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d", w.Code)
+	}
+	var h ServiceHistory
+	if err := json.NewDecoder(w.Body).Decode(&h); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(h.Images) != 1 || len(h.Images[0].Releases) != 2 {
+		t.Fatalf("want 1 image with 2 releases, got %+v", h.Images)
+	}
+	if got := h.Images[0].Releases[0].Digest; got != digestB {
+		t.Errorf("want newest release first (%s), got %s", digestB, got)
+	}
+	if got := h.Images[0].Releases[0].Tag; got != "dockward-bbbbbbbbbbbb" {
+		t.Errorf("want pinned tag dockward-bbbbbbbbbbbb, got %s", got)
+	}
+}
+
+func TestHandleHistory_UnknownService(t *testing.T) {
+	api := historyAPI(t)
+	w := httptest.NewRecorder()
+	api.handleHistory(w, httptest.NewRequest(http.MethodGet, "/history/nope", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("want 404, got %d", w.Code)
+	}
+}
+
+func TestHandleRollbackTo_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		want   int
+	}{
+		{"wrong method", http.MethodGet, "/rollback/web?digest=" + digestA, http.StatusMethodNotAllowed},
+		{"missing digest", http.MethodPost, "/rollback/web", http.StatusBadRequest},
+		{"malformed digest", http.MethodPost, "/rollback/web?digest=sha256:abc", http.StatusBadRequest},
+		{"unknown service", http.MethodPost, "/rollback/nope?digest=" + digestA, http.StatusNotFound},
+		{"digest not retained", http.MethodPost, "/rollback/web?digest=sha256:" + strings.Repeat("c", 64), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := historyAPI(t)
+			w := httptest.NewRecorder()
+			api.handleRollbackTo(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.want {
+				t.Errorf("want %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestHandleRollbackTo_DeployInProgress(t *testing.T) {
+	api := historyAPI(t)
+	api.updater.tryStartDeploy("web")
+
+	w := httptest.NewRecorder()
+	api.handleRollbackTo(w, httptest.NewRequest(http.MethodPost, "/rollback/web?digest="+digestA, nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("want 409, got %d", w.Code)
+	}
+}
+
+func TestUnpinService(t *testing.T) {
+	api := historyAPI(t)
+	api.updater.pinned["web/api:latest"] = digestA
+
+	w := httptest.NewRecorder()
+	api.handleUnpin(w, httptest.NewRequest(http.MethodPost, "/unpin/web", nil))
+	if !strings.Contains(w.Body.String(), `"unpinned"`) {
+		t.Fatalf("want unpinned, got %s", w.Body.String())
+	}
+	if len(api.updater.PinnedDigests()) != 0 {
+		t.Error("want pin cleared")
+	}
+
+	w = httptest.NewRecorder()
+	api.handleUnpin(w, httptest.NewRequest(http.MethodPost, "/unpin/web", nil))
+	if !strings.Contains(w.Body.String(), `"not_pinned"`) {
+		t.Errorf("want not_pinned on second call, got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,87 @@
+package watcher
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/audit"
+	"github.com/studiowebux/dockward/internal/logger"
+)
+
+// POST /freeze?until=<RFC3339>|duration=<2h>[&reason=] - hold all image deploys
+// DELETE /freeze - lift the freeze; pending updates deploy within a minute
+func (a *API) handleFreeze(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodDelete:
+		active, err := a.updater.Unfreeze()
+		if err != nil {
+			logger.Printf("[api] ERROR: state write error: %v", err)
+		}
+		if !active {
+			writeJSON(w, map[string]string{"status": "not_frozen"})
+			return
+		}
+		if werr := a.audit.Write(audit.Entry{
+			Event:   "unfreeze",
+			Message: "Deploy freeze lifted via API",
+			Level:   "info",
+		}); werr != nil {
+			logger.Printf("[api] ERROR: audit write error: %v", werr)
+		}
+		writeJSON(w, map[string]string{"status": "unfrozen"})
+		return
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	var until time.Time
+	switch {
+	case q.Get("until") != "":
+		t, err := time.Parse(time.RFC3339, q.Get("until"))
+		if err != nil {
+			http.Error(w, "invalid until: must be RFC3339 (e.g. 2026-03-02T18:00:00Z)", http.StatusBadRequest)
+			return
+		}
+		until = t
+	case q.Get("duration") != "":
+		d, err := time.ParseDuration(q.Get("duration"))
+		if err != nil || d <= 0 {
+			http.Error(w, "invalid duration: must be a positive Go duration (e.g. 2h30m)", http.StatusBadRequest)
+			return
+		}
+		until = time.Now().Add(d)
+	default:
+		http.Error(w, "until or duration is required", http.StatusBadRequest)
+		return
+	}
+	if !until.After(time.Now()) {
+		http.Error(w, "until must be in the future", http.StatusBadRequest)
+		return
+	}
+	reason := q.Get("reason")
+	if len(reason) > 200 {
+		reason = reason[:200]
+	}
+
+	if err := a.updater.Freeze(until, reason); err != nil {
+		logger.Printf("[api] ERROR: state write error: %v", err)
+	}
+	msg := fmt.Sprintf("Deploy freeze set via API until %s", until.UTC().Format(time.RFC3339))
+	if reason != "" {
+		msg += ": " + reason
+	}
+	logger.Printf("[api] %s", msg)
+	if werr := a.audit.Write(audit.Entry{
+		Event:   "freeze",
+		Message: msg,
+		Level:   "info",
+		Reason:  reason,
+	}); werr != nil {
+		logger.Printf("[api] ERROR: audit write error: %v", werr)
+	}
+	writeJSON(w, map[string]string{"status": "frozen", "until": until.UTC().Format(time.RFC3339)})
+}
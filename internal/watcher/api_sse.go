@@ -24,6 +24,9 @@ func (a *API) sendStatus(w http.ResponseWriter, flusher http.Flusher) {
 		"services":       services,
 		"uptime_seconds": a.metrics.Meta().UptimeSeconds,
 	}
+	if f := a.updater.ActiveFreeze(); f != nil {
+		status["freeze"] = f
+	}
 
 	if statusData, err := json.Marshal(status); err == nil {
 		fmt.Fprintf(w, "event: status\ndata: %s\n\n", statusData)
@@ -23,7 +23,10 @@ func testAPI(al *audit.Logger) *API {
 	}
 
 	// Create a mock Docker health checker for testing
-	dc := docker.NewClient()
+	dc, err := docker.NewClient(docker.Options{})
+	if err != nil {
+		panic(err)
+	}
 	dockerHealth := docker.NewHealthChecker(dc, 30*time.Second, 5*time.Second)
 
 	return &API{audit: al, hub: h, dockerHealth: dockerHealth}
@@ -0,0 +1,188 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+)
+
+// Death classes of a stopped container.
+const (
+	deathOOM    = "oom"    // killed by the kernel OOM killer
+	deathCrash  = "crash"  // non-zero exit code
+	deathSignal = "signal" // killed by a signal (exit code 128+n)
+	deathClean  = "clean"  // exit code 0
+)
+
+// deathClasses lists every death class, for metrics labels.
+var deathClasses = []string{deathOOM, deathCrash, deathSignal, deathClean}
+
+// healSlack is how long after a heal action its die events may still arrive.
+const healSlack = 5 * time.Second
+
+// oomWindow is how long an oom event is attributed to the container's next
+// die event. An OOM kill of a child process does not stop the container.
+const oomWindow = 10 * time.Second
+
+// death describes why a container stopped.
+type death struct {
+	class    string
+	exitCode int
+	memLimit int64  // bytes, 0 = unlimited
+	err      string // runtime error reported by the engine, if any
+}
+
+// classifyDeath classifies a stopped container from its exit code. Exit
+// codes above 128 are deaths by signal 128-n, as reported by the engine.
+func classifyDeath(exitCode int, oomKilled bool) string {
+	switch {
+	case oomKilled:
+		return deathOOM
+	case exitCode == 0:
+		return deathClean
+	case exitCode > 128 && exitCode <= 128+64:
+		return deathSignal
+	default:
+		return deathCrash
+	}
+}
+
+// String describes the death for alert reasons: "killed by OOM at 512MB
+// limit", "signal 9 (killed)", "exit 1".
+func (d death) String() string {
+	var s string
+	switch d.class {
+	case deathOOM:
+		if d.memLimit > 0 {
+			s = fmt.Sprintf("killed by OOM at %dMB limit", d.memLimit>>20)
+		} else {
+			s = "killed by OOM (no memory limit)"
+		}
+	case deathSignal:
+		sig := d.exitCode - 128
+		s = fmt.Sprintf("signal %d (%s)", sig, syscall.Signal(sig))
+	default:
+		s = fmt.Sprintf("exit %d", d.exitCode)
+	}
+	if d.err != "" {
+		s += ": " + d.err
+	}
+	return s
+}
+
+// alert returns the event, message and level of a single death.
+func (d death) alert() (event, message, level string) {
+	switch d.class {
+	case deathOOM:
+		return "oom_killed", "Container " + d.String() + ".", "critical"
+	case deathSignal:
+		return "died", "Container killed by " + d.String() + ".", "critical"
+	case deathClean:
+		return "died", "Container exited cleanly (" + d.String() + ").", "warning"
+	default:
+		return "died", "Container exited unexpectedly (" + d.String() + ").", "critical"
+	}
+}
+
+// inspectDeath reads why a container stopped. The exit code of the die event
+// wins over the inspected state: a restart policy may already have restarted
+// the container, which resets its state.
+func (h *Healer) inspectDeath(ctx context.Context, containerID, exitAttr string) death {
+	code, err := strconv.Atoi(exitAttr)
+	haveCode := err == nil
+	oom := h.takeOOM(containerID)
+
+	var d death
+	if info, err := h.docker.InspectContainer(ctx, containerID); err == nil {
+		d.memLimit = info.HostConfig.Memory
+		if !info.State.Running {
+			oom = oom || info.State.OOMKilled
+			d.err = info.State.Error
+			if !haveCode {
+				code = info.State.ExitCode
+			}
+		}
+	}
+	d.exitCode = code
+	d.class = classifyDeath(code, oom)
+	return d
+}
+
+// markOOM records an oom event for a container.
+func (h *Healer) markOOM(containerID string) {
+	h.oomMu.Lock()
+	h.oom[containerID] = time.Now()
+	h.oomMu.Unlock()
+}
+
+// takeOOM reports whether the container had an oom event within oomWindow,
+// and forgets it.
+func (h *Healer) takeOOM(containerID string) bool {
+	h.oomMu.Lock()
+	defer h.oomMu.Unlock()
+	at, ok := h.oom[containerID]
+	delete(h.oom, containerID)
+	return ok && time.Since(at) < oomWindow
+}
+
+// recordDeath records a death of the service at the given time and returns
+// the deaths within its crash_loop_window, this one included, and the mean
+// time between them.
+func (h *Healer) recordDeath(svc *config.Service, at time.Time) (int, time.Duration) {
+	window := time.Duration(svc.CrashLoopWindow) * time.Second
+	h.deathsMu.Lock()
+	defer h.deathsMu.Unlock()
+	var recent []time.Time
+	for _, t := range h.deaths[svc.Name] {
+		if at.Sub(t) < window {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, at)
+	h.deaths[svc.Name] = recent
+	n := len(recent)
+	if n < 2 {
+		return n, 0
+	}
+	return n, recent[n-1].Sub(recent[0]) / time.Duration(n-1)
+}
+
+// crashLooping reports whether the service died crash_loop_deaths times
+// within the last crash_loop_window.
+func (h *Healer) crashLooping(svc *config.Service) bool {
+	window := time.Duration(svc.CrashLoopWindow) * time.Second
+	h.deathsMu.Lock()
+	defer h.deathsMu.Unlock()
+	n := 0
+	for _, t := range h.deaths[svc.Name] {
+		if time.Since(t) < window {
+			n++
+		}
+	}
+	return n >= svc.CrashLoopDeaths
+}
+
+// setHealing marks the start (active) or end of a heal action of the service.
+// Die events are attributed to the action until healSlack after its end.
+func (h *Healer) setHealing(service string, active bool) {
+	h.healingMu.Lock()
+	defer h.healingMu.Unlock()
+	if active {
+		h.healing[service] = time.Time{}
+		return
+	}
+	h.healing[service] = time.Now().Add(healSlack)
+}
+
+// isHealing reports whether a die event of the service is caused by a heal
+// action: one in progress or ended within healSlack.
+func (h *Healer) isHealing(service string) bool {
+	h.healingMu.Lock()
+	defer h.healingMu.Unlock()
+	until, ok := h.healing[service]
+	return ok && (until.IsZero() || time.Now().Before(until))
+}
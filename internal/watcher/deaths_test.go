@@ -0,0 +1,148 @@
+package watcher
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/notify"
+)
+
+func TestClassifyDeath(t *testing.T) {
+	tests := []struct {
+		exitCode int
+		oom      bool
+		want     string
+	}{
+		{0, false, deathClean},
+		{1, false, deathCrash},
+		{255, false, deathCrash},
+		{137, false, deathSignal},
+		{143, false, deathSignal},
+		{137, true, deathOOM},
+		{0, true, deathOOM},
+	}
+	for _, tt := range tests {
+		if got := classifyDeath(tt.exitCode, tt.oom); got != tt.want {
+			t.Errorf("classifyDeath(%d, %v) = %q, want %q", tt.exitCode, tt.oom, got, tt.want)
+		}
+	}
+}
+
+func TestDeath_String(t *testing.T) {
+	tests := []struct {
+		d    death
+		want string
+	}{
+		{death{class: deathOOM, exitCode: 137, memLimit: 512 << 20}, "killed by OOM at 512MB limit"},
+		{death{class: deathOOM, exitCode: 137}, "killed by OOM (no memory limit)"},
+		{death{class: deathCrash, exitCode: 1}, "exit 1"},
+		{death{class: deathCrash, exitCode: 127, err: "exec: not found"}, "exit 127: exec: not found"},
+	}
+	for _, tt := range tests {
+		if got := tt.d.String(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+	if got := (death{class: deathSignal, exitCode: 137}).String(); !strings.HasPrefix(got, "signal 9 (") {
+		t.Errorf("signal death: got %q", got)
+	}
+	if event, _, _ := (death{class: deathOOM}).alert(); event != "oom_killed" {
+		t.Errorf("OOM event = %q, want oom_killed", event)
+	}
+}
+
+func TestHealer_RecordDeath_CrashLoop(t *testing.T) {
+	h := &Healer{deaths: make(map[string][]time.Time)}
+	svc := &config.Service{Name: "myapp", CrashLoopDeaths: 3, CrashLoopWindow: 60}
+
+	start := time.Now().Add(-30 * time.Second)
+	h.recordDeath(svc, start.Add(-2*time.Minute)) // outside the window by the third death
+	if n, _ := h.recordDeath(svc, start); n != 1 {
+		t.Errorf("want old death pruned, got %d deaths", n)
+	}
+	h.recordDeath(svc, start.Add(4*time.Second))
+	n, every := h.recordDeath(svc, start.Add(8*time.Second))
+	if n != 3 || every != 4*time.Second {
+		t.Errorf("want 3 deaths every 4s, got %d every %s", n, every)
+	}
+	if !h.crashLooping(svc) {
+		t.Error("service should be crash looping")
+	}
+
+	other := &config.Service{Name: "other", CrashLoopDeaths: 3, CrashLoopWindow: 60}
+	if h.crashLooping(other) {
+		t.Error("deaths are tracked per service")
+	}
+}
+
+func TestHealer_TakeOOM(t *testing.T) {
+	h := &Healer{oom: make(map[string]time.Time)}
+	h.markOOM("abc")
+	if !h.takeOOM("abc") {
+		t.Error("oom event should be attributed to the next death")
+	}
+	if h.takeOOM("abc") {
+		t.Error("oom event should be consumed")
+	}
+
+	h.oom["old"] = time.Now().Add(-time.Minute)
+	if h.takeOOM("old") {
+		t.Error("stale oom event should be ignored")
+	}
+}
+
+func TestMetrics_Deaths(t *testing.T) {
+	m := NewMetrics()
+	m.SeedServices([]string{"myapp"})
+	m.IncDeaths("myapp", deathOOM)
+	m.IncCrashLoops("myapp")
+
+	out := m.Prometheus()
+	for _, want := range []string{
+		`watcher_container_deaths_total{service="myapp",reason="oom"} 1`,
+		`watcher_container_deaths_total{service="myapp",reason="crash"} 0`,
+		`watcher_crash_loops_total{service="myapp"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in /metrics output", want)
+		}
+	}
+}
+
+func TestHealer_HealActionDeathsNotCounted(t *testing.T) {
+	engine, dc := newFakeEngine(t)
+	sent := &countNotifier{}
+	h := &Healer{
+		docker:     dc,
+		dispatcher: notify.NewDispatcher(sent),
+		updater:    &Updater{deploying: make(map[string]time.Time)},
+		metrics:    NewMetrics(),
+		deaths:     make(map[string][]time.Time),
+		healing:    make(map[string]time.Time),
+		oom:        make(map[string]time.Time),
+		degraded:   make(map[string]bool),
+		startedAt:  time.Now().Add(-time.Hour),
+	}
+	svc := &config.Service{Name: "shop", CrashLoopDeaths: 3, CrashLoopWindow: 60}
+
+	// A project restart stops every container of the project at once.
+	h.setHealing("shop", true)
+	for _, id := range []string{"web", "worker", "db"} {
+		h.handleDied(context.Background(), svc, id, id, "0")
+	}
+	h.setHealing("shop", false)
+	h.handleDied(context.Background(), svc, "web", "web", "143") // trailing event
+	if len(h.deaths["shop"]) != 0 || sent.n != 0 {
+		t.Fatalf("want heal action deaths ignored, got %d deaths and %d alerts", len(h.deaths["shop"]), sent.n)
+	}
+
+	h.healing["shop"] = time.Now().Add(-time.Second) // slack over
+	engine.addContainer(&fakeContainer{ID: "web", Name: "web", State: "exited"})
+	h.handleDied(context.Background(), svc, "web", "web", "1")
+	if len(h.deaths["shop"]) != 1 || sent.n != 1 {
+		t.Errorf("want a later death counted and reported, got %d deaths and %d alerts", len(h.deaths["shop"]), sent.n)
+	}
+}
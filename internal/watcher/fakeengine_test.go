@@ -0,0 +1,297 @@
+package watcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/studiowebux/dockward/internal/docker"
+)
+
+// fakeContainer is a container of fakeEngine.
+type fakeContainer struct {
+	ID      string
+	Name    string
+	Image   string // reference the container was created from
+	ImageID string
+	Labels  map[string]string
+	State   string // running, exited, ...
+	Health  string // healthcheck status; empty = no healthcheck
+}
+
+// fakeEngine serves the subset of the Docker Engine API the updater uses,
+// backed by in-memory containers and images.
+type fakeEngine struct {
+	mu         sync.Mutex
+	containers []*fakeContainer
+	images     map[string]docker.ImageInspect // by reference and by ID
+	tags       []string                       // "<src> <repo>:<tag>" per tag request
+	pulls      []string
+	remote     map[string]docker.ImageInspect // what a pull of a reference fetches
+	execs      map[string]string              // exec ID -> container ID
+	// probeExit is the exit code of an exec in a container; default 0.
+	probeExit map[string]int
+	// recreate simulates compose recreating a removed container; nil
+	// leaves it removed.
+	recreate func(removed fakeContainer) *fakeContainer
+	// created counts the containers created through the API; they are
+	// named created-1, created-2, ...
+	created int
+	// failCreate fails container creation; failStart fails starting the
+	// containers with these IDs.
+	failCreate bool
+	failStart  map[string]bool
+}
+
+var apiVersionPrefix = regexp.MustCompile(`^/v[0-9.]+`)
+
+// newFakeEngine starts a fake engine and returns a client connected to it.
+func newFakeEngine(t *testing.T) (*fakeEngine, *docker.Client) {
+	t.Helper()
+	f := &fakeEngine{
+		images:    make(map[string]docker.ImageInspect),
+		remote:    make(map[string]docker.ImageInspect),
+		execs:     make(map[string]string),
+		probeExit: make(map[string]int),
+		failStart: make(map[string]bool),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(srv.Close)
+	dc, err := docker.NewClient(docker.Options{Host: "tcp://" + srv.Listener.Addr().String()})
+	if err != nil {
+		t.Fatalf("docker client: %v", err)
+	}
+	return f, dc
+}
+
+// addImage registers an image under its ID and every reference in refs.
+func (f *fakeEngine) addImage(img docker.ImageInspect, refs ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.images[img.ID] = img
+	for _, ref := range refs {
+		f.images[ref] = img
+	}
+}
+
+func (f *fakeEngine) addContainer(c *fakeContainer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.containers = append(f.containers, c)
+}
+
+func (f *fakeEngine) find(idOrName string) *fakeContainer {
+	for _, c := range f.containers {
+		if c.ID == idOrName || c.Name == idOrName {
+			return c
+		}
+	}
+	return nil
+}
+
+func (f *fakeEngine) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := apiVersionPrefix.ReplaceAllString(r.URL.Path, "")
+
+	switch {
+	case r.Method == http.MethodGet && path == "/containers/json":
+		f.list(w, r)
+	case r.Method == http.MethodPost && path == "/containers/create":
+		f.create(w, r)
+	case strings.HasPrefix(path, "/containers/"):
+		f.container(w, r, strings.TrimPrefix(path, "/containers/"))
+	case strings.HasPrefix(path, "/exec/"):
+		id, action, _ := strings.Cut(strings.TrimPrefix(path, "/exec/"), "/")
+		if action == "json" {
+			json.NewEncoder(w).Encode(map[string]any{"ExitCode": f.probeExit[f.execs[id]], "Running": false})
+		}
+	case r.Method == http.MethodPost && path == "/images/create":
+		ref := r.URL.Query().Get("fromImage") + ":" + r.URL.Query().Get("tag")
+		f.pulls = append(f.pulls, ref)
+		if img, ok := f.remote[ref]; ok {
+			f.images[img.ID] = img
+			f.images[ref] = img
+		}
+	case strings.HasPrefix(path, "/images/"):
+		f.image(w, r, strings.TrimPrefix(path, "/images/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeEngine) list(w http.ResponseWriter, r *http.Request) {
+	var filters map[string][]string
+	if raw := r.URL.Query().Get("filters"); raw != "" {
+		json.Unmarshal([]byte(raw), &filters)
+	}
+	out := []docker.Container{}
+	for _, c := range f.containers {
+		match := true
+		for _, label := range filters["label"] {
+			k, v, _ := strings.Cut(label, "=")
+			match = match && c.Labels[k] == v
+		}
+		for _, name := range filters["name"] {
+			match = match && strings.Contains(c.Name, name)
+		}
+		if match {
+			out = append(out, docker.Container{ID: c.ID, Names: []string{"/" + c.Name}, Image: c.Image, Labels: c.Labels, State: c.State})
+		}
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func (f *fakeEngine) container(w http.ResponseWriter, r *http.Request, rest string) {
+	idOrName, action, _ := strings.Cut(rest, "/")
+	c := f.find(idOrName)
+	if c == nil {
+		http.Error(w, `{"message":"no such container"}`, http.StatusNotFound)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && action == "json":
+		info := docker.ContainerInspect{
+			ID:     c.ID,
+			Name:   "/" + c.Name,
+			Image:  c.ImageID,
+			State:  docker.ContainerState{Status: c.State, Running: c.State == "running"},
+			Config: docker.ContainerConfig{Image: c.Image, Labels: c.Labels},
+		}
+		if c.Health != "" {
+			info.State.Health = &docker.HealthState{Status: c.Health}
+		}
+		json.NewEncoder(w).Encode(info)
+	case action == "stop":
+		c.State = "exited"
+	case action == "start":
+		if f.failStart[c.ID] {
+			http.Error(w, `{"message":"cannot start container"}`, http.StatusInternalServerError)
+			return
+		}
+		c.State = "running"
+	case action == "rename":
+		name := r.URL.Query().Get("name")
+		if other := f.find(name); other != nil && other != c {
+			http.Error(w, `{"message":"name already in use"}`, http.StatusConflict)
+			return
+		}
+		c.Name = name
+	case action == "exec":
+		id := fmt.Sprintf("exec-%d", len(f.execs))
+		f.execs[id] = c.ID
+		json.NewEncoder(w).Encode(map[string]string{"Id": id})
+	case r.Method == http.MethodDelete:
+		for i, other := range f.containers {
+			if other == c {
+				f.containers = append(f.containers[:i], f.containers[i+1:]...)
+				break
+			}
+		}
+		if f.recreate != nil {
+			if n := f.recreate(*c); n != nil {
+				f.containers = append(f.containers, n)
+			}
+		}
+	}
+}
+
+// create creates a container from the Image and Labels of a create body.
+func (f *fakeEngine) create(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if f.failCreate {
+		http.Error(w, `{"message":"cannot create container"}`, http.StatusInternalServerError)
+		return
+	}
+	if f.find(name) != nil {
+		http.Error(w, `{"message":"name already in use"}`, http.StatusConflict)
+		return
+	}
+	var spec struct {
+		Image  string
+		Labels map[string]string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		http.Error(w, `{"message":"bad create body"}`, http.StatusBadRequest)
+		return
+	}
+	f.created++
+	c := &fakeContainer{
+		ID:      fmt.Sprintf("created-%d", f.created),
+		Name:    name,
+		Image:   spec.Image,
+		ImageID: f.images[spec.Image].ID,
+		Labels:  spec.Labels,
+		State:   "created",
+	}
+	f.containers = append(f.containers, c)
+	json.NewEncoder(w).Encode(map[string]string{"Id": c.ID})
+}
+
+func (f *fakeEngine) image(w http.ResponseWriter, r *http.Request, rest string) {
+	switch {
+	case r.Method == http.MethodGet && strings.HasSuffix(rest, "/json"):
+		img, ok := f.images[strings.TrimSuffix(rest, "/json")]
+		if !ok {
+			http.Error(w, `{"message":"no such image"}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(img)
+	case r.Method == http.MethodPost && strings.HasSuffix(rest, "/tag"):
+		src := strings.TrimSuffix(rest, "/tag")
+		ref := r.URL.Query().Get("repo") + ":" + r.URL.Query().Get("tag")
+		img, ok := f.images[src]
+		if !ok {
+			http.Error(w, `{"message":"no such image"}`, http.StatusNotFound)
+			return
+		}
+		f.images[ref] = img
+		f.tags = append(f.tags, src+" "+ref)
+	case r.Method == http.MethodDelete:
+		ref, _ := url.PathUnescape(rest)
+		delete(f.images, ref)
+	}
+}
+
+// ref returns the ID of the image a reference points at.
+func (f *fakeEngine) ref(ref string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.images[ref].ID
+}
+
+// get returns a copy of the container with ID or name idOrName, or nil.
+func (f *fakeEngine) get(idOrName string) *fakeContainer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c := f.find(idOrName); c != nil {
+		cp := *c
+		return &cp
+	}
+	return nil
+}
+
+// fakeComposeCLI puts a docker executable that accepts every command first on
+// PATH, so compose calls succeed without a runtime. Returns a compose file
+// path for the service under test.
+func fakeComposeCLI(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte("#!/bin/sh\nexit 0\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	file := filepath.Join(dir, "compose.yml")
+	if err := os.WriteFile(file, []byte("services: {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
@@ -9,9 +9,12 @@ import (
 	"time"
 
 	"github.com/studiowebux/dockward/internal/audit"
+	"github.com/studiowebux/dockward/internal/compose"
 	"github.com/studiowebux/dockward/internal/config"
 	"github.com/studiowebux/dockward/internal/docker"
 	"github.com/studiowebux/dockward/internal/notify"
+	"github.com/studiowebux/dockward/internal/saferun"
+	"github.com/studiowebux/dockward/internal/state"
 )
 
 // verboseMode enables debug-level log output. Set via SetVerbose in main.
@@ -35,6 +38,7 @@ type Healer struct {
 	updater    *Updater
 	metrics    *Metrics
 	audit      *audit.Logger
+	state      *state.Store // persists restartCounts and exhausted
 
 	// cooldowns tracks when each container can next be auto-restarted.
 	cooldowns   map[string]time.Time
@@ -45,44 +49,134 @@ type Healer struct {
 	degraded   map[string]bool
 	degradedMu sync.Mutex
 
-	// restartCounts tracks consecutive failed restart attempts per service.
+	// restartCounts tracks consecutive failed heal attempts per service.
 	// Keyed by service name. Reset when a healthy event is received.
+	// Persisted so a dockward restart does not reset the restart budget.
 	restartCounts   map[string]int
 	restartCountsMu sync.Mutex
 
-	// exhausted tracks services where max restarts have been reached.
+	// exhausted tracks services whose heal escalation ladder has run out.
 	// Suppresses repeated log messages for subsequent unhealthy events.
 	// Cleared when a healthy event is received.
 	exhausted   map[string]bool
 	exhaustedMu sync.Mutex
 
+	// probes tracks consecutive probe failures per service (services with a
+	// probe configured). Reset on the first passing probe.
+	probes   map[string]*probeState
+	probesMu sync.Mutex
+
+	// deaths records recent container deaths per service for crash-loop
+	// detection. Entries older than crash_loop_window are pruned.
+	deaths   map[string][]time.Time
+	deathsMu sync.Mutex
+
+	// healing records, per service, a heal action in progress (zero time)
+	// or the end of the slack after one. Die events it causes do not count
+	// as deaths.
+	healing   map[string]time.Time
+	healingMu sync.Mutex
+
+	// oom records oom events per container ID until the die event that
+	// follows them.
+	oom   map[string]time.Time
+	oomMu sync.Mutex
+
+	// seenDeaths records when a death was last handled per container ID, so
+	// reconciliation only replays the deaths the event stream missed.
+	seenDeaths   map[string]time.Time
+	seenDeathsMu sync.Mutex
+
+	// present tracks services whose containers were found by the last
+	// reconciliation. A service that loses all its containers is reported once.
+	present   map[string]bool
+	presentMu sync.Mutex
+
 	// startedAt records when the healer started. Die events within the
 	// startup grace period (15s) are suppressed to avoid false alarms
 	// from stale Docker events received right after dockward restarts.
 	startedAt time.Time
 }
 
-// NewHealer creates a health monitor.
-func NewHealer(cfg *config.Config, dc *docker.Client, dispatcher *notify.Dispatcher, updater *Updater, metrics *Metrics, al *audit.Logger) *Healer {
-	return &Healer{
+// NewHealer creates a health monitor. Persisted restart counters in st are
+// restored immediately; st may be nil.
+func NewHealer(cfg *config.Config, dc *docker.Client, dispatcher *notify.Dispatcher, updater *Updater, metrics *Metrics, al *audit.Logger, st *state.Store) *Healer {
+	h := &Healer{
 		cfg:           cfg,
 		docker:        dc,
 		dispatcher:    dispatcher,
 		updater:       updater,
 		metrics:       metrics,
 		audit:         al,
+		state:         st,
 		cooldowns:     make(map[string]time.Time),
 		degraded:      make(map[string]bool),
 		restartCounts: make(map[string]int),
 		exhausted:     make(map[string]bool),
+		probes:        make(map[string]*probeState),
+		deaths:        make(map[string][]time.Time),
+		healing:       make(map[string]time.Time),
+		oom:           make(map[string]time.Time),
+		seenDeaths:    make(map[string]time.Time),
+		present:       make(map[string]bool),
 		startedAt:     time.Now(),
 	}
+	snap := st.Snapshot()
+	for k, v := range snap.RestartCounts {
+		h.restartCounts[k] = v
+	}
+	for k, v := range snap.Exhausted {
+		h.exhausted[k] = v
+	}
+	return h
+}
+
+// persist writes restartCounts and exhausted to the state store.
+func (h *Healer) persist() {
+	h.restartCountsMu.Lock()
+	counts := make(map[string]int, len(h.restartCounts))
+	for k, v := range h.restartCounts {
+		counts[k] = v
+	}
+	h.restartCountsMu.Unlock()
+
+	h.exhaustedMu.Lock()
+	exhausted := make(map[string]bool, len(h.exhausted))
+	for k, v := range h.exhausted {
+		exhausted[k] = v
+	}
+	h.exhaustedMu.Unlock()
+
+	if err := h.state.Update(func(s *state.State) {
+		s.RestartCounts = counts
+		s.Exhausted = exhausted
+	}); err != nil {
+		logger.Printf("[healer] ERROR: state write error: %v", err)
+	}
+}
+
+// resetRestarts clears the restart counter and exhausted flag for a service
+// and persists the change if anything was set.
+func (h *Healer) resetRestarts(service string) {
+	h.restartCountsMu.Lock()
+	_, hadCount := h.restartCounts[service]
+	delete(h.restartCounts, service)
+	h.restartCountsMu.Unlock()
+	h.exhaustedMu.Lock()
+	_, wasExhausted := h.exhausted[service]
+	delete(h.exhausted, service)
+	h.exhaustedMu.Unlock()
+	if hadCount || wasExhausted {
+		h.persist()
+	}
 }
 
 // Run starts the Docker event stream listener. Blocks until ctx is cancelled.
 func (h *Healer) Run(ctx context.Context) {
 	logger.Printf("[healer] listening for Docker health events")
 	h.seedHealthFromInspect(ctx)
+	saferun.Go("healer-probes", func() { h.runProbes(ctx) })
+	saferun.Go("healer-reconcile", func() { h.runReconcile(ctx) })
 	h.docker.StreamEvents(ctx, func(event docker.Event) {
 		h.handleEvent(ctx, event)
 	})
@@ -129,8 +223,12 @@ func (h *Healer) handleEvent(ctx context.Context, event docker.Event) {
 	case strings.HasPrefix(event.Action, "health_status: healthy"):
 		h.handleHealthy(ctx, &svc, containerName)
 
+	case event.Action == "oom":
+		h.markOOM(containerID)
+
 	case event.Action == "die":
-		h.handleDied(ctx, &svc, containerName)
+		h.noteDeath(containerID, event.At())
+		h.handleDied(ctx, &svc, containerName, containerID, event.Actor.Attributes["exitCode"])
 
 	case event.Action == "start":
 		h.handleStarted(ctx, &svc, containerName, containerID)
@@ -150,18 +248,26 @@ func (h *Healer) handleUnhealthy(ctx context.Context, svc *config.Service, conta
 	if err == nil {
 		reason = info.LastHealthOutput()
 	}
+	h.heal(ctx, svc, containerName, containerID, reason)
+}
 
+// heal handles an unhealthy container, from a Docker health event or a
+// failing probe: notify, or run the next step of the service's heal
+// escalation ladder, within the step's cooldown, when auto_heal is enabled.
+func (h *Healer) heal(ctx context.Context, svc *config.Service, containerName, containerID, reason string) {
 	logger.Printf("[healer] %s: unhealthy. Reason: %s", svc.Name, reason)
 	h.metrics.SetHealthy(svc.Name, false)
 	h.setDegraded(svc.Name, true)
 
 	if !svc.AutoHeal {
+		logs := captureLogs(ctx, h.docker, *svc, containerID)
 		h.dispatcher.Send(ctx, notify.Alert{
 			Service:   svc.Name,
 			Event:     "unhealthy",
 			Message:   "Container is unhealthy.",
 			Reason:    reason,
 			Container: containerName,
+			Logs:      logs,
 			Level:     notify.LevelWarning,
 		})
 		if err := h.audit.Write(audit.Entry{
@@ -171,52 +277,77 @@ func (h *Healer) handleUnhealthy(ctx context.Context, svc *config.Service, conta
 			Level:     "warning",
 			Container: containerName,
 			Reason:    reason,
+			Logs:      logs,
 		}); err != nil {
 			logger.Printf("[healer] %s: audit write error: %v", svc.Name, err)
 		}
 		return
 	}
 
-	// Check if max consecutive restarts exceeded.
+	// The consecutive failed attempts pick the step of the escalation ladder.
+	ladder := svc.HealLadder()
 	h.restartCountsMu.Lock()
 	count := h.restartCounts[svc.Name]
 	h.restartCountsMu.Unlock()
-	if count >= svc.HealMaxRestarts {
-		h.exhaustedMu.Lock()
-		alreadyExhausted := h.exhausted[svc.Name]
-		h.exhausted[svc.Name] = true
-		h.exhaustedMu.Unlock()
-		if !alreadyExhausted {
-			logger.Printf("[healer] %s: max restarts (%d) reached, manual intervention required", svc.Name, svc.HealMaxRestarts)
-		}
+	step, stepNum, ok := healStep(ladder, count)
+	if !ok {
+		h.giveUp(ctx, svc, containerName, count, reason)
 		return
 	}
+	action := healActions[step.Action]
 
 	// Check cooldown.
 	if h.inCooldown(containerName) {
-		debugf("[healer] %s: in cooldown, skipping restart", svc.Name)
+		debugf("[healer] %s: in cooldown, skipping %s", svc.Name, action.noun)
 		return
 	}
 
-	// Restart the container.
-	logger.Printf("[healer] %s: restarting", svc.Name)
+	// pre_heal hooks run before the heal action; a failure does not prevent it.
+	var hookOut string
+	if len(svc.PreHeal) > 0 {
+		env := append(hookEnv(*svc, hookPreHeal, nil), "DOCKWARD_CONTAINER="+containerName)
+		var failure string
+		hookOut, failure = runHooks(ctx, h.cfg.Runtime, *svc, "pre_heal hook", svc.PreHeal, env)
+		if failure != "" {
+			logger.Printf("[healer] %s: %s, healing anyway", svc.Name, failure)
+		}
+	}
+
+	message := action.doing + "."
+	if len(ladder) > 1 {
+		message = fmt.Sprintf("%s (escalation step %d/%d).", action.doing, stepNum, len(ladder))
+	}
+	logger.Printf("[healer] %s: %s (step %d/%d)", svc.Name, action.event, stepNum, len(ladder))
 	if err := h.audit.Write(audit.Entry{
 		Service:   svc.Name,
-		Event:     "restarting",
-		Message:   "Restarting unhealthy container.",
+		Event:     action.event,
+		Message:   message,
 		Level:     "warning",
 		Container: containerName,
 		Reason:    reason,
+		Output:    hookOut,
+		Logs:      captureLogs(ctx, h.docker, *svc, containerID),
 	}); err != nil {
 		logger.Printf("[healer] %s: audit write error: %v", svc.Name, err)
 	}
-	if err := h.docker.RestartContainer(ctx, containerID, 10); err != nil {
-		logger.Printf("[healer] %s: restart failed: %v", svc.Name, err)
+
+	// Set the step's cooldown, whether or not the action succeeds. It lasts
+	// at least until the action is verified, so the next step cannot start
+	// before this one is counted.
+	h.cooldownsMu.Lock()
+	h.cooldowns[containerName] = time.Now().Add(max(time.Duration(step.Cooldown)*time.Second, healVerifyDelay))
+	h.cooldownsMu.Unlock()
+
+	h.setHealing(svc.Name, true)
+	out, err := h.runHealStep(ctx, svc, step, containerID)
+	h.setHealing(svc.Name, false)
+	if err != nil {
+		logger.Printf("[healer] %s: %s failed: %v", svc.Name, action.noun, err)
 		h.metrics.IncFailures(svc.Name)
 		h.dispatcher.Send(ctx, notify.Alert{
 			Service:   svc.Name,
 			Event:     "critical",
-			Message:   "Failed to restart unhealthy container.",
+			Message:   action.failed + ".",
 			Reason:    reason,
 			Container: containerName,
 			Level:     notify.LevelCritical,
@@ -224,88 +355,211 @@ func (h *Healer) handleUnhealthy(ctx context.Context, svc *config.Service, conta
 		if werr := h.audit.Write(audit.Entry{
 			Service:   svc.Name,
 			Event:     "restart_failed",
-			Message:   fmt.Sprintf("Failed to restart unhealthy container: %v", err),
+			Message:   fmt.Sprintf("%s: %v", action.failed, err),
 			Level:     "critical",
 			Container: containerName,
 			Reason:    reason,
+			Output:    out,
 		}); werr != nil {
 			logger.Printf("[healer] %s: audit write error: %v", svc.Name, werr)
 		}
+		if count := h.failedAttempt(svc.Name); count >= healAttempts(ladder) {
+			h.giveUp(ctx, svc, containerName, count, err.Error())
+		}
 		return
 	}
 
-	// Set cooldown.
-	cooldown := time.Duration(svc.HealCooldown) * time.Second
-	h.cooldownsMu.Lock()
-	h.cooldowns[containerName] = time.Now().Add(cooldown)
-	h.cooldownsMu.Unlock()
+	// Wait briefly, then check if the action fixed it.
+	go h.verifyAfterRestart(ctx, svc, step, containerName, containerID, reason)
+}
+
+// healVerifyDelay is how long after a heal action the container is checked.
+var healVerifyDelay = 30 * time.Second
+
+// healAction names an escalation action in logs, events and messages.
+type healAction struct {
+	event   string // audit event written before the action
+	success string // event once the service recovered
+	noun    string
+	doing   string
+	done    string
+	failed  string
+}
+
+var healActions = map[string]healAction{
+	config.HealRestart: {
+		event:   "restarting",
+		success: "restarted",
+		noun:    "restart",
+		doing:   "Restarting unhealthy container",
+		done:    "Restarted unhealthy container successfully",
+		failed:  "Failed to restart unhealthy container",
+	},
+	config.HealRecreate: {
+		event:   "recreating",
+		success: "recreated",
+		noun:    "recreate",
+		doing:   "Recreating unhealthy container",
+		done:    "Recreated unhealthy container successfully",
+		failed:  "Failed to recreate unhealthy container",
+	},
+	config.HealProjectRestart: {
+		event:   "project_restarting",
+		success: "project_restarted",
+		noun:    "project restart",
+		doing:   "Restarting the compose project of unhealthy container",
+		done:    "Restarted the compose project successfully",
+		failed:  "Failed to restart the compose project",
+	},
+}
+
+// healStep returns the ladder step to run after the given number of
+// consecutive failed attempts, and its 1-based position. Returns false once every step has used
+// its attempts.
+func healStep(ladder []config.HealStep, failed int) (config.HealStep, int, bool) {
+	for i, st := range ladder {
+		if failed < st.Attempts {
+			return st, i + 1, true
+		}
+		failed -= st.Attempts
+	}
+	return config.HealStep{}, 0, false
+}
+
+// healAttempts returns the total number of attempts of a ladder.
+func healAttempts(ladder []config.HealStep) int {
+	n := 0
+	for _, st := range ladder {
+		n += st.Attempts
+	}
+	return n
+}
 
-	// Wait briefly, then check if restart fixed it.
-	go h.verifyAfterRestart(ctx, svc, containerName, containerID, reason)
+// runHealStep performs one escalation action. Compose actions run in the
+// service's compose project; recreate targets the compose service of the
+// unhealthy container.
+func (h *Healer) runHealStep(ctx context.Context, svc *config.Service, step config.HealStep, containerID string) (string, error) {
+	switch step.Action {
+	case config.HealRecreate:
+		info, err := h.docker.InspectContainer(ctx, containerID)
+		if err != nil {
+			return "", fmt.Errorf("inspect: %w", err)
+		}
+		service := info.Config.Labels["com.docker.compose.service"]
+		if service == "" {
+			return "", fmt.Errorf("container has no com.docker.compose.service label")
+		}
+		return compose.Recreate(ctx, h.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile, service, h.updater.composeEnv(*svc, nil)...)
+	case config.HealProjectRestart:
+		return compose.Restart(ctx, h.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile, h.updater.composeEnv(*svc, nil)...)
+	default:
+		return "", h.docker.RestartContainer(ctx, containerID, 10)
+	}
 }
 
-func (h *Healer) verifyAfterRestart(ctx context.Context, svc *config.Service, containerName, containerID, reason string) {
+// failedAttempt counts a heal attempt that did not recover the service and
+// returns the consecutive failed attempts.
+func (h *Healer) failedAttempt(service string) int {
+	h.restartCountsMu.Lock()
+	h.restartCounts[service]++
+	count := h.restartCounts[service]
+	h.restartCountsMu.Unlock()
+	h.persist()
+	return count
+}
+
+// giveUp marks a service exhausted once its escalation ladder has run out.
+// The critical notification is sent only the first time.
+func (h *Healer) giveUp(ctx context.Context, svc *config.Service, containerName string, count int, why string) {
+	h.exhaustedMu.Lock()
+	alreadyExhausted := h.exhausted[svc.Name]
+	h.exhausted[svc.Name] = true
+	h.exhaustedMu.Unlock()
+	if alreadyExhausted {
+		return
+	}
+	h.persist()
+
+	logger.Printf("[healer] %s: giving up after %d failed heal attempts, manual intervention required", svc.Name, count)
+	message := fmt.Sprintf("Giving up after %d failed heal attempts. Manual intervention required.", count)
+	h.dispatcher.Send(ctx, notify.Alert{
+		Service:   svc.Name,
+		Event:     "critical",
+		Message:   message,
+		Reason:    why,
+		Container: containerName,
+		Level:     notify.LevelCritical,
+	})
+	if err := h.audit.Write(audit.Entry{
+		Service:   svc.Name,
+		Event:     "critical",
+		Message:   message,
+		Level:     "critical",
+		Container: containerName,
+		Reason:    why,
+	}); err != nil {
+		logger.Printf("[healer] %s: audit write error: %v", svc.Name, err)
+	}
+}
+
+func (h *Healer) verifyAfterRestart(ctx context.Context, svc *config.Service, step config.HealStep, containerName, containerID, reason string) {
 	select {
 	case <-ctx.Done():
 		return
-	case <-time.After(30 * time.Second):
+	case <-time.After(healVerifyDelay):
 	}
 
-	info, err := h.docker.InspectContainer(ctx, containerID)
+	action := healActions[step.Action]
+
+	// Compose actions replace the container: look it up by name.
+	ref := containerID
+	if step.Action != config.HealRestart {
+		ref = containerName
+	}
+	info, err := h.docker.InspectContainer(ctx, ref)
 	if err != nil {
-		logger.Printf("[healer] %s: could not verify after restart: %v", svc.Name, err)
+		logger.Printf("[healer] %s: could not verify after %s: %v", svc.Name, action.noun, err)
 		return
 	}
 
-	if info.State.Health != nil && info.State.Health.Status == "unhealthy" {
-		logger.Printf("[healer] %s: still unhealthy after restart", svc.Name)
+	unhealthy := info.State.Health != nil && info.State.Health.Status == "unhealthy"
+	why := info.LastHealthOutput()
+	if svc.Probe != nil {
+		perr := runProbe(ctx, h.docker, *svc, info.ID)
+		unhealthy = perr != nil
+		if perr != nil {
+			why = "probe: " + perr.Error()
+		}
+	}
+
+	if unhealthy {
+		logger.Printf("[healer] %s: still unhealthy after %s", svc.Name, action.noun)
 		h.metrics.IncFailures(svc.Name)
 
-		// Increment consecutive failure counter.
-		h.restartCountsMu.Lock()
-		h.restartCounts[svc.Name]++
-		count := h.restartCounts[svc.Name]
-		h.restartCountsMu.Unlock()
-
-		if count >= svc.HealMaxRestarts {
-			logger.Printf("[healer] %s: giving up after %d consecutive failed restarts", svc.Name, count)
-			h.dispatcher.Send(ctx, notify.Alert{
-				Service:   svc.Name,
-				Event:     "critical",
-				Message:   fmt.Sprintf("Giving up after %d consecutive failed restarts. Manual intervention required.", count),
-				Reason:    info.LastHealthOutput(),
-				Container: containerName,
-				Level:     notify.LevelCritical,
-			})
-			if err := h.audit.Write(audit.Entry{
-				Service:   svc.Name,
-				Event:     "critical",
-				Message:   fmt.Sprintf("Giving up after %d consecutive failed restarts. Manual intervention required.", count),
-				Level:     "critical",
-				Container: containerName,
-				Reason:    info.LastHealthOutput(),
-			}); err != nil {
-				logger.Printf("[healer] %s: audit write error: %v", svc.Name, err)
-			}
-		} else {
-			h.dispatcher.Send(ctx, notify.Alert{
-				Service:   svc.Name,
-				Event:     "critical",
-				Message:   fmt.Sprintf("Container still unhealthy after restart (attempt %d/%d).", count, svc.HealMaxRestarts),
-				Reason:    info.LastHealthOutput(),
-				Container: containerName,
-				Level:     notify.LevelCritical,
-			})
-			if werr := h.audit.Write(audit.Entry{
-				Service:   svc.Name,
-				Event:     "critical",
-				Message:   fmt.Sprintf("Container still unhealthy after restart (attempt %d/%d).", count, svc.HealMaxRestarts),
-				Level:     "critical",
-				Container: containerName,
-				Reason:    info.LastHealthOutput(),
-			}); werr != nil {
-				logger.Printf("[healer] %s: audit write error: %v", svc.Name, werr)
-			}
+		total := healAttempts(svc.HealLadder())
+		count := h.failedAttempt(svc.Name)
+		if count >= total {
+			h.giveUp(ctx, svc, containerName, count, why)
+			return
+		}
+		message := fmt.Sprintf("Container still unhealthy after %s (attempt %d/%d).", action.noun, count, total)
+		h.dispatcher.Send(ctx, notify.Alert{
+			Service:   svc.Name,
+			Event:     "critical",
+			Message:   message,
+			Reason:    why,
+			Container: containerName,
+			Level:     notify.LevelCritical,
+		})
+		if werr := h.audit.Write(audit.Entry{
+			Service:   svc.Name,
+			Event:     "critical",
+			Message:   message,
+			Level:     "critical",
+			Container: containerName,
+			Reason:    why,
+		}); werr != nil {
+			logger.Printf("[healer] %s: audit write error: %v", svc.Name, werr)
 		}
 		return
 	}
@@ -321,24 +575,19 @@ func (h *Healer) verifyAfterRestart(ctx context.Context, svc *config.Service, co
 
 	h.metrics.SetHealthy(svc.Name, true)
 	h.setDegraded(svc.Name, false)
-	h.restartCountsMu.Lock()
-	delete(h.restartCounts, svc.Name)
-	h.restartCountsMu.Unlock()
-	h.exhaustedMu.Lock()
-	delete(h.exhausted, svc.Name)
-	h.exhaustedMu.Unlock()
+	h.resetRestarts(svc.Name)
 	h.dispatcher.Send(ctx, notify.Alert{
 		Service:   svc.Name,
-		Event:     "restarted",
-		Message:   "Restarted unhealthy container successfully.",
+		Event:     action.success,
+		Message:   action.done + ".",
 		Reason:    reason,
 		Container: containerName,
 		Level:     notify.LevelWarning,
 	})
 	if err := h.audit.Write(audit.Entry{
 		Service:   svc.Name,
-		Event:     "restarted",
-		Message:   "Restarted unhealthy container successfully.",
+		Event:     action.success,
+		Message:   action.done + ".",
 		Level:     "info",
 		Container: containerName,
 		Reason:    reason,
@@ -360,12 +609,7 @@ func (h *Healer) handleHealthy(ctx context.Context, svc *config.Service, contain
 	// This ensures the flag doesn't get stuck when IsDeploying races with a die→recover cycle.
 	if wasDegraded {
 		h.setDegraded(svc.Name, false)
-		h.restartCountsMu.Lock()
-		delete(h.restartCounts, svc.Name)
-		h.restartCountsMu.Unlock()
-		h.exhaustedMu.Lock()
-		delete(h.exhausted, svc.Name)
-		h.exhaustedMu.Unlock()
+		h.resetRestarts(svc.Name)
 	}
 
 	// Skip notification if the updater is mid-deploy — it sends its own success alert.
@@ -398,7 +642,7 @@ func (h *Healer) handleHealthy(ctx context.Context, svc *config.Service, contain
 	}
 }
 
-func (h *Healer) handleDied(ctx context.Context, svc *config.Service, containerName string) {
+func (h *Healer) handleDied(ctx context.Context, svc *config.Service, containerName, containerID, exitCode string) {
 	// Suppress die events during startup grace period (15s) to avoid
 	// false alarms from stale events received right after dockward restarts.
 	if time.Since(h.startedAt) < 15*time.Second {
@@ -411,28 +655,84 @@ func (h *Healer) handleDied(ctx context.Context, svc *config.Service, containerN
 		return
 	}
 
+	// Containers stopped by our own heal action are not deaths.
+	if h.isHealing(svc.Name) {
+		debugf("[healer] %s: %s stopped by a heal action, not counted", svc.Name, containerName)
+		return
+	}
+
+	d := h.inspectDeath(ctx, containerID, exitCode)
+	h.metrics.IncDeaths(svc.Name, d.class)
+	count, interval := h.recordDeath(svc, time.Now())
+	switch {
+	case count == svc.CrashLoopDeaths:
+		h.handleCrashLoop(ctx, svc, containerName, d, count, interval, captureLogs(ctx, h.docker, *svc, containerID))
+		return
+	case count > svc.CrashLoopDeaths:
+		debugf("[healer] %s: %s during crash loop, suppressed", svc.Name, d)
+		return
+	}
+
 	// Already degraded from a previous die/unhealthy event. Suppress to
 	// avoid log and notification spam during restart loops.
 	if h.isDegraded(svc.Name) {
 		return
 	}
 
-	logger.Printf("[healer] %s: container died unexpectedly", svc.Name)
+	logger.Printf("[healer] %s: container died unexpectedly (%s)", svc.Name, d)
+	h.metrics.SetHealthy(svc.Name, false)
+	h.setDegraded(svc.Name, true)
+	event, message, level := d.alert()
+	logs := captureLogs(ctx, h.docker, *svc, containerID)
+	h.dispatcher.Send(ctx, notify.Alert{
+		Service:   svc.Name,
+		Event:     event,
+		Message:   message,
+		Reason:    d.String(),
+		Container: containerName,
+		Logs:      logs,
+		Level:     level,
+	})
+	if err := h.audit.Write(audit.Entry{
+		Service:   svc.Name,
+		Event:     event,
+		Message:   message,
+		Level:     level,
+		Container: containerName,
+		Reason:    d.String(),
+		Logs:      logs,
+	}); err != nil {
+		logger.Printf("[healer] %s: audit write error: %v", svc.Name, err)
+	}
+}
+
+// handleCrashLoop reports a service whose deaths reached crash_loop_deaths
+// within crash_loop_window. Further deaths are suppressed until the loop ends.
+func (h *Healer) handleCrashLoop(ctx context.Context, svc *config.Service, containerName string, d death, count int, interval time.Duration, logs string) {
+	every := max(interval.Round(time.Second), time.Second)
+	window := time.Duration(svc.CrashLoopWindow) * time.Second
+	message := fmt.Sprintf("Crash loop: %s, restarting every %s (%d deaths in %s).", d, every, count, window)
+	logger.Printf("[healer] %s: crash loop: %s, restarting every %s", svc.Name, d, every)
+	h.metrics.IncCrashLoops(svc.Name)
 	h.metrics.SetHealthy(svc.Name, false)
 	h.setDegraded(svc.Name, true)
 	h.dispatcher.Send(ctx, notify.Alert{
 		Service:   svc.Name,
-		Event:     "died",
-		Message:   "Container exited unexpectedly.",
+		Event:     "crash_loop",
+		Message:   message,
+		Reason:    d.String(),
 		Container: containerName,
+		Logs:      logs,
 		Level:     notify.LevelCritical,
 	})
 	if err := h.audit.Write(audit.Entry{
 		Service:   svc.Name,
-		Event:     "died",
-		Message:   "Container exited unexpectedly.",
+		Event:     "crash_loop",
+		Message:   message,
 		Level:     "critical",
 		Container: containerName,
+		Reason:    d.String(),
+		Logs:      logs,
 	}); err != nil {
 		logger.Printf("[healer] %s: audit write error: %v", svc.Name, err)
 	}
@@ -450,20 +750,21 @@ func (h *Healer) handleStarted(ctx context.Context, svc *config.Service, contain
 		logger.Printf("[healer] %s: start inspect error: %v", svc.Name, err)
 		return
 	}
-	if info.State.Health != nil {
-		return
+	if info.State.Health != nil || svc.Probe != nil {
+		return // the next healthy event or passing probe clears degraded
 	}
 
-	// No healthcheck: container is running — treat as recovered.
+	// No healthcheck: container is running — treat as recovered. A restart
+	// within a crash loop is not a recovery worth notifying.
+	if h.crashLooping(svc) {
+		h.setDegraded(svc.Name, false)
+		debugf("[healer] %s: restarted during crash loop", svc.Name)
+		return
+	}
 	logger.Printf("[healer] %s: restarted (no healthcheck), clearing degraded state", svc.Name)
 	h.metrics.SetHealthy(svc.Name, true)
 	h.setDegraded(svc.Name, false)
-	h.restartCountsMu.Lock()
-	delete(h.restartCounts, svc.Name)
-	h.restartCountsMu.Unlock()
-	h.exhaustedMu.Lock()
-	delete(h.exhausted, svc.Name)
-	h.exhaustedMu.Unlock()
+	h.resetRestarts(svc.Name)
 	h.dispatcher.Send(ctx, notify.Alert{
 		Service:   svc.Name,
 		Event:     "recovered",
@@ -512,6 +813,13 @@ func (h *Healer) inCooldown(containerName string) bool {
 	return time.Now().Before(deadline)
 }
 
+// isExhausted reports whether the service's heal escalation ladder ran out.
+func (h *Healer) isExhausted(service string) bool {
+	h.exhaustedMu.Lock()
+	defer h.exhaustedMu.Unlock()
+	return h.exhausted[service]
+}
+
 func (h *Healer) setDegraded(serviceName string, degraded bool) {
 	h.degradedMu.Lock()
 	defer h.degradedMu.Unlock()
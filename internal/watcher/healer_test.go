@@ -1,11 +1,13 @@
 package watcher
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/studiowebux/dockward/internal/config"
 	"github.com/studiowebux/dockward/internal/docker"
+	"github.com/studiowebux/dockward/internal/notify"
 )
 
 func TestHealer_CooldownPreventsDoubleRestart(t *testing.T) {
@@ -69,6 +71,63 @@ func TestHealer_MaxRestartsExhausted(t *testing.T) {
 	}
 }
 
+func TestHealStep_Escalation(t *testing.T) {
+	ladder := []config.HealStep{
+		{Action: config.HealRestart, Attempts: 2},
+		{Action: config.HealRecreate, Attempts: 1},
+		{Action: config.HealProjectRestart, Attempts: 1},
+	}
+	want := []struct {
+		action string
+		num    int
+	}{
+		{config.HealRestart, 1},
+		{config.HealRestart, 1},
+		{config.HealRecreate, 2},
+		{config.HealProjectRestart, 3},
+	}
+	for failed, w := range want {
+		st, num, ok := healStep(ladder, failed)
+		if !ok || st.Action != w.action || num != w.num {
+			t.Errorf("after %d failures: got %q step %d (ok=%v), want %q step %d", failed, st.Action, num, ok, w.action, w.num)
+		}
+	}
+	if _, _, ok := healStep(ladder, healAttempts(ladder)); ok {
+		t.Error("ladder should be exhausted after all attempts")
+	}
+	if n := healAttempts(ladder); n != 4 {
+		t.Errorf("want 4 attempts, got %d", n)
+	}
+}
+
+// countNotifier counts the alerts it receives.
+type countNotifier struct{ n int }
+
+func (c *countNotifier) Name() string                             { return "count" }
+func (c *countNotifier) Send(context.Context, notify.Alert) error { c.n++; return nil }
+
+func TestHealer_GiveUpMarksExhaustedOnce(t *testing.T) {
+	sent := &countNotifier{}
+	h := &Healer{
+		dispatcher:    notify.NewDispatcher(sent),
+		restartCounts: make(map[string]int),
+		exhausted:     make(map[string]bool),
+	}
+	svc := &config.Service{Name: "myapp"}
+
+	if n := h.failedAttempt(svc.Name); n != 1 {
+		t.Fatalf("want 1 failed attempt, got %d", n)
+	}
+	h.giveUp(context.Background(), svc, "myapp-web-1", 1, "probe failed")
+	h.giveUp(context.Background(), svc, "myapp-web-1", 1, "probe failed")
+	if !h.ExhaustedServices()[svc.Name] {
+		t.Error("service should be exhausted after giveUp")
+	}
+	if sent.n != 1 {
+		t.Errorf("want 1 critical alert, got %d", sent.n)
+	}
+}
+
 func TestHealer_DegradedStateTracking(t *testing.T) {
 	h := &Healer{
 		degraded: make(map[string]bool),
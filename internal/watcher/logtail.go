@@ -0,0 +1,80 @@
+package watcher
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/docker"
+	"github.com/studiowebux/dockward/internal/logger"
+)
+
+const (
+	logTailTimeout = 10 * time.Second // reading a container's log for one event
+	maxLogTail     = 16 << 10         // bytes of log kept per event; the oldest lines are dropped
+)
+
+// captureLogs returns the last lines of a container's log per the service's
+// log_tail, with its redact patterns applied. Returns "" when log_tail is not
+// set or the log cannot be read.
+func captureLogs(ctx context.Context, dc *docker.Client, svc config.Service, containerID string) string {
+	if svc.LogTail == nil || containerID == "" {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(ctx, logTailTimeout)
+	defer cancel()
+	out, err := dc.ContainerLogs(ctx, containerID, svc.LogTail.Lines)
+	if err != nil {
+		logger.Printf("[logs] %s: %v", svc.Name, err)
+		return ""
+	}
+	return redactLogs(out, svc.LogTail.Redact)
+}
+
+// redactLogs replaces every match of patterns with [REDACTED] and trims the
+// result to maxLogTail bytes, keeping the most recent lines.
+func redactLogs(s string, patterns []string) string {
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			continue // rejected by config validation
+		}
+		s = re.ReplaceAllString(s, "[REDACTED]")
+	}
+	s = strings.TrimRight(s, "\n")
+	if len(s) > maxLogTail {
+		s = s[len(s)-maxLogTail:]
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			s = s[i+1:]
+		}
+	}
+	return s
+}
+
+// failingContainer returns the ID and name of the first verify target that
+// is not healthy, else of the first target, for the log tail of a rollback.
+// Returns empty strings when the service has no log_tail.
+func (u *Updater) failingContainer(ctx context.Context, svc config.Service, changed []imageChange) (id, name string) {
+	if svc.LogTail == nil {
+		return "", ""
+	}
+	ids, err := u.verifyTargets(ctx, svc, changed)
+	if err != nil || len(ids) == 0 {
+		return "", ""
+	}
+	for _, cid := range ids {
+		info, err := u.docker.InspectContainer(ctx, cid)
+		if err != nil {
+			continue
+		}
+		if healthy, _, _ := containerVerdict(info); !healthy {
+			return cid, info.ContainerName()
+		}
+		if id == "" {
+			id, name = cid, info.ContainerName()
+		}
+	}
+	return id, name
+}
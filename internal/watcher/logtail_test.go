@@ -0,0 +1,27 @@
+package watcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactLogs(t *testing.T) {
+	in := "connecting to db\npassword=hunter2 user=app\nTOKEN=abc123\n"
+	got := redactLogs(in, []string{`password=\S+`, `(?i)token=\S+`})
+	want := "connecting to db\n[REDACTED] user=app\n[REDACTED]"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRedactLogs_KeepsMostRecent(t *testing.T) {
+	line := strings.Repeat("x", 99) + "\n"
+	in := strings.Repeat(line, 2*maxLogTail/len(line)) + "last line\n"
+	got := redactLogs(in, nil)
+	if len(got) > maxLogTail {
+		t.Errorf("want at most %d bytes, got %d", maxLogTail, len(got))
+	}
+	if !strings.HasSuffix(got, "last line") || !strings.HasPrefix(got, "xxx") {
+		t.Errorf("want whole lines ending with the newest, got ...%q", got[len(got)-20:])
+	}
+}
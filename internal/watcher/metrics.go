@@ -19,6 +19,8 @@ type Metrics struct {
 	failures     map[string]int64
 	cpuAlerts    map[string]int64
 	memoryAlerts map[string]int64
+	deaths       map[string]map[string]int64 // service -> death class
+	crashLoops   map[string]int64
 
 	// Gauges
 	serviceHealthy map[string]bool
@@ -45,6 +47,8 @@ func NewMetrics() *Metrics {
 		failures:     make(map[string]int64),
 		cpuAlerts:    make(map[string]int64),
 		memoryAlerts: make(map[string]int64),
+		deaths:       make(map[string]map[string]int64),
+		crashLoops:   make(map[string]int64),
 		serviceHealthy: make(map[string]bool),
 		serviceBlocked: make(map[string]bool),
 		startTime:      time.Now(),
@@ -81,6 +85,15 @@ func (m *Metrics) SeedServices(names []string) {
 		if _, ok := m.memoryAlerts[name]; !ok {
 			m.memoryAlerts[name] = 0
 		}
+		if _, ok := m.deaths[name]; !ok {
+			m.deaths[name] = make(map[string]int64, len(deathClasses))
+			for _, class := range deathClasses {
+				m.deaths[name][class] = 0
+			}
+		}
+		if _, ok := m.crashLoops[name]; !ok {
+			m.crashLoops[name] = 0
+		}
 	}
 }
 
@@ -120,6 +133,23 @@ func (m *Metrics) IncMemoryAlerts(service string) {
 	m.mu.Unlock()
 }
 
+// IncDeaths counts a container death of the service by class (oom, crash,
+// signal, clean).
+func (m *Metrics) IncDeaths(service, class string) {
+	m.mu.Lock()
+	if m.deaths[service] == nil {
+		m.deaths[service] = make(map[string]int64)
+	}
+	m.deaths[service][class]++
+	m.mu.Unlock()
+}
+
+func (m *Metrics) IncCrashLoops(service string) {
+	m.mu.Lock()
+	m.crashLoops[service]++
+	m.mu.Unlock()
+}
+
 func (m *Metrics) SetHealthy(service string, healthy bool) {
 	m.mu.Lock()
 	m.serviceHealthy[service] = healthy
@@ -317,6 +347,25 @@ func (m *Metrics) Prometheus() string {
 		fmt.Fprintf(&b, "watcher_memory_alerts_total{service=%q} %d\n", svc, m.memoryAlerts[svc])
 	}
 
+	b.WriteString("# HELP watcher_container_deaths_total Total container deaths by reason (oom, crash, signal, clean)\n")
+	b.WriteString("# TYPE watcher_container_deaths_total counter\n")
+	deathServices := make([]string, 0, len(m.deaths))
+	for svc := range m.deaths {
+		deathServices = append(deathServices, svc)
+	}
+	sort.Strings(deathServices)
+	for _, svc := range deathServices {
+		for _, class := range deathClasses {
+			fmt.Fprintf(&b, "watcher_container_deaths_total{service=%q,reason=%q} %d\n", svc, class, m.deaths[svc][class])
+		}
+	}
+
+	b.WriteString("# HELP watcher_crash_loops_total Total crash loops detected\n")
+	b.WriteString("# TYPE watcher_crash_loops_total counter\n")
+	for _, svc := range sortedKeysInt64(m.crashLoops) {
+		fmt.Fprintf(&b, "watcher_crash_loops_total{service=%q} %d\n", svc, m.crashLoops[svc])
+	}
+
 	// Docker daemon health metrics
 	b.WriteString("# HELP docker_daemon_healthy Whether Docker daemon is healthy (1) or not (0)\n")
 	b.WriteString("# TYPE docker_daemon_healthy gauge\n")
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"github.com/studiowebux/dockward/internal/logger"
 	"net/url"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -36,8 +38,10 @@ type cpuSample struct {
 	systemUsage uint64
 }
 
-// Monitor polls container resource usage and fires alerts when thresholds are exceeded.
-// Pattern: background goroutine, interval = poll_interval, cooldown = heal_cooldown.
+// Monitor polls container resource usage and fires alerts when thresholds are
+// exceeded for threshold_samples samples or threshold_duration, and recovery
+// notifications once usage falls threshold_hysteresis points below them.
+// Pattern: background goroutine, interval = stats_interval.
 type Monitor struct {
 	cfg        *config.Config
 	docker     *docker.Client
@@ -59,9 +63,19 @@ type Monitor struct {
 	prevCPU   map[string]cpuSample
 	prevCPUMu sync.Mutex
 
-	// alertedAt tracks the last alert time per "service:metric" key to prevent spam.
-	alertedAt   map[string]time.Time
-	alertedAtMu sync.Mutex
+	// alerts holds the resource alert state per "containerID:metric" key.
+	alerts   map[string]*resourceAlert
+	alertsMu sync.Mutex
+}
+
+// ResourceAlertStatus is an active resource alert of one container, for the
+// status API.
+type ResourceAlertStatus struct {
+	Container string    `json:"container"` // short container ID
+	Metric    string    `json:"metric"`    // cpu or memory
+	Level     string    `json:"level"`     // warning or critical
+	Percent   float64   `json:"percent"`   // last sample
+	Since     time.Time `json:"since"`
 }
 
 // NewMonitor creates a resource monitor.
@@ -75,7 +89,7 @@ func NewMonitor(cfg *config.Config, dc *docker.Client, dispatcher *notify.Dispat
 		latest:         make(map[string]ServiceStats),
 		containerStats: make(map[string]ContainerStats),
 		prevCPU:        make(map[string]cpuSample),
-		alertedAt:      make(map[string]time.Time),
+		alerts:         make(map[string]*resourceAlert),
 	}
 }
 
@@ -124,16 +138,51 @@ func (m *Monitor) ContainerStatsSnapshot() map[string]ContainerStats {
 	return result
 }
 
+// ResourceAlerts returns the active resource alerts per service, ordered by
+// container and metric.
+func (m *Monitor) ResourceAlerts() map[string][]ResourceAlertStatus {
+	m.alertsMu.Lock()
+	defer m.alertsMu.Unlock()
+	keys := make([]string, 0, len(m.alerts))
+	for k, a := range m.alerts {
+		if a.level != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	result := make(map[string][]ResourceAlertStatus)
+	for _, k := range keys {
+		a := m.alerts[k]
+		id, metric, _ := strings.Cut(k, ":")
+		result[a.service] = append(result[a.service], ResourceAlertStatus{
+			Container: shortID(id),
+			Metric:    metric,
+			Level:     a.level,
+			Percent:   a.value,
+			Since:     a.since,
+		})
+	}
+	return result
+}
+
 func (m *Monitor) pollAll(ctx context.Context) {
 	services := m.cfg.SnapshotServices()
 	// Track container IDs seen this cycle to evict stale entries.
 	currentIDs := make(map[string]struct{})
+	// Services whose containers could not be listed keep their alerts: a
+	// transient list error does not mean the containers are gone.
+	unlisted := make(map[string]bool)
 
 	for _, svc := range services {
 		if ctx.Err() != nil {
 			return
 		}
-		ids := m.checkService(ctx, svc)
+		ids, err := m.checkService(ctx, svc)
+		if err != nil {
+			logger.Printf("[monitor] %s: list containers: %v", svc.Name, err)
+			unlisted[svc.Name] = true
+			continue
+		}
 		for _, id := range ids {
 			currentIDs[id] = struct{}{}
 		}
@@ -155,17 +204,32 @@ func (m *Monitor) pollAll(ctx context.Context) {
 		}
 	}
 	m.prevCPUMu.Unlock()
-}
 
-func (m *Monitor) checkService(ctx context.Context, svc config.Service) []string {
-	containerIDs := findRunningContainerIDs(ctx, m.docker, svc)
-	if len(containerIDs) == 0 {
-		return nil
+	// A raised alert of a container that is gone recovers with it.
+	type goneAlert struct{ service, message string }
+	var gone []goneAlert
+	m.alertsMu.Lock()
+	for k, a := range m.alerts {
+		id, metric, _ := strings.Cut(k, ":")
+		if _, ok := currentIDs[id]; !ok && !unlisted[a.service] {
+			if a.level != "" {
+				gone = append(gone, goneAlert{a.service, fmt.Sprintf("Container %s is gone, %s %s alert cleared.", shortID(id), metric, a.level)})
+			}
+			delete(m.alerts, k)
+		}
+	}
+	m.alertsMu.Unlock()
+	for _, g := range gone {
+		m.notify(ctx, g.service, "resource_recovered", notify.LevelInfo, g.message)
 	}
+}
 
-	cooldown := time.Duration(svc.HealCooldown) * time.Second
-	if cooldown <= 0 {
-		cooldown = 5 * time.Minute
+// checkService samples the running containers of svc and returns their IDs.
+// An error means the containers could not be listed.
+func (m *Monitor) checkService(ctx context.Context, svc config.Service) ([]string, error) {
+	containerIDs, err := findRunningContainerIDs(ctx, m.docker, svc)
+	if err != nil || len(containerIDs) == 0 {
+		return nil, err
 	}
 
 	// Collect per-container stats and aggregate for display.
@@ -213,24 +277,14 @@ func (m *Monitor) checkService(ctx context.Context, svc config.Service) []string
 		}
 		m.containerStatsMu.Unlock()
 
-		// Per-container threshold alerts use container-scoped cooldown keys.
-		if svc.CPUThreshold > 0 && cpuPct > svc.CPUThreshold {
-			if m.maybeAlert(ctx, svc, "cpu:"+id, cooldown,
-				fmt.Sprintf("Container %s CPU %.1f%% exceeds threshold %.1f%%", id[:12], cpuPct, svc.CPUThreshold),
-			) {
-				m.metrics.IncCPUAlerts(svc.Name)
-			}
+		// Per-container threshold alerts keep their state per container.
+		if hasPrev {
+			m.observe(ctx, svc, id, "cpu", cpuThresholds(svc), cpuPct,
+				fmt.Sprintf("CPU %.1f%%", cpuPct))
 		}
-
-		if svc.MemoryThreshold > 0 && raw.MemoryLimit > 0 {
-			if containerMemPct > svc.MemoryThreshold {
-				if m.maybeAlert(ctx, svc, "memory:"+id, cooldown,
-					fmt.Sprintf("Container %s memory %.1f%% (%.0f MB / %.0f MB) exceeds threshold %.1f%%",
-						id[:12], containerMemPct, float64(raw.MemoryUsage)/1024/1024, float64(raw.MemoryLimit)/1024/1024, svc.MemoryThreshold),
-				) {
-					m.metrics.IncMemoryAlerts(svc.Name)
-				}
-			}
+		if raw.MemoryLimit > 0 {
+			m.observe(ctx, svc, id, "memory", memoryThresholds(svc), containerMemPct,
+				fmt.Sprintf("memory %.1f%% (%.0f MB / %.0f MB)", containerMemPct, float64(raw.MemoryUsage)/1024/1024, float64(raw.MemoryLimit)/1024/1024))
 		}
 	}
 
@@ -249,65 +303,105 @@ func (m *Monitor) checkService(ctx context.Context, svc config.Service) []string
 	}
 	m.latestMu.Unlock()
 
-	return containerIDs
+	return containerIDs, nil
 }
 
-func (m *Monitor) maybeAlert(ctx context.Context, svc config.Service, metric string, cooldown time.Duration, message string) bool {
-	key := svc.Name + ":" + metric
+// observe feeds one sample of a container metric to its alert state and
+// notifies when the alert is raised, escalated or recovers. usage describes
+// the sample for the message, e.g. "CPU 93.4%".
+func (m *Monitor) observe(ctx context.Context, svc config.Service, id, metric string, t thresholds, value float64, usage string) {
+	key := id + ":" + metric
+	now := time.Now()
+
+	m.alertsMu.Lock()
+	if !t.enabled() {
+		delete(m.alerts, key)
+		m.alertsMu.Unlock()
+		return
+	}
+	a := m.alerts[key]
+	if a == nil {
+		a = &resourceAlert{service: svc.Name}
+		m.alerts[key] = a
+	}
+	prev, changed := a.observe(t, value, now)
+	level, held := a.level, a.current()
+	m.alertsMu.Unlock()
 
-	m.alertedAtMu.Lock()
-	last, ok := m.alertedAt[key]
-	if ok && time.Since(last) < cooldown {
-		m.alertedAtMu.Unlock()
-		return false
+	if !changed {
+		return
 	}
-	m.alertedAt[key] = time.Now()
-	m.alertedAtMu.Unlock()
+	switch {
+	case level == "":
+		m.notify(ctx, svc.Name, "resource_recovered", notify.LevelInfo,
+			fmt.Sprintf("Container %s %s, back below %.1f%%.", shortID(id), usage, t.recovery()))
+	case levelRank[level] > levelRank[prev]:
+		m.notify(ctx, svc.Name, "resource_alert", level,
+			fmt.Sprintf("Container %s %s exceeds %s threshold %.1f%%%s.", shortID(id), usage, level, t.level(level), sustainedFor(held, now)))
+		if metric == "cpu" {
+			m.metrics.IncCPUAlerts(svc.Name)
+		} else {
+			m.metrics.IncMemoryAlerts(svc.Name)
+		}
+	default:
+		logger.Printf("[monitor] %s: container %s %s, down to %s", svc.Name, shortID(id), usage, level)
+	}
+}
 
-	logger.Printf("[monitor] %s: %s", svc.Name, message)
+func (m *Monitor) notify(ctx context.Context, service, event, level, message string) {
+	logger.Printf("[monitor] %s: %s", service, message)
 
 	m.dispatcher.Send(ctx, notify.Alert{
-		Service: svc.Name,
-		Event:   "resource_alert",
+		Service: service,
+		Event:   event,
 		Message: message,
-		Level:   notify.LevelWarning,
+		Level:   level,
 	})
 
 	if err := m.audit.Write(audit.Entry{
-		Service: svc.Name,
-		Event:   "resource_alert",
+		Service: service,
+		Event:   event,
 		Message: message,
-		Level:   "warning",
+		Level:   level,
 	}); err != nil {
-		logger.Printf("[monitor] %s: audit write error: %v", svc.Name, err)
+		logger.Printf("[monitor] %s: audit write error: %v", service, err)
 	}
-	return true
 }
 
 // findRunningContainerIDs returns all running container IDs for a service.
 // Used by the monitor to collect stats across multi-container services.
-func findRunningContainerIDs(ctx context.Context, dc *docker.Client, svc config.Service) []string {
+// Unlike findRunningContainerID it reports list errors, so the caller can
+// tell a failed list from a service without running containers.
+func findRunningContainerIDs(ctx context.Context, dc *docker.Client, svc config.Service) ([]string, error) {
 	var ids []string
 
 	if svc.ComposeProject != "" {
 		containers, err := dc.ListContainersByProject(ctx, svc.ComposeProject)
-		if err == nil {
-			for _, c := range containers {
-				if c.State == "running" {
-					ids = append(ids, c.ID)
-				}
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range containers {
+			if c.State == "running" {
+				ids = append(ids, c.ID)
 			}
 		}
 	}
 
 	if len(ids) == 0 && svc.ContainerName != "" {
 		// Fallback to container_name for heal-only services with no compose project.
-		if id := findRunningContainerID(ctx, dc, svc); id != "" {
-			ids = append(ids, id)
+		filter := url.QueryEscape(fmt.Sprintf(`{"name":["%s"]}`, svc.ContainerName))
+		containers, err := dc.ListContainersFiltered(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range containers {
+			if c.State == "running" {
+				return append(ids, c.ID), nil
+			}
 		}
 	}
 
-	return ids
+	return ids, nil
 }
 
 // findRunningContainerID returns the first running container ID for a service,
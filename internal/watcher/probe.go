@@ -0,0 +1,158 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/docker"
+	"github.com/studiowebux/dockward/internal/probe"
+	"github.com/studiowebux/dockward/internal/saferun"
+)
+
+// probeTarget returns the running container a service's probe applies to:
+// exec probes run in it and the healer restarts it. probe.service selects a
+// compose service; otherwise the first running container of the service.
+// Package-level so both Updater and Healer can use it.
+func probeTarget(ctx context.Context, dc *docker.Client, svc config.Service) string {
+	if svc.Probe.Service == "" || svc.ComposeProject == "" {
+		return findRunningContainerID(ctx, dc, svc)
+	}
+	containers, err := dc.ListContainersByProject(ctx, svc.ComposeProject)
+	if err != nil {
+		return ""
+	}
+	for _, c := range containers {
+		if c.State == "running" && c.Labels[composeServiceLabel] == svc.Probe.Service {
+			return c.ID
+		}
+	}
+	return ""
+}
+
+// runProbe runs svc's probe once. Exec probes run inside container id.
+func runProbe(ctx context.Context, dc *docker.Client, svc config.Service, id string) error {
+	return probe.Run(ctx, svc.Probe, func(ctx context.Context, cmd []string) (int, string, error) {
+		if id == "" {
+			return 0, "", fmt.Errorf("no running container")
+		}
+		res, err := dc.Exec(ctx, id, cmd)
+		if err != nil {
+			return 0, "", err
+		}
+		return res.ExitCode, res.Output, nil
+	})
+}
+
+// probeState tracks one service's probe between attempts.
+type probeState struct {
+	next      time.Time // earliest time of the next attempt
+	running   bool      // an attempt is in flight
+	failures  int       // consecutive failed attempts
+	unhealthy bool      // threshold reached and not yet passed again
+}
+
+// record applies one probe outcome. Returns unhealthy when the failure
+// threshold is reached (then resets the counter, so a still-failing service
+// must fail threshold more times before healing again), and recovered on the
+// first pass after an unhealthy verdict.
+func (s *probeState) record(err error, threshold int) (unhealthy, recovered bool) {
+	if err == nil {
+		s.failures = 0
+		recovered = s.unhealthy
+		s.unhealthy = false
+		return false, recovered
+	}
+	s.failures++
+	if s.failures < threshold {
+		return false, false
+	}
+	s.failures = 0
+	s.unhealthy = true
+	return true, false
+}
+
+// runProbes runs the probes of all services that configure one, each at its
+// own interval, until ctx is cancelled. A service whose probe reaches its
+// failure threshold is healed like an unhealthy Docker health event; a
+// passing probe afterwards is handled like a healthy event.
+func (h *Healer) runProbes(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, svc := range h.cfg.SnapshotServices() {
+				if svc.Probe == nil || svc.Silent {
+					continue
+				}
+				h.probesMu.Lock()
+				st := h.probes[svc.Name]
+				if st == nil {
+					st = &probeState{}
+					h.probes[svc.Name] = st
+				}
+				due := !st.running && !now.Before(st.next)
+				if due {
+					st.running = true
+					st.next = now.Add(time.Duration(svc.Probe.Interval) * time.Second)
+				}
+				h.probesMu.Unlock()
+				if due {
+					svc := svc
+					saferun.Go("healer-probe", func() { h.probeOnce(ctx, svc, st) })
+				}
+			}
+		}
+	}
+}
+
+// probeOnce runs one probe attempt for svc and acts on its outcome.
+func (h *Healer) probeOnce(ctx context.Context, svc config.Service, st *probeState) {
+	defer func() {
+		h.probesMu.Lock()
+		st.running = false
+		h.probesMu.Unlock()
+	}()
+
+	// Deploys verify probes themselves and roll back on failure.
+	if h.updater.IsDeploying(svc.Name) {
+		return
+	}
+	id := probeTarget(ctx, h.docker, svc)
+	if id == "" {
+		return // nothing running; die events cover this
+	}
+	err := runProbe(ctx, h.docker, svc, id)
+	if ctx.Err() != nil {
+		return
+	}
+
+	h.probesMu.Lock()
+	wasUnhealthy := st.unhealthy
+	unhealthy, recovered := st.record(err, svc.Probe.FailureThreshold)
+	h.probesMu.Unlock()
+	if err != nil {
+		debugf("[healer] %s: probe failed: %v", svc.Name, err)
+	}
+	// Without auto_heal there is nothing to retry: alert once per episode.
+	if unhealthy && wasUnhealthy && !svc.AutoHeal {
+		return
+	}
+	if !unhealthy && !recovered {
+		return
+	}
+
+	name := id
+	if info, ierr := h.docker.InspectContainer(ctx, id); ierr == nil {
+		name = info.ContainerName()
+	}
+	if unhealthy {
+		h.heal(ctx, &svc, name, id, fmt.Sprintf("probe failed %d times: %v", svc.Probe.FailureThreshold, err))
+		return
+	}
+	h.handleHealthy(ctx, &svc, name)
+}
@@ -0,0 +1,31 @@
+package watcher
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestProbeState_Record(t *testing.T) {
+	fail := errors.New("connection refused")
+	var s probeState
+
+	for i := 1; i < 3; i++ {
+		if unhealthy, _ := s.record(fail, 3); unhealthy {
+			t.Fatalf("failure %d: unhealthy before threshold", i)
+		}
+	}
+	if unhealthy, _ := s.record(fail, 3); !unhealthy {
+		t.Fatal("want unhealthy at threshold")
+	}
+	// The counter restarts so a still-failing service heals again only
+	// after another full threshold of failures.
+	if unhealthy, _ := s.record(fail, 3); unhealthy {
+		t.Error("want counter reset after unhealthy verdict")
+	}
+	if _, recovered := s.record(nil, 3); !recovered {
+		t.Error("want recovered on first pass after unhealthy")
+	}
+	if _, recovered := s.record(nil, 3); recovered {
+		t.Error("want recovered only once")
+	}
+}
@@ -0,0 +1,204 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/audit"
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/docker"
+	"github.com/studiowebux/dockward/internal/logger"
+	"github.com/studiowebux/dockward/internal/notify"
+)
+
+const (
+	// deathSlack is how far a die event may precede the FinishedAt of the
+	// container it reports and still account for it.
+	deathSlack = 2 * time.Second
+	// deathMemory is how long handled deaths are remembered per container.
+	deathMemory = 24 * time.Hour
+)
+
+// runReconcile reconciles every docker_health.reconcile_interval until ctx is
+// cancelled. Events missed while the stream was down, or lost by the daemon,
+// are caught up here.
+func (h *Healer) runReconcile(ctx context.Context) {
+	interval := time.Duration(h.cfg.DockerHealth.ReconcileInterval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.reconcile(ctx)
+		}
+	}
+}
+
+// reconcile inspects the containers of every configured service and replays
+// the transitions the event stream did not deliver — unhealthy, stopped or
+// missing — through the same handlers as the events. Docker reports a health
+// status only when it changes, so a container still unhealthy after a heal
+// step gets its next step here once the step's cooldown is over.
+func (h *Healer) reconcile(ctx context.Context) {
+	h.pruneDeaths()
+	for _, svc := range h.cfg.SnapshotServices() {
+		if svc.Silent || h.updater.IsDeploying(svc.Name) {
+			continue
+		}
+		containers, err := serviceContainers(ctx, h.docker, svc)
+		if err != nil {
+			debugf("[healer] %s: reconcile: %v", svc.Name, err)
+			continue
+		}
+		if len(containers) == 0 {
+			h.handleMissing(ctx, &svc)
+			continue
+		}
+		h.setPresent(svc.Name, true)
+		for _, c := range containers {
+			degraded := h.isDegraded(svc.Name)
+			if degraded && (!svc.AutoHeal || h.isExhausted(svc.Name)) {
+				break // already reported; the next event or pass clears it
+			}
+			info, err := h.docker.InspectContainer(ctx, c.ID)
+			if err != nil {
+				debugf("[healer] %s: reconcile inspect error: %v", svc.Name, err)
+				continue
+			}
+			if degraded {
+				h.reheal(ctx, &svc, info)
+				continue
+			}
+			h.reconcileContainer(ctx, &svc, info)
+		}
+	}
+}
+
+// reconcileContainer synthesizes the event a container's state calls for
+// when the healer did not see it.
+func (h *Healer) reconcileContainer(ctx context.Context, svc *config.Service, info *docker.ContainerInspect) {
+	name := info.ContainerName()
+	switch {
+	case !info.State.Running:
+		if !h.missedDeath(info.ID, info.State.FinishedAt) {
+			return
+		}
+		logger.Printf("[healer] %s: reconcile: %s stopped without a die event", svc.Name, name)
+		h.noteDeath(info.ID, info.State.FinishedAt)
+		h.handleDied(ctx, svc, name, info.ID, strconv.Itoa(info.State.ExitCode))
+	case info.State.Health != nil && info.State.Health.Status == "unhealthy":
+		logger.Printf("[healer] %s: reconcile: %s unhealthy without a health event", svc.Name, name)
+		h.heal(ctx, svc, name, info.ID, info.LastHealthOutput())
+	}
+}
+
+// reheal runs the next heal step for a container of a degraded service that
+// is still unhealthy once the last step's cooldown is over.
+func (h *Healer) reheal(ctx context.Context, svc *config.Service, info *docker.ContainerInspect) {
+	name := info.ContainerName()
+	if !info.State.Running || info.State.Health == nil || info.State.Health.Status != "unhealthy" ||
+		h.inCooldown(name) {
+		return
+	}
+	logger.Printf("[healer] %s: reconcile: %s still unhealthy after its cooldown", svc.Name, name)
+	h.heal(ctx, svc, name, info.ID, info.LastHealthOutput())
+}
+
+// handleMissing reports a service whose containers are gone. Only a service
+// whose containers were seen before is reported, once until they return.
+func (h *Healer) handleMissing(ctx context.Context, svc *config.Service) {
+	if !h.setPresent(svc.Name, false) {
+		return
+	}
+	logger.Printf("[healer] %s: reconcile: no container found", svc.Name)
+	h.metrics.SetHealthy(svc.Name, false)
+	h.setDegraded(svc.Name, true)
+	h.dispatcher.Send(ctx, notify.Alert{
+		Service: svc.Name,
+		Event:   "missing",
+		Message: "No container found for the service.",
+		Level:   notify.LevelWarning,
+	})
+	if err := h.audit.Write(audit.Entry{
+		Service: svc.Name,
+		Event:   "missing",
+		Message: "No container found for the service.",
+		Level:   "warning",
+	}); err != nil {
+		logger.Printf("[healer] %s: audit write error: %v", svc.Name, err)
+	}
+}
+
+// setPresent records whether the service has containers and returns the
+// previous value.
+func (h *Healer) setPresent(service string, present bool) bool {
+	h.presentMu.Lock()
+	defer h.presentMu.Unlock()
+	was := h.present[service]
+	h.present[service] = present
+	return was
+}
+
+// noteDeath records that a death of the container at the given time was
+// handled.
+func (h *Healer) noteDeath(containerID string, at time.Time) {
+	h.seenDeathsMu.Lock()
+	h.seenDeaths[containerID] = at
+	h.seenDeathsMu.Unlock()
+}
+
+// missedDeath reports whether a container that stopped at finishedAt did so
+// after the healer started and without a die event being handled for it.
+func (h *Healer) missedDeath(containerID string, finishedAt time.Time) bool {
+	if !finishedAt.After(h.startedAt) {
+		return false
+	}
+	h.seenDeathsMu.Lock()
+	defer h.seenDeathsMu.Unlock()
+	seen, ok := h.seenDeaths[containerID]
+	return !ok || finishedAt.After(seen.Add(deathSlack))
+}
+
+// pruneDeaths forgets handled deaths older than deathMemory.
+func (h *Healer) pruneDeaths() {
+	h.seenDeathsMu.Lock()
+	defer h.seenDeathsMu.Unlock()
+	for id, at := range h.seenDeaths {
+		if time.Since(at) > deathMemory {
+			delete(h.seenDeaths, id)
+		}
+	}
+}
+
+// serviceContainers returns every container of a service, stopped ones
+// included, by compose project label or exact container name.
+func serviceContainers(ctx context.Context, dc *docker.Client, svc config.Service) ([]docker.Container, error) {
+	if svc.ComposeProject != "" {
+		return dc.ListContainersByProject(ctx, svc.ComposeProject)
+	}
+	if svc.ContainerName == "" {
+		return nil, fmt.Errorf("no compose_project or container_name")
+	}
+	filter := url.QueryEscape(fmt.Sprintf(`{"name":["%s"]}`, svc.ContainerName))
+	containers, err := dc.ListContainersFiltered(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	// The name filter matches substrings.
+	var out []docker.Container
+	for _, c := range containers {
+		if slices.Contains(c.Names, "/"+svc.ContainerName) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,153 @@
+package watcher
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/notify"
+)
+
+func TestHealer_MissedDeath(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	h := &Healer{startedAt: start, seenDeaths: make(map[string]time.Time)}
+
+	if h.missedDeath("abc", start.Add(-time.Minute)) {
+		t.Error("a container stopped before the healer started is not a missed death")
+	}
+	died := start.Add(10 * time.Minute)
+	if !h.missedDeath("abc", died) {
+		t.Error("a death without a die event should be missed")
+	}
+
+	// The die event is stamped right after FinishedAt.
+	h.noteDeath("abc", died.Add(time.Millisecond))
+	if h.missedDeath("abc", died) {
+		t.Error("a death reported by a die event is not missed")
+	}
+	if !h.missedDeath("abc", died.Add(time.Minute)) {
+		t.Error("a later death of the same container should be missed")
+	}
+	if !h.missedDeath("other", died) {
+		t.Error("deaths are tracked per container")
+	}
+
+	h.seenDeaths["old"] = time.Now().Add(-2 * deathMemory)
+	h.pruneDeaths()
+	if _, ok := h.seenDeaths["old"]; ok {
+		t.Error("old deaths should be pruned")
+	}
+}
+
+func TestHealer_SetPresent(t *testing.T) {
+	h := &Healer{present: make(map[string]bool)}
+	if h.setPresent("web", false) {
+		t.Error("a service never seen is not reported missing")
+	}
+	h.setPresent("web", true)
+	if !h.setPresent("web", false) {
+		t.Error("a service seen before should be reported missing")
+	}
+	if h.setPresent("web", false) {
+		t.Error("a missing service is reported once")
+	}
+}
+
+// alertLog records the messages of sent alerts.
+type alertLog struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *alertLog) Name() string { return "log" }
+func (l *alertLog) Send(_ context.Context, a notify.Alert) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, a.Message)
+	return nil
+}
+
+func (l *alertLog) all() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.messages...)
+}
+
+func TestHealer_ReconcileWalksEscalationLadder(t *testing.T) {
+	defer func(d time.Duration) { healVerifyDelay = d }(healVerifyDelay)
+	healVerifyDelay = 10 * time.Millisecond
+
+	engine, dc := newFakeEngine(t)
+	file := fakeComposeCLI(t)
+	engine.addContainer(&fakeContainer{
+		ID:     "aaaaaaaaaaaaaaaa",
+		Name:   "worker-1",
+		Labels: map[string]string{"com.docker.compose.project": "worker", "com.docker.compose.service": "worker"},
+		State:  "running",
+		Health: "unhealthy", // stays unhealthy whatever the healer does
+	})
+	svc := config.Service{
+		Name:           "worker",
+		ComposeProject: "worker",
+		ComposeFiles:   []string{file},
+		AutoHeal:       true,
+		HealEscalation: []config.HealStep{
+			{Action: config.HealRestart, Attempts: 1},
+			{Action: config.HealRecreate, Attempts: 1},
+			{Action: config.HealProjectRestart, Attempts: 1},
+		},
+	}
+	sent := &alertLog{}
+	h := &Healer{
+		cfg:           &config.Config{Runtime: "docker", Services: []config.Service{svc}},
+		docker:        dc,
+		dispatcher:    notify.NewDispatcher(sent),
+		updater:       &Updater{deploying: make(map[string]time.Time)},
+		metrics:       NewMetrics(),
+		cooldowns:     make(map[string]time.Time),
+		degraded:      make(map[string]bool),
+		restartCounts: make(map[string]int),
+		exhausted:     make(map[string]bool),
+		healing:       make(map[string]time.Time),
+		seenDeaths:    make(map[string]time.Time),
+		present:       make(map[string]bool),
+		startedAt:     time.Now(),
+	}
+	failed := func() int {
+		h.restartCountsMu.Lock()
+		defer h.restartCountsMu.Unlock()
+		return h.restartCounts["worker"]
+	}
+	ctx := context.Background()
+
+	// The only unhealthy event Docker sends starts the first step; every
+	// later step comes from reconciliation once the cooldown is over.
+	h.handleUnhealthy(ctx, &svc, "worker-1", "aaaaaaaaaaaaaaaa")
+	for step := 1; step <= 3; step++ {
+		deadline := time.Now().Add(5 * time.Second)
+		for failed() < step {
+			if time.Now().After(deadline) {
+				t.Fatalf("step %d was not verified", step)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		time.Sleep(2 * healVerifyDelay)
+		h.reconcile(ctx)
+	}
+	h.reconcile(ctx)
+
+	want := []string{
+		"Container still unhealthy after restart (attempt 1/3).",
+		"Container still unhealthy after recreate (attempt 2/3).",
+		"Giving up after 3 failed heal attempts. Manual intervention required.",
+	}
+	if got := sent.all(); !slices.Equal(got, want) {
+		t.Errorf("alerts = %q, want %q", got, want)
+	}
+	if !h.isExhausted("worker") {
+		t.Error("want the service exhausted after the last step")
+	}
+}
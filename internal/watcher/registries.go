@@ -0,0 +1,103 @@
+package watcher
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/registry"
+)
+
+// imageTarget is a configured image entry resolved against its registry.
+type imageTarget struct {
+	registry     string // registry name from config
+	client       *registry.Client
+	host         string        // registry host (e.g. "ghcr.io", "localhost:5000")
+	repo         string        // repository path within the registry (e.g. "org/app")
+	tag          string        // tracked tag (e.g. "latest")
+	pollInterval time.Duration // poll interval of the registry
+}
+
+// prefix returns the registry-prefixed repository ("ghcr.io/org/app"), the
+// form Docker uses in RepoTags/RepoDigests and for retagging.
+func (t imageTarget) prefix() string {
+	return t.host + "/" + t.repo
+}
+
+// remoteRef returns the "repo:tag" form expected by registry.Client.
+func (t imageTarget) remoteRef() string {
+	return t.repo + ":" + t.tag
+}
+
+// resolveImage resolves one Images entry of svc to its registry, host,
+// repository and tag. See config.ImageRegistry for the selection rules.
+// Under a tag policy the tag is the one last deployed by the policy.
+func (u *Updater) resolveImage(svc config.Service, img string) (imageTarget, error) {
+	name, err := u.cfg.ImageRegistry(svc, img)
+	if err != nil {
+		return imageTarget{}, err
+	}
+	client, ok := u.registries[name]
+	if !ok {
+		return imageTarget{}, fmt.Errorf("registry %q has no client", name)
+	}
+	var reg config.Registry
+	for _, r := range u.cfg.AllRegistries() {
+		if r.Name == name {
+			reg = r
+			break
+		}
+	}
+
+	host, rest := config.SplitImageHost(img)
+	if host == "" {
+		host = config.RegistryHost(reg.URL)
+	}
+	tag := imageTag(rest)
+	if svc.TagPolicy != nil {
+		tag = u.currentTag(svc.Name+"/"+img, tag)
+	}
+	return imageTarget{
+		registry:     name,
+		client:       client,
+		host:         host,
+		repo:         imageName(rest),
+		tag:          tag,
+		pollInterval: time.Duration(reg.PollInterval) * time.Second,
+	}, nil
+}
+
+// pollInterval returns how often svc is polled: the shortest poll interval
+// among the registries its images come from, or the default registry's
+// interval when it has no resolvable images.
+func (u *Updater) pollInterval(svc config.Service) time.Duration {
+	var interval time.Duration
+	for _, img := range svc.Images {
+		t, err := u.resolveImage(svc, img)
+		if err != nil {
+			continue
+		}
+		if interval == 0 || t.pollInterval < interval {
+			interval = t.pollInterval
+		}
+	}
+	if interval == 0 {
+		u.cfg.RLock()
+		interval = time.Duration(u.cfg.Registry.PollInterval) * time.Second
+		u.cfg.RUnlock()
+	}
+	return interval
+}
+
+// minPollInterval returns the shortest poll interval of all configured
+// registries; the poll loop ticks at this rate and skips services not yet due.
+func (u *Updater) minPollInterval() time.Duration {
+	var interval time.Duration
+	for _, r := range u.cfg.AllRegistries() {
+		d := time.Duration(r.PollInterval) * time.Second
+		if interval == 0 || d < interval {
+			interval = d
+		}
+	}
+	return interval
+}
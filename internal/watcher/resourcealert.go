@@ -0,0 +1,140 @@
+package watcher
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/notify"
+)
+
+// thresholds are the alert levels of one resource metric of a service.
+type thresholds struct {
+	warning    float64       // percent; 0 = level disabled
+	critical   float64       // percent; 0 = level disabled
+	samples    int           // consecutive samples above a level before it alerts; 0 = disabled
+	duration   time.Duration // or time above a level before it alerts; 0 = disabled
+	hysteresis float64       // points below a level at which it clears
+}
+
+func cpuThresholds(svc config.Service) thresholds {
+	t := thresholds{
+		warning:  svc.CPUThreshold,
+		critical: svc.CPUCritical,
+		samples:  svc.ThresholdSamples,
+		duration: time.Duration(svc.ThresholdDuration) * time.Second,
+	}
+	if svc.ThresholdHysteresis != nil {
+		t.hysteresis = *svc.ThresholdHysteresis
+	}
+	return t
+}
+
+func memoryThresholds(svc config.Service) thresholds {
+	t := cpuThresholds(svc)
+	t.warning, t.critical = svc.MemoryThreshold, svc.MemoryCritical
+	return t
+}
+
+func (t thresholds) enabled() bool { return t.warning > 0 || t.critical > 0 }
+
+// level returns the threshold of an alert level.
+func (t thresholds) level(level string) float64 {
+	if level == notify.LevelCritical {
+		return t.critical
+	}
+	return t.warning
+}
+
+// recovery returns the usage below which every alert level is cleared.
+func (t thresholds) recovery() float64 {
+	if t.warning > 0 {
+		return t.warning - t.hysteresis
+	}
+	return t.critical - t.hysteresis
+}
+
+// levelRank orders alert levels; the empty level is no alert.
+var levelRank = map[string]int{"": 0, notify.LevelWarning: 1, notify.LevelCritical: 2}
+
+// streak counts consecutive samples above one threshold.
+type streak struct {
+	count int
+	since time.Time // first sample of the streak
+}
+
+func (s *streak) observe(above bool, now time.Time) {
+	if !above {
+		*s = streak{}
+		return
+	}
+	if s.count == 0 {
+		s.since = now
+	}
+	s.count++
+}
+
+// sustained reports whether the streak lasted threshold_samples samples or
+// threshold_duration, whichever comes first.
+func (s streak) sustained(t thresholds, now time.Time) bool {
+	return s.count > 0 &&
+		((t.samples > 0 && s.count >= t.samples) || (t.duration > 0 && now.Sub(s.since) >= t.duration))
+}
+
+// resourceAlert is the alert state of one metric of one container. A level
+// is raised once usage stays above its threshold long enough, and cleared
+// once usage falls hysteresis points below it.
+type resourceAlert struct {
+	service string
+	level   string    // "", warning or critical
+	since   time.Time // when level was entered
+	value   float64   // last sample, percent
+
+	warn, crit streak
+}
+
+// observe records a sample and reports whether the alert level changed.
+// The level before the change is returned alongside.
+func (a *resourceAlert) observe(t thresholds, value float64, now time.Time) (prev string, changed bool) {
+	a.value = value
+	a.warn.observe(t.warning > 0 && value > t.warning, now)
+	a.crit.observe(t.critical > 0 && value > t.critical, now)
+
+	level := a.level
+	switch {
+	case a.crit.sustained(t, now) && levelRank[level] < levelRank[notify.LevelCritical]:
+		level = notify.LevelCritical
+	case a.warn.sustained(t, now) && level == "":
+		level = notify.LevelWarning
+	}
+	if level == notify.LevelCritical && value < t.critical-t.hysteresis {
+		level = notify.LevelWarning
+	}
+	if level == notify.LevelWarning && (t.warning <= 0 || value < t.warning-t.hysteresis) {
+		level = ""
+	}
+
+	prev = a.level
+	if level == prev {
+		return prev, false
+	}
+	a.level, a.since = level, now
+	return prev, true
+}
+
+// current returns the streak above the current level's threshold.
+func (a *resourceAlert) current() streak {
+	if a.level == notify.LevelCritical {
+		return a.crit
+	}
+	return a.warn
+}
+
+// sustainedFor describes how long usage stayed above the threshold before an
+// alert, e.g. " for 1m30s (4 samples)". Empty for a single sample.
+func sustainedFor(s streak, now time.Time) string {
+	if s.count < 2 {
+		return ""
+	}
+	return fmt.Sprintf(" for %s (%d samples)", now.Sub(s.since).Round(time.Second), s.count)
+}
@@ -0,0 +1,139 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/docker"
+	"github.com/studiowebux/dockward/internal/notify"
+)
+
+func TestResourceAlert_SustainedSamples(t *testing.T) {
+	th := thresholds{warning: 80, critical: 95, samples: 3, hysteresis: 5}
+	a := &resourceAlert{}
+	now := time.Now()
+
+	steps := []struct {
+		value float64
+		want  string
+	}{
+		{90, ""}, // one sample is a burst
+		{70, ""}, // streak broken
+		{85, ""},
+		{88, ""},
+		{91, notify.LevelWarning}, // third consecutive sample above 80
+		{97, notify.LevelWarning},
+		{98, notify.LevelWarning},
+		{99, notify.LevelCritical},
+		{92, notify.LevelCritical}, // within hysteresis of 95
+		{85, notify.LevelWarning},
+		{76, notify.LevelWarning}, // within hysteresis of 80
+		{74, ""},
+	}
+	for i, s := range steps {
+		a.observe(th, s.value, now.Add(time.Duration(i)*30*time.Second))
+		if a.level != s.want {
+			t.Fatalf("sample %d (%.0f%%): level %q, want %q", i, s.value, a.level, s.want)
+		}
+	}
+}
+
+func TestResourceAlert_SustainedDuration(t *testing.T) {
+	th := thresholds{warning: 80, duration: time.Minute, hysteresis: 5}
+	a := &resourceAlert{}
+	now := time.Now()
+
+	if _, changed := a.observe(th, 90, now); changed {
+		t.Fatal("a single sample should not alert")
+	}
+	a.observe(th, 90, now.Add(30*time.Second))
+	prev, changed := a.observe(th, 90, now.Add(time.Minute))
+	if !changed || prev != "" || a.level != notify.LevelWarning {
+		t.Fatalf("want warning after a minute, got %q (changed=%v)", a.level, changed)
+	}
+	if got := sustainedFor(a.current(), now.Add(time.Minute)); got != " for 1m0s (3 samples)" {
+		t.Errorf("sustainedFor = %q", got)
+	}
+
+	prev, changed = a.observe(th, 50, now.Add(2*time.Minute))
+	if !changed || prev != notify.LevelWarning || a.level != "" {
+		t.Errorf("want recovery from warning, got %q (changed=%v)", a.level, changed)
+	}
+}
+
+func TestResourceAlert_CriticalOnly(t *testing.T) {
+	th := thresholds{critical: 90, samples: 1, hysteresis: 10}
+	a := &resourceAlert{}
+	now := time.Now()
+
+	if a.observe(th, 95, now); a.level != notify.LevelCritical {
+		t.Fatalf("want critical, got %q", a.level)
+	}
+	if a.observe(th, 85, now); a.level != notify.LevelCritical {
+		t.Errorf("within hysteresis: want critical, got %q", a.level)
+	}
+	if a.observe(th, 75, now); a.level != "" {
+		t.Errorf("want recovered, got %q", a.level)
+	}
+	if got := th.recovery(); got != 80 {
+		t.Errorf("recovery() = %.0f, want 80", got)
+	}
+}
+
+func TestMonitor_ResourceAlerts(t *testing.T) {
+	m := &Monitor{alerts: map[string]*resourceAlert{
+		"bbbbbbbbbbbbbbbb:memory": {service: "web", level: notify.LevelCritical, value: 97},
+		"aaaaaaaaaaaaaaaa:cpu":    {service: "web", level: notify.LevelWarning, value: 85},
+		"cccccccccccccccc:cpu":    {service: "db", value: 10}, // not raised
+	}}
+	got := m.ResourceAlerts()
+	if len(got) != 1 || len(got["web"]) != 2 {
+		t.Fatalf("want two alerts for web only, got %+v", got)
+	}
+	if a := got["web"][0]; a.Container != "aaaaaaaaaaaa" || a.Metric != "cpu" || a.Level != notify.LevelWarning {
+		t.Errorf("unexpected first alert: %+v", a)
+	}
+}
+
+func TestMonitor_GoneContainerRecoversAlert(t *testing.T) {
+	sent := &countNotifier{}
+	m := NewMonitor(&config.Config{}, nil, notify.NewDispatcher(sent), nil, NewMetrics())
+	m.alerts["aaaaaaaaaaaaaaaa:cpu"] = &resourceAlert{service: "web", level: notify.LevelWarning, value: 85}
+	m.alerts["bbbbbbbbbbbbbbbb:memory"] = &resourceAlert{service: "web", value: 10} // not raised
+
+	m.pollAll(context.Background())
+
+	if sent.n != 1 {
+		t.Errorf("want one recovery for the raised alert, got %d notifications", sent.n)
+	}
+	if len(m.alerts) != 0 {
+		t.Errorf("want alerts of gone containers evicted, got %+v", m.alerts)
+	}
+}
+
+func TestMonitor_ListErrorKeepsAlerts(t *testing.T) {
+	// Nothing listens on port 1: every container list fails.
+	dc, err := docker.NewClient(docker.Options{Host: "tcp://127.0.0.1:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := &countNotifier{}
+	cfg := &config.Config{Services: []config.Service{{Name: "web", ComposeProject: "shop"}}}
+	m := NewMonitor(cfg, dc, notify.NewDispatcher(sent), nil, NewMetrics())
+	m.alerts["aaaaaaaaaaaaaaaa:cpu"] = &resourceAlert{service: "web", level: notify.LevelWarning, value: 85}
+	m.alerts["bbbbbbbbbbbbbbbb:cpu"] = &resourceAlert{service: "removed", level: notify.LevelWarning, value: 90}
+
+	m.pollAll(context.Background())
+
+	if _, ok := m.alerts["aaaaaaaaaaaaaaaa:cpu"]; !ok {
+		t.Error("want the alert of a service whose list failed kept")
+	}
+	if _, ok := m.alerts["bbbbbbbbbbbbbbbb:cpu"]; ok {
+		t.Error("want the alert of a service no longer configured cleared")
+	}
+	if sent.n != 1 {
+		t.Errorf("want one recovery, for the removed service only, got %d notifications", sent.n)
+	}
+}
@@ -4,9 +4,9 @@ package watcher
 import (
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
-	"github.com/studiowebux/dockward/internal/logger"
 	"os"
 	"strings"
 	"sync"
@@ -16,18 +16,21 @@ import (
 	"github.com/studiowebux/dockward/internal/compose"
 	"github.com/studiowebux/dockward/internal/config"
 	"github.com/studiowebux/dockward/internal/docker"
+	"github.com/studiowebux/dockward/internal/logger"
 	"github.com/studiowebux/dockward/internal/notify"
 	"github.com/studiowebux/dockward/internal/registry"
+	"github.com/studiowebux/dockward/internal/state"
 )
 
 // Updater polls the registry for image changes and triggers deploys with rollback.
 type Updater struct {
 	cfg        *config.Config
 	docker     *docker.Client
-	registry   *registry.Client
+	registries map[string]*registry.Client // keyed by registry name ("default" + registries[].name)
 	dispatcher *notify.Dispatcher
 	metrics    *Metrics
 	audit      *audit.Logger
+	state      *state.Store // persists blocked/notFound/startAttempted/composeHashes and deploy history
 
 	// deploying tracks services currently in a deploy cycle.
 	// The healer checks this to avoid interfering with rollback.
@@ -36,10 +39,32 @@ type Updater struct {
 
 	// blocked maps "service/image" -> digest that caused a rollback.
 	// Prevents infinite rollback loops by skipping known-bad digests.
-	// Memory-only: cleared on watcher restart.
+	// Persisted to the state store so a restart does not redeploy a bad digest.
 	blocked   map[string]string
 	blockedMu sync.RWMutex
 
+	// pinned maps "service/image" -> digest selected by a manual rollback
+	// (POST /rollback). Pinned images are not upgraded until unpinned.
+	// Persisted to the state store.
+	pinned   map[string]string
+	pinnedMu sync.RWMutex
+
+	// pending maps service name -> update held outside its deploy windows or
+	// by a freeze. Released by the window ticker in Run. Not persisted: the
+	// first poll after a restart detects the change again.
+	pending   map[string]PendingDeploy
+	pendingMu sync.RWMutex
+
+	// approved maps service name -> image entry -> digest approved via
+	// POST /approve (require_approval services). Guarded by pendingMu;
+	// cleared with the pending entry once the deploy starts.
+	approved map[string]map[string]string
+
+	// freeze holds every image deploy until freeze.Until (nil = no freeze).
+	// Set via POST /freeze; persisted to the state store.
+	freeze   *state.Freeze
+	freezeMu sync.RWMutex
+
 	// notFound maps "service/image" -> remote digest at time of failure.
 	// Suppresses repeated deploy attempts when the local image cannot be
 	// resolved (e.g. compose file image field mismatch). Cleared when the
@@ -66,6 +91,11 @@ type Updater struct {
 	composeHashes   map[string]string
 	composeHashesMu sync.Mutex
 
+	// driftHeld maps service name -> compose hash whose redeploy is held by
+	// a deploy window or freeze, so the hold is logged once per change.
+	// Guarded by composeHashesMu.
+	driftHeld map[string]string
+
 	// deployed maps "service/image" -> deployed image reference and digest.
 	// Updated after each successful deploy and on each poll when image is up to date.
 	deployed   map[string]DeployedInfo
@@ -80,25 +110,34 @@ type Updater struct {
 	checkStatusMu sync.RWMutex
 }
 
-// NewUpdater creates an image updater.
-func NewUpdater(cfg *config.Config, dc *docker.Client, rc *registry.Client, dispatcher *notify.Dispatcher, metrics *Metrics, al *audit.Logger) *Updater {
-	return &Updater{
+// NewUpdater creates an image updater. registries maps each configured
+// registry name to its client (see config.AllRegistries). Persisted state
+// in st is restored immediately; st may be nil.
+func NewUpdater(cfg *config.Config, dc *docker.Client, registries map[string]*registry.Client, dispatcher *notify.Dispatcher, metrics *Metrics, al *audit.Logger, st *state.Store) *Updater {
+	u := &Updater{
 		cfg:            cfg,
 		docker:         dc,
-		registry:       rc,
+		registries:     registries,
 		dispatcher:     dispatcher,
 		metrics:        metrics,
 		audit:          al,
+		state:          st,
 		deploying:      make(map[string]time.Time),
 		blocked:        make(map[string]string),
+		pinned:         make(map[string]string),
+		pending:        make(map[string]PendingDeploy),
+		approved:       make(map[string]map[string]string),
 		notFound:       make(map[string]string),
 		errored:        make(map[string]string),
 		startAttempted: make(map[string]string),
 		composeHashes:  make(map[string]string),
+		driftHeld:      make(map[string]string),
 		deployed:       make(map[string]DeployedInfo),
 		lastChecked:    make(map[string]time.Time),
 		checkStatus:    make(map[string]string),
 	}
+	u.restoreState()
+	return u
 }
 
 // IsDeploying returns true if a service is currently in a deploy cycle.
@@ -137,8 +176,10 @@ func (u *Updater) DeployingCount() int {
 }
 
 // Run starts the polling loop. Blocks until ctx is cancelled.
+// The loop ticks at the shortest registry poll interval; each tick only
+// checks services whose own interval (see pollInterval) has elapsed.
 func (u *Updater) Run(ctx context.Context) {
-	interval := time.Duration(u.cfg.Registry.PollInterval) * time.Second
+	interval := u.minPollInterval()
 	logger.Printf("[updater] polling every %s", interval)
 
 	// Run once immediately on startup.
@@ -151,14 +192,20 @@ func (u *Updater) Run(ctx context.Context) {
 	cleanupTicker := time.NewTicker(1 * time.Hour)
 	defer cleanupTicker.Stop()
 
+	// Window ticker - every minute, deploy pending updates whose window opened
+	windowTicker := time.NewTicker(time.Minute)
+	defer windowTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			u.pollAll(ctx)
+			u.pollDue(ctx)
 		case <-cleanupTicker.C:
 			u.cleanupOldEntries()
+		case <-windowTicker.C:
+			u.releasePending(ctx)
 		}
 	}
 }
@@ -236,15 +283,68 @@ func (u *Updater) cleanupOldEntries() {
 	}
 	u.composeHashesMu.Unlock()
 
+	// Clean blocked and notFound ("service/image" keys)
+	u.blockedMu.Lock()
+	for k := range u.blocked {
+		if !currentServices[k] {
+			delete(u.blocked, k)
+		}
+	}
+	u.blockedMu.Unlock()
+	u.notFoundMu.Lock()
+	for k := range u.notFound {
+		if !currentServices[k] {
+			delete(u.notFound, k)
+		}
+	}
+	u.notFoundMu.Unlock()
+	u.pinnedMu.Lock()
+	for k := range u.pinned {
+		if !currentServices[k] {
+			delete(u.pinned, k)
+		}
+	}
+	u.pinnedMu.Unlock()
+	u.pendingMu.Lock()
+	for k := range u.pending {
+		if !currentServices[k] {
+			delete(u.pending, k)
+		}
+	}
+	for k := range u.approved {
+		if !currentServices[k] {
+			delete(u.approved, k)
+		}
+	}
+	u.pendingMu.Unlock()
+
+	u.persist()
 	logger.Printf("[updater] cleaned old entries from state maps")
 }
 
+// pollAll checks every service regardless of its poll interval.
+// Used on startup and for manual trigger-all.
 func (u *Updater) pollAll(ctx context.Context) {
+	u.poll(ctx, true)
+}
+
+// pollDue checks only services whose poll interval has elapsed since their
+// last check.
+func (u *Updater) pollDue(ctx context.Context) {
+	u.poll(ctx, false)
+}
+
+func (u *Updater) poll(ctx context.Context, force bool) {
 	u.metrics.RecordPoll()
 	for _, svc := range u.cfg.SnapshotServices() {
 		if ctx.Err() != nil {
 			return
 		}
+		// A second of slack absorbs ticker jitter so a service is not
+		// pushed back a whole tick when its interval equals the tick rate.
+		if !force && time.Since(u.GetLastCheck(svc.Name)) < u.pollInterval(svc)-time.Second {
+			continue
+		}
 		if svc.AutoUpdate {
 			if err := u.checkAndUpdate(ctx, svc, false); err != nil {
 				u.handlePollError(ctx, svc, err)
@@ -278,6 +378,8 @@ func (u *Updater) composeHash(files []string) (string, error) {
 // checkComposeDrift detects byte-level changes in compose files and runs
 // compose up -d (no pull) when the content hash differs from the last known hash.
 // First-run stores the hash without deploying — the service is already running the current spec.
+// Outside deploy windows or during a freeze the new hash is not stored, so the
+// change is redeployed on the first poll after the hold lifts.
 func (u *Updater) checkComposeDrift(ctx context.Context, svc config.Service) error {
 	if len(svc.ComposeFiles) == 0 {
 		return nil
@@ -290,8 +392,23 @@ func (u *Updater) checkComposeDrift(ctx context.Context, svc config.Service) err
 
 	u.composeHashesMu.Lock()
 	prev := u.composeHashes[svc.Name]
+	if prev != "" && prev != hash {
+		if reason, until := u.holdReason(svc, time.Now()); reason != "" {
+			first := u.driftHeld[svc.Name] != hash
+			u.driftHeld[svc.Name] = hash
+			u.composeHashesMu.Unlock()
+			if first {
+				logger.Printf("[updater] %s: compose file changed, redeploy %s", svc.Name, heldUntil(reason, until))
+			}
+			return nil
+		}
+	}
+	delete(u.driftHeld, svc.Name)
 	u.composeHashes[svc.Name] = hash
 	u.composeHashesMu.Unlock()
+	if prev != hash {
+		u.persist()
+	}
 
 	if prev == "" || prev == hash {
 		return nil // first run or no change
@@ -299,7 +416,7 @@ func (u *Updater) checkComposeDrift(ctx context.Context, svc config.Service) err
 
 	logger.Printf("[updater] %s: compose file changed, redeploying", svc.Name)
 	u.tryStartDeploy(svc.Name)
-	composeOut, err := compose.Up(ctx, u.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile)
+	composeOut, err := compose.Up(ctx, u.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile, u.composeEnv(svc, nil)...)
 	if err != nil {
 		u.clearDeploying(svc.Name)
 		return fmt.Errorf("compose up (drift): %w", err)
@@ -417,10 +534,18 @@ func (u *Updater) GetNextCheck(serviceName string) time.Time {
 	lastCheck, exists := u.lastChecked[serviceName]
 	u.lastCheckedMu.RUnlock()
 
+	interval := u.minPollInterval()
+	for _, svc := range u.cfg.SnapshotServices() {
+		if svc.Name == serviceName {
+			interval = u.pollInterval(svc)
+			break
+		}
+	}
+
 	if !exists {
-		return time.Now().Add(time.Duration(u.cfg.Registry.PollInterval) * time.Second)
+		return time.Now().Add(interval)
 	}
-	return lastCheck.Add(time.Duration(u.cfg.Registry.PollInterval) * time.Second)
+	return lastCheck.Add(interval)
 }
 
 // GetLastCheck returns the last check time for a service
@@ -470,10 +595,34 @@ func (u *Updater) checkAndUpdate(ctx context.Context, svc config.Service, manual
 	// Per-image loop: check each image for digest changes.
 	for _, img := range svc.Images {
 		key := svc.Name + "/" + img
-		registryPrefix := registryHost(u.cfg.Registry.URL) + "/" + imageName(img)
+		target, err := u.resolveImage(svc, img)
+		if err != nil {
+			return fmt.Errorf("resolve registry for %s: %w", img, err)
+		}
+		registryPrefix := target.prefix()
 
-		// Step 1: Get remote digest from registry.
-		remoteDigest, err := u.registry.RemoteDigest(ctx, img)
+		// Pinned by a manual rollback: hold the image until unpinned.
+		u.pinnedMu.RLock()
+		_, isPinned := u.pinned[key]
+		u.pinnedMu.RUnlock()
+		if isPinned {
+			continue
+		}
+
+		// Step 1: Get remote digest from registry. Under a tag policy, the
+		// digest of the highest matching tag.
+		remoteRef, newTag := target.remoteRef(), ""
+		if svc.TagPolicy != nil {
+			selected, err := u.selectTag(ctx, svc, target)
+			if err != nil {
+				return err
+			}
+			if selected != target.tag {
+				newTag = selected
+				remoteRef = target.repo + ":" + selected
+			}
+		}
+		remoteDigest, err := target.client.RemoteDigest(ctx, remoteRef)
 		if err != nil {
 			return fmt.Errorf("remote digest %s: %w", img, err)
 		}
@@ -492,6 +641,7 @@ func (u *Updater) checkAndUpdate(ctx context.Context, svc config.Service, manual
 			delete(u.blocked, key)
 			u.blockedMu.Unlock()
 			u.metrics.SetBlocked(svc.Name, false)
+			u.persist()
 		}
 
 		// Check if this image is in the notFound suppression map.
@@ -506,15 +656,17 @@ func (u *Updater) checkAndUpdate(ctx context.Context, svc config.Service, manual
 			u.notFoundMu.Lock()
 			delete(u.notFound, key)
 			u.notFoundMu.Unlock()
+			u.persist()
 		}
 
 		// Step 2: Get local digest from Docker.
-		localDigest, localSize := u.resolveLocalDigestForImage(ctx, svc, registryPrefix, img)
+		localDigest, localSize := u.resolveLocalDigestForImage(ctx, svc, target, img)
 		if localDigest == "" {
 			logger.Printf("[updater] %s/%s: no local digest resolved, suppressing until registry digest changes", svc.Name, img)
 			u.notFoundMu.Lock()
 			u.notFound[key] = remoteDigest
 			u.notFoundMu.Unlock()
+			u.persist()
 			u.dispatcher.Send(ctx, notify.Alert{
 				Service: svc.Name,
 				Event:   "not_found",
@@ -533,17 +685,25 @@ func (u *Updater) checkAndUpdate(ctx context.Context, svc config.Service, manual
 		}
 
 		// Step 3: Compare.
-		if localDigest == remoteDigest {
-			u.setDeployedInfo(key, registryPrefix+":"+imageTag(img), localDigest, localSize)
+		if localDigest == remoteDigest && newTag == "" {
+			u.setDeployedInfo(key, registryPrefix+":"+target.tag, localDigest, localSize)
 			if representativeDigest == "" {
 				representativeDigest = remoteDigest
 			}
 			continue
 		}
 
-		logger.Printf("[updater] %s/%s: digest changed %s -> %s", svc.Name, img, shortDigest(localDigest), shortDigest(remoteDigest))
+		if newTag != "" {
+			logger.Printf("[updater] %s/%s: tag policy selected %s (was %s)", svc.Name, img, newTag, target.tag)
+		} else {
+			logger.Printf("[updater] %s/%s: digest changed %s -> %s", svc.Name, img, shortDigest(localDigest), shortDigest(remoteDigest))
+		}
 		changed = append(changed, imageChange{
 			Image:     img,
+			Prefix:    registryPrefix,
+			Repo:      target.repo,
+			Tag:       target.tag,
+			NewTag:    newTag,
 			OldDigest: localDigest,
 			NewDigest: remoteDigest,
 		})
@@ -551,11 +711,16 @@ func (u *Updater) checkAndUpdate(ctx context.Context, svc config.Service, manual
 
 	// No image changes: verify containers are running, handle auto_start.
 	if len(changed) == 0 {
-		_, status := u.findContainerByProject(ctx, svc.ComposeProject)
+		u.clearPending(svc.Name)
+		_, status := u.findServiceContainer(ctx, svc)
 		if status == containerRunning {
 			u.startAttemptedMu.Lock()
+			_, hadAttempt := u.startAttempted[svc.Name]
 			delete(u.startAttempted, svc.Name)
 			u.startAttemptedMu.Unlock()
+			if hadAttempt {
+				u.persist()
+			}
 			u.clearPollError(svc)
 			if manual {
 				if werr := u.audit.Write(audit.Entry{
@@ -570,7 +735,8 @@ func (u *Updater) checkAndUpdate(ctx context.Context, svc config.Service, manual
 			return nil
 		}
 
-		if !svc.AutoStart {
+		// auto_start runs compose; a missing standalone container has no config to recreate.
+		if !svc.AutoStart || svc.Standalone() {
 			u.clearPollError(svc)
 			if manual {
 				if werr := u.audit.Write(audit.Entry{
@@ -585,6 +751,13 @@ func (u *Updater) checkAndUpdate(ctx context.Context, svc config.Service, manual
 			return nil
 		}
 
+		// Starting the project is a compose up like a deploy: wait for the
+		// deploy window or freeze to lift.
+		if reason, until := u.holdReason(svc, time.Now()); reason != "" {
+			debugf("[updater] %s: containers not running, auto_start %s", svc.Name, heldUntil(reason, until))
+			return nil
+		}
+
 		// Guard against repeated start attempts at the same image version.
 		u.startAttemptedMu.RLock()
 		attemptedDigest := u.startAttempted[svc.Name]
@@ -595,12 +768,13 @@ func (u *Updater) checkAndUpdate(ctx context.Context, svc config.Service, manual
 		u.startAttemptedMu.Lock()
 		u.startAttempted[svc.Name] = representativeDigest
 		u.startAttemptedMu.Unlock()
+		u.persist()
 
 		u.tryStartDeploy(svc.Name)
 		switch status {
 		case containerStuck:
 			logger.Printf("[updater] %s: containers stuck (created/restarting), forcing down+up", svc.Name)
-			composeOut, err := compose.Restart(ctx, u.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile)
+			composeOut, err := compose.Restart(ctx, u.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile, u.composeEnv(svc, nil)...)
 			if err != nil {
 				u.clearDeploying(svc.Name)
 				return fmt.Errorf("compose restart (stuck containers): %w", err)
@@ -622,7 +796,7 @@ func (u *Updater) checkAndUpdate(ctx context.Context, svc config.Service, manual
 			}
 		default:
 			logger.Printf("[updater] %s: images up to date but no containers, starting compose project", svc.Name)
-			composeOut, err := compose.Up(ctx, u.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile)
+			composeOut, err := compose.Up(ctx, u.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile, u.composeEnv(svc, nil)...)
 			if err != nil {
 				u.clearDeploying(svc.Name)
 				return fmt.Errorf("compose up (no running container): %w", err)
@@ -649,23 +823,33 @@ func (u *Updater) checkAndUpdate(ctx context.Context, svc config.Service, manual
 	}
 
 	u.clearPollError(svc)
+	if svc.RequireApproval && !u.isApproved(svc.Name, changed) {
+		u.holdDeploy(ctx, svc, changed, holdApproval, time.Time{})
+		return nil
+	}
+	if reason, until := u.holdReason(svc, time.Now()); reason != "" {
+		u.holdDeploy(ctx, svc, changed, reason, until)
+		return nil
+	}
+	u.clearPending(svc.Name)
 	return u.deploy(ctx, svc, changed)
 }
 
 // resolveLocalDigestForImage tries two strategies to find the local image digest:
 //  1. Resolve via running container's actual image ID (what is actually deployed).
-//  2. Fallback: inspect image by constructed reference (registryHost/name:tag)
+//  2. Fallback: inspect image by constructed reference (host/repo:tag)
 //     when no running container exists.
 //
 // Strategy 1 is preferred because the image tag reference can be updated (e.g.
 // by a docker pull) before the container is recreated, which would make the
 // local digest match the remote and skip the deploy even though the container
 // is still running the old image.
-func (u *Updater) resolveLocalDigestForImage(ctx context.Context, svc config.Service, registryPrefix, img string) (string, int64) {
-	fullImage := registryPrefix + ":" + imageTag(img)
+func (u *Updater) resolveLocalDigestForImage(ctx context.Context, svc config.Service, target imageTarget, img string) (string, int64) {
+	registryPrefix := target.prefix()
+	fullImage := registryPrefix + ":" + target.tag
 
 	// Strategy 1: resolve via running container's image ID (authoritative).
-	container, status := u.findContainerByProject(ctx, svc.ComposeProject)
+	container, status := u.findServiceContainer(ctx, svc)
 	if status == containerRunning {
 		info, err := u.docker.InspectContainer(ctx, container.ID)
 		if err == nil {
@@ -714,39 +898,61 @@ func (u *Updater) deploy(ctx context.Context, svc config.Service, changed []imag
 		return nil
 	}
 
-	// Step 1: For each changed image, tag the currently running container's image as :rollback.
-	// We tag by image ID so it works regardless of how compose references the image name.
-	// We also capture the compose image reference (OldRef) for rollback retag.
-	allContainers, _ := u.docker.ListContainersByProject(ctx, svc.ComposeProject)
-	for i, ch := range changed {
-		registryPrefix := registryHost(u.cfg.Registry.URL) + "/" + imageName(ch.Image)
-		imgName := imageName(ch.Image)
-		for _, c := range allContainers {
-			if c.State != "running" {
-				continue
-			}
-			// Match container to image by name (handles both short and registry-prefixed forms).
-			cName := imageName(c.Image)
-			if cName == imgName || strings.HasSuffix(cName, "/"+imgName) {
-				if info, err := u.docker.InspectContainer(ctx, c.ID); err == nil {
-					changed[i].OldRef = info.Config.Image
-					if err := u.docker.TagImage(ctx, info.Image, registryPrefix, "rollback"); err != nil {
-						logger.Printf("[updater] %s/%s: failed to tag rollback: %v", svc.Name, ch.Image, err)
-					}
-				}
-				break
-			}
-		}
+	// Step 1: Tag the currently running images as :rollback.
+	u.tagRollbacks(ctx, svc, changed)
+	var groups []rolloutGroup
+	if svc.Rollout.Rolling() {
+		groups = u.rolloutGroups(ctx, svc, changed)
 	}
 
 	// Step 2: Pull new images and recreate via compose.
 	logger.Printf("[updater] %s: pulling and deploying", svc.Name)
-	pullOut, err := compose.Pull(ctx, u.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile)
+	env := u.composeEnv(svc, changed)
+	var pullOut string
+	var err error
+	if svc.Standalone() {
+		pullOut, err = u.enginePull(ctx, svc, changed)
+	} else {
+		pullOut, err = compose.Pull(ctx, u.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile, env...)
+	}
 	if err != nil {
 		u.clearDeploying(svc.Name)
+		if svc.Standalone() {
+			return fmt.Errorf("pull: %w", err)
+		}
 		return fmt.Errorf("compose pull: %w", err)
 	}
-	upOut, err := compose.Up(ctx, u.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile)
+	// compose pull fetches every image of the project, including pinned ones.
+	u.reapplyPins(ctx, svc)
+	hookOut, ok := u.preDeploy(ctx, svc, changed, pullOut)
+	if !ok {
+		// The pulled tags point at the rejected images: restore them so a
+		// later heal or compose up does not start them, and block the digests
+		// so the next poll does not run the hooks again.
+		u.blockDigests(svc, changed)
+		if u.restoreTags(ctx, svc, changed) {
+			u.cleanupRollbacks(ctx, changed)
+		}
+		u.clearDeploying(svc.Name)
+		return nil
+	}
+	pullOut = joinOutput(pullOut, hookOut)
+	if len(groups) > 0 {
+		// Rolling: replace replicas batch by batch; verification is per batch.
+		go u.rollingDeploy(ctx, svc, changed, groups, env, pullOut)
+		return nil
+	}
+	if svc.Standalone() {
+		// Standalone: replace the container through the Engine API.
+		upOut, err := u.engineReplace(ctx, svc, changed[0].Prefix+":"+changed[0].deployTag())
+		if err != nil {
+			u.clearDeploying(svc.Name)
+			return fmt.Errorf("replace container: %w\noutput: %s", err, upOut)
+		}
+		go u.verifyAfterDeploy(ctx, svc, changed, joinOutput(pullOut, upOut))
+		return nil
+	}
+	upOut, err := compose.Up(ctx, u.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile, env...)
 	if err != nil {
 		u.clearDeploying(svc.Name)
 		return fmt.Errorf("compose up: %w", err)
@@ -759,6 +965,33 @@ func (u *Updater) deploy(ctx context.Context, svc config.Service, changed []imag
 	return nil
 }
 
+// tagRollbacks tags the image of each changed image's running container as
+// :rollback. We tag by image ID so it works regardless of how compose references
+// the image name. We also capture the compose image reference (OldRef) for
+// rollback retag, and retain the running digest in the release history.
+func (u *Updater) tagRollbacks(ctx context.Context, svc config.Service, changed []imageChange) {
+	allContainers, _ := u.serviceContainers(ctx, svc)
+	for i, ch := range changed {
+		for _, c := range allContainers {
+			if c.State != "running" {
+				continue
+			}
+			// Match container to image by name (handles both short and registry-prefixed forms).
+			cName := imageName(c.Image)
+			if cName == ch.Repo || strings.HasSuffix(cName, "/"+ch.Repo) {
+				if info, err := u.docker.InspectContainer(ctx, c.ID); err == nil {
+					changed[i].OldRef = info.Config.Image
+					if err := u.docker.TagImage(ctx, info.Image, ch.Prefix, "rollback"); err != nil {
+						logger.Printf("[updater] %s/%s: failed to tag rollback: %v", svc.Name, ch.Image, err)
+					}
+					u.retainPrevious(ctx, svc, ch, info.Image)
+				}
+				break
+			}
+		}
+	}
+}
+
 func (u *Updater) verifyAfterDeploy(ctx context.Context, svc config.Service, changed []imageChange, composeOut string) {
 	defer u.clearDeploying(svc.Name)
 
@@ -766,7 +999,7 @@ func (u *Updater) verifyAfterDeploy(ctx context.Context, svc config.Service, cha
 	deadline := time.Now().Add(grace)
 	logger.Printf("[updater] %s: health polling for %s", svc.Name, grace)
 
-	ticker := time.NewTicker(5 * time.Second)
+	ticker := time.NewTicker(healthPollInterval)
 	defer ticker.Stop()
 
 	for {
@@ -776,57 +1009,39 @@ func (u *Updater) verifyAfterDeploy(ctx context.Context, svc config.Service, cha
 		case <-ticker.C:
 		}
 
-		container, cStatus := u.findContainerByProject(ctx, svc.ComposeProject)
-		if cStatus == containerNone {
-			if time.Now().After(deadline) {
-				logger.Printf("[updater] %s: container not found after grace period, rolling back", svc.Name)
-				u.rollback(ctx, svc, changed, "container not found after deploy", composeOut)
-				return
-			}
-			continue // Container may be starting up.
-		}
-
-		info, err := u.docker.InspectContainer(ctx, container.ID)
+		expired := time.Now().After(deadline)
+		ids, err := u.verifyTargets(ctx, svc, changed)
 		if err != nil {
-			logger.Printf("[updater] %s: inspect failed during health poll: %v", svc.Name, err)
-			if time.Now().After(deadline) {
-				u.rollback(ctx, svc, changed, "inspect failed: "+err.Error(), composeOut)
+			logger.Printf("[updater] %s: list containers during health poll: %v", svc.Name, err)
+			if expired {
+				u.rollback(ctx, svc, changed, "list containers failed: "+err.Error(), composeOut)
 				return
 			}
 			continue
 		}
 
-		// No healthcheck configured: success if running.
-		if info.State.Health == nil {
-			if info.State.Running {
-				u.onDeploySuccess(ctx, svc, changed, info.ContainerName(), info.Config.Image, composeOut)
-				return
-			}
-			if time.Now().After(deadline) {
-				u.rollback(ctx, svc, changed, "container not running", composeOut)
-				return
-			}
-			continue
+		report := u.checkHealth(ctx, ids)
+		done, reason := verdict(svc.Verify, report, expired)
+		done, reason = u.probeVerdict(ctx, svc, ids, done, reason, expired)
+		if !done {
+			continue // Containers may be starting up.
 		}
-
-		switch info.State.Health.Status {
-		case "healthy":
-			u.onDeploySuccess(ctx, svc, changed, info.ContainerName(), info.Config.Image, composeOut)
+		if reason != "" {
+			logger.Printf("[updater] %s: verification failed (%d/%d healthy), rolling back: %s", svc.Name, report.healthy, report.total, reason)
+			u.rollback(ctx, svc, changed, reason, composeOut)
+			return
+		}
+		smokeOut, reason := u.smokeTest(ctx, svc, changed)
+		composeOut = joinOutput(composeOut, smokeOut)
+		if ctx.Err() != nil {
 			return
-		case "unhealthy":
-			reason := info.LastHealthOutput()
-			logger.Printf("[updater] %s: unhealthy, rolling back immediately", svc.Name)
+		}
+		if reason != "" {
 			u.rollback(ctx, svc, changed, reason, composeOut)
 			return
-		default: // "starting" or other transient states
-			if time.Now().After(deadline) {
-				reason := info.LastHealthOutput()
-				logger.Printf("[updater] %s: still %s after grace period, rolling back", svc.Name, info.State.Health.Status)
-				u.rollback(ctx, svc, changed, reason, composeOut)
-				return
-			}
-			// Keep polling.
 		}
+		u.onDeploySuccess(ctx, svc, changed, report.name, report.image, composeOut)
+		return
 	}
 }
 
@@ -841,7 +1056,7 @@ func (u *Updater) verifyHealthAfterCompose(ctx context.Context, svc config.Servi
 	deadline := time.Now().Add(grace)
 	logger.Printf("[updater] %s: verifying health for %s", svc.Name, grace)
 
-	ticker := time.NewTicker(5 * time.Second)
+	ticker := time.NewTicker(healthPollInterval)
 	defer ticker.Stop()
 
 	for {
@@ -851,58 +1066,31 @@ func (u *Updater) verifyHealthAfterCompose(ctx context.Context, svc config.Servi
 		case <-ticker.C:
 		}
 
-		container, cStatus := u.findContainerByProject(ctx, svc.ComposeProject)
-		if cStatus == containerNone {
-			if time.Now().After(deadline) {
-				logger.Printf("[updater] %s: no container found after grace period", svc.Name)
-				u.metrics.SetHealthy(svc.Name, false)
-				return
-			}
-			continue
-		}
-
-		info, err := u.docker.InspectContainer(ctx, container.ID)
+		expired := time.Now().After(deadline)
+		ids, err := u.verifyTargets(ctx, svc, nil)
 		if err != nil {
-			if time.Now().After(deadline) {
-				logger.Printf("[updater] %s: inspect failed after grace: %v", svc.Name, err)
+			if expired {
+				logger.Printf("[updater] %s: list containers failed after grace: %v", svc.Name, err)
 				u.metrics.SetHealthy(svc.Name, false)
 				return
 			}
 			continue
 		}
 
-		// No healthcheck: running = healthy.
-		if info.State.Health == nil {
-			if info.State.Running {
-				logger.Printf("[updater] %s: running (no healthcheck), marking healthy", svc.Name)
-				u.metrics.SetHealthy(svc.Name, true)
-				return
-			}
-			if time.Now().After(deadline) {
-				logger.Printf("[updater] %s: not running after grace period", svc.Name)
-				u.metrics.SetHealthy(svc.Name, false)
-				return
-			}
+		report := u.checkHealth(ctx, ids)
+		done, reason := verdict(svc.Verify, report, expired)
+		done, reason = u.probeVerdict(ctx, svc, ids, done, reason, expired)
+		if !done {
 			continue
 		}
-
-		// Has healthcheck: respect Docker's health status.
-		switch info.State.Health.Status {
-		case "healthy":
-			logger.Printf("[updater] %s: healthy after compose operation", svc.Name)
-			u.metrics.SetHealthy(svc.Name, true)
-			return
-		case "unhealthy":
-			logger.Printf("[updater] %s: unhealthy after compose operation", svc.Name)
+		if reason != "" {
+			logger.Printf("[updater] %s: not healthy after compose operation (%d/%d healthy): %s", svc.Name, report.healthy, report.total, reason)
 			u.metrics.SetHealthy(svc.Name, false)
 			return
-		default: // "starting" etc.
-			if time.Now().After(deadline) {
-				logger.Printf("[updater] %s: still %s after grace period", svc.Name, info.State.Health.Status)
-				u.metrics.SetHealthy(svc.Name, false)
-				return
-			}
 		}
+		logger.Printf("[updater] %s: healthy after compose operation (%d/%d containers)", svc.Name, report.healthy, report.total)
+		u.metrics.SetHealthy(svc.Name, true)
+		return
 	}
 }
 
@@ -910,13 +1098,26 @@ func (u *Updater) onDeploySuccess(ctx context.Context, svc config.Service, chang
 	logger.Printf("[updater] %s: deployed successfully", svc.Name)
 	u.metrics.IncUpdates(svc.Name)
 	u.metrics.SetHealthy(svc.Name, true)
+	event, message := "updated", "Deployed new image successfully."
 	for _, ch := range changed {
 		u.setDeployedInfo(svc.Name+"/"+ch.Image, imageRef, ch.NewDigest, 0) // size resolved next poll
+		u.retainRelease(ctx, svc, ch, ch.Prefix+":"+ch.deployTag(), ch.NewDigest)
+		if ch.NewTag != "" {
+			u.setCurrentTag(svc.Name+"/"+ch.Image, ch.NewTag)
+			message = fmt.Sprintf("Deployed tag %s (was %s) successfully.", ch.NewTag, ch.Tag)
+		}
+		if ch.Pin {
+			u.pinnedMu.Lock()
+			u.pinned[svc.Name+"/"+ch.Image] = ch.NewDigest
+			u.pinnedMu.Unlock()
+			event, message = "pinned", "Rolled back to retained digest. Updates held until unpinned."
+		}
 	}
+	u.persist()
 	u.dispatcher.Send(ctx, notify.Alert{
 		Service:   svc.Name,
-		Event:     "updated",
-		Message:   "Deployed new image successfully.",
+		Event:     event,
+		Message:   message,
 		OldDigest: changed[0].OldDigest,
 		NewDigest: changed[0].NewDigest,
 		Container: containerName,
@@ -924,8 +1125,8 @@ func (u *Updater) onDeploySuccess(ctx context.Context, svc config.Service, chang
 	})
 	if err := u.audit.Write(audit.Entry{
 		Service:   svc.Name,
-		Event:     "updated",
-		Message:   "Deployed new image successfully.",
+		Event:     event,
+		Message:   message,
 		Level:     "info",
 		OldDigest: changed[0].OldDigest,
 		NewDigest: changed[0].NewDigest,
@@ -934,7 +1135,12 @@ func (u *Updater) onDeploySuccess(ctx context.Context, svc config.Service, chang
 	}); err != nil {
 		logger.Printf("[updater] %s: audit write error: %v", svc.Name, err)
 	}
+	u.recordHistory(svc.Name, event, "", changed)
 	u.cleanupRollbacks(ctx, changed)
+	if svc.Standalone() {
+		u.engineCleanup(ctx, svc)
+	}
+	u.afterHooks(ctx, svc, hookPostDeploy, svc.PostDeploy, changed)
 }
 
 func (u *Updater) rollback(ctx context.Context, svc config.Service, changed []imageChange, reason string, composeOut string) {
@@ -943,39 +1149,14 @@ func (u *Updater) rollback(ctx context.Context, svc config.Service, changed []im
 	u.metrics.SetHealthy(svc.Name, false)
 
 	// Block all new digests to prevent infinite rollback loops.
-	u.blockedMu.Lock()
-	for _, ch := range changed {
-		key := svc.Name + "/" + ch.Image
-		u.blocked[key] = ch.NewDigest
-		logger.Printf("[updater] %s/%s: blocked digest %s", svc.Name, ch.Image, shortDigest(ch.NewDigest))
-	}
-	u.blockedMu.Unlock()
-	u.metrics.SetBlocked(svc.Name, true)
+	u.blockDigests(svc, changed)
+	u.recordHistory(svc.Name, "rolled_back", reason, changed)
 
-	// Retag each :rollback back to its versioned tag and (if needed) to the compose ref.
-	// If compose uses a different image reference than the registry-prefixed form
-	// (e.g. "localhost:5000/firegen:latest" vs "firegen:latest"), also retag to
-	// that reference so compose up picks up the old image correctly.
-	tagFailed := false
-	for _, ch := range changed {
-		registryPrefix := registryHost(u.cfg.Registry.URL) + "/" + imageName(ch.Image)
-		tag := imageTag(ch.Image)
-		rollbackImage := registryPrefix + ":rollback"
-		composeRef := registryPrefix + ":" + tag
-
-		if ch.OldRef != "" && ch.OldRef != composeRef {
-			if err := u.docker.TagImage(ctx, rollbackImage, imageName(ch.OldRef), imageTag(ch.OldRef)); err != nil {
-				logger.Printf("[updater] %s/%s: rollback retag to compose ref %s failed: %v", svc.Name, ch.Image, ch.OldRef, err)
-			}
-		}
-
-		if err := u.docker.TagImage(ctx, rollbackImage, registryPrefix, tag); err != nil {
-			logger.Printf("[updater] %s/%s: rollback tag failed: %v", svc.Name, ch.Image, err)
-			tagFailed = true
-		}
-	}
+	// Capture the failing container's log before the rollback replaces it.
+	failedID, failedName := u.failingContainer(ctx, svc, changed)
+	logs := captureLogs(ctx, u.docker, svc, failedID)
 
-	if tagFailed {
+	if !u.restoreTags(ctx, svc, changed) {
 		u.metrics.IncFailures(svc.Name)
 		u.dispatcher.Send(ctx, notify.Alert{
 			Service:   svc.Name,
@@ -984,6 +1165,8 @@ func (u *Updater) rollback(ctx context.Context, svc config.Service, changed []im
 			Reason:    reason,
 			OldDigest: changed[0].OldDigest,
 			NewDigest: changed[0].NewDigest,
+			Container: failedName,
+			Logs:      logs,
 			Level:     notify.LevelCritical,
 		})
 		if werr := u.audit.Write(audit.Entry{
@@ -995,13 +1178,25 @@ func (u *Updater) rollback(ctx context.Context, svc config.Service, changed []im
 			NewDigest: changed[0].NewDigest,
 			Reason:    reason,
 			Output:    composeOut,
+			Container: failedName,
+			Logs:      logs,
 		}); werr != nil {
 			logger.Printf("[updater] %s: audit write error: %v", svc.Name, werr)
 		}
 		return
 	}
 
-	rollbackOut, err := compose.Up(ctx, u.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile)
+	var rollbackOut string
+	var err error
+	if svc.Standalone() {
+		rollbackOut, err = u.engineRestore(ctx, svc)
+		if errors.Is(err, errNotReplaced) {
+			// The failed replacement already swapped the previous container back.
+			rollbackOut, err = "previous container already in place", nil
+		}
+	} else {
+		rollbackOut, err = compose.Up(ctx, u.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile, u.composeEnv(svc, nil)...)
+	}
 	allOut := strings.TrimSpace(composeOut + "\n" + rollbackOut)
 	if err != nil {
 		logger.Printf("[updater] %s: rollback compose up failed: %v", svc.Name, err)
@@ -1013,6 +1208,8 @@ func (u *Updater) rollback(ctx context.Context, svc config.Service, changed []im
 			Reason:    reason,
 			OldDigest: changed[0].OldDigest,
 			NewDigest: changed[0].NewDigest,
+			Container: failedName,
+			Logs:      logs,
 			Level:     notify.LevelCritical,
 		})
 		if werr := u.audit.Write(audit.Entry{
@@ -1024,6 +1221,8 @@ func (u *Updater) rollback(ctx context.Context, svc config.Service, changed []im
 			NewDigest: changed[0].NewDigest,
 			Reason:    reason,
 			Output:    allOut,
+			Container: failedName,
+			Logs:      logs,
 		}); werr != nil {
 			logger.Printf("[updater] %s: audit write error: %v", svc.Name, werr)
 		}
@@ -1037,6 +1236,8 @@ func (u *Updater) rollback(ctx context.Context, svc config.Service, changed []im
 		Reason:    reason,
 		OldDigest: changed[0].OldDigest,
 		NewDigest: changed[0].NewDigest,
+		Container: failedName,
+		Logs:      logs,
 		Level:     notify.LevelWarning,
 	})
 	if err := u.audit.Write(audit.Entry{
@@ -1048,18 +1249,68 @@ func (u *Updater) rollback(ctx context.Context, svc config.Service, changed []im
 		NewDigest: changed[0].NewDigest,
 		Reason:    reason,
 		Output:    allOut,
+		Container: failedName,
+		Logs:      logs,
 	}); err != nil {
 		logger.Printf("[updater] %s: audit write error: %v", svc.Name, err)
 	}
 
 	u.cleanupRollbacks(ctx, changed)
+	u.afterHooks(ctx, svc, hookPostRollback, svc.PostRollback, changed)
+}
+
+// blockDigests blocks the new digest of each changed image so later polls
+// skip it until the registry digest changes.
+func (u *Updater) blockDigests(svc config.Service, changed []imageChange) {
+	blockedAny := false
+	u.blockedMu.Lock()
+	for _, ch := range changed {
+		if ch.Pin {
+			continue // a retained digest is not a new release; leave it selectable
+		}
+		key := svc.Name + "/" + ch.Image
+		u.blocked[key] = ch.NewDigest
+		blockedAny = true
+		logger.Printf("[updater] %s/%s: blocked digest %s", svc.Name, ch.Image, shortDigest(ch.NewDigest))
+	}
+	u.blockedMu.Unlock()
+	if blockedAny {
+		u.metrics.SetBlocked(svc.Name, true)
+	}
+	u.persist()
+}
+
+// restoreTags retags each :rollback back to its versioned tag and (if needed)
+// to the compose ref. If compose uses a different image reference than the
+// registry-prefixed form (e.g. "localhost:5000/firegen:latest" vs
+// "firegen:latest"), it also retags that reference so compose up picks up the
+// old image correctly. Returns false when a versioned tag could not be restored.
+func (u *Updater) restoreTags(ctx context.Context, svc config.Service, changed []imageChange) bool {
+	ok := true
+	for _, ch := range changed {
+		registryPrefix := ch.Prefix
+		tag := ch.Tag
+		rollbackImage := registryPrefix + ":rollback"
+		composeRef := registryPrefix + ":" + tag
+
+		if ch.OldRef != "" && ch.OldRef != composeRef {
+			if err := u.docker.TagImage(ctx, rollbackImage, imageName(ch.OldRef), imageTag(ch.OldRef)); err != nil {
+				logger.Printf("[updater] %s/%s: rollback retag to compose ref %s failed: %v", svc.Name, ch.Image, ch.OldRef, err)
+			}
+		}
+
+		if err := u.docker.TagImage(ctx, rollbackImage, registryPrefix, tag); err != nil {
+			logger.Printf("[updater] %s/%s: rollback tag failed: %v", svc.Name, ch.Image, err)
+			ok = false
+		}
+	}
+	return ok
 }
 
 func (u *Updater) cleanupRollbacks(ctx context.Context, changed []imageChange) {
 	for _, ch := range changed {
-		registryPrefix := registryHost(u.cfg.Registry.URL) + "/" + imageName(ch.Image)
-		if err := u.docker.RemoveImage(ctx, registryPrefix+":rollback"); err != nil {
-			logger.Printf("[updater] failed to remove rollback image %s:rollback: %v", registryPrefix, err)
+		if err := u.docker.RemoveImage(ctx, ch.Prefix+":rollback"); err != nil {
+			logger.Printf("[updater] failed to remove rollback image %s:rollback: %v", ch.Prefix, err)
 		}
 	}
 }
@@ -1111,6 +1362,7 @@ func (u *Updater) UnblockService(service string) bool {
 	}
 	u.blockedMu.Unlock()
 	if found {
+		u.persist()
 		u.metrics.SetBlocked(service, false)
 		logger.Printf("[updater] %s: manually unblocked", service)
 	}
@@ -1159,10 +1411,24 @@ func (u *Updater) serviceContainerInfos(ctx context.Context, project string) []C
 
 // imageChange records a digest transition for a single image within a deploy cycle.
 type imageChange struct {
-	Image     string // short form from config (e.g. "api:latest")
+	Image     string // entry as written in config (e.g. "api:latest", "ghcr.io/org/api:1")
+	Prefix    string // registry-prefixed repository (e.g. "localhost:5000/api")
+	Repo      string // repository path within the registry (e.g. "api")
+	Tag       string // tracked tag (e.g. "latest"); under a tag policy, the tag running before the deploy
+	NewTag    string // tag selected by a tag policy when it differs from Tag
 	OldDigest string
 	NewDigest string
 	OldRef    string // compose image reference captured from container inspect (for rollback retag)
+	Pin       bool   // manual rollback to a retained digest; pinned on success
+}
+
+// deployTag returns the tag being deployed: NewTag when a tag policy moved
+// to a new tag, Tag otherwise.
+func (ch imageChange) deployTag() string {
+	if ch.NewTag != "" {
+		return ch.NewTag
+	}
+	return ch.Tag
 }
 
 // DeployedInfo holds the deployed image reference and digest for a service image.
@@ -1200,12 +1466,13 @@ func (u *Updater) DeployedInfos() map[string]DeployedInfo {
 	return result
 }
 
-// findContainerByProject finds a container by compose project label.
+// findServiceContainer finds a container of svc by compose project label
+// (or container_name for standalone services).
 // Returns the first running container and the project status.
-func (u *Updater) findContainerByProject(ctx context.Context, project string) (*docker.Container, containerStatus) {
-	containers, err := u.docker.ListContainersByProject(ctx, project)
+func (u *Updater) findServiceContainer(ctx context.Context, svc config.Service) (*docker.Container, containerStatus) {
+	containers, err := u.serviceContainers(ctx, svc)
 	if err != nil {
-		logger.Printf("[updater] failed to list containers for project %s: %v", project, err)
+		logger.Printf("[updater] %s: failed to list containers: %v", svc.Name, err)
 		return nil, containerNone
 	}
 	if len(containers) == 0 {
@@ -1223,22 +1490,18 @@ func (u *Updater) findContainerByProject(ctx context.Context, project string) (*
 }
 
 // Helper functions for parsing image references.
-
-func registryHost(url string) string {
-	s := strings.TrimPrefix(url, "http://")
-	s = strings.TrimPrefix(s, "https://")
-	return strings.TrimRight(s, "/")
-}
+// A ":" only separates the tag when it follows the last "/", so registry
+// ports ("localhost:5000/app") are not mistaken for tags.
 
 func imageName(image string) string {
-	if idx := strings.LastIndex(image, ":"); idx >= 0 {
+	if idx := strings.LastIndex(image, ":"); idx > strings.LastIndex(image, "/") {
 		return image[:idx]
 	}
 	return image
 }
 
 func imageTag(image string) string {
-	if idx := strings.LastIndex(image, ":"); idx >= 0 {
+	if idx := strings.LastIndex(image, ":"); idx > strings.LastIndex(image, "/") {
 		return image[idx+1:]
 	}
 	return "latest"
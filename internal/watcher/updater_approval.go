@@ -0,0 +1,79 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/logger"
+	"github.com/studiowebux/dockward/internal/saferun"
+)
+
+// ErrNoPendingApproval is returned by Approve and Reject when the service
+// has no update waiting for approval.
+var ErrNoPendingApproval = errors.New("no update awaiting approval")
+
+// isApproved reports whether every digest in changed was approved for service.
+func (u *Updater) isApproved(service string, changed []imageChange) bool {
+	u.pendingMu.RLock()
+	defer u.pendingMu.RUnlock()
+	approved := u.approved[service]
+	if len(approved) == 0 {
+		return false
+	}
+	for _, ch := range changed {
+		if approved[ch.Image] != ch.NewDigest {
+			return false
+		}
+	}
+	return true
+}
+
+// Approve releases the update held for approval and re-checks the service in
+// the background. Only the approved digests deploy: if the registry moved on
+// meanwhile, the newer digest is held for approval again. Deploy windows and
+// freezes still apply to an approved update.
+func (u *Updater) Approve(svc config.Service) (PendingDeploy, error) {
+	u.pendingMu.Lock()
+	p, ok := u.pending[svc.Name]
+	if !ok || p.Reason != holdApproval {
+		u.pendingMu.Unlock()
+		return PendingDeploy{}, ErrNoPendingApproval
+	}
+	u.approved[svc.Name] = p.Images
+	u.pendingMu.Unlock()
+
+	logger.Printf("[updater] %s: pending update approved", svc.Name)
+	saferun.Go("approve-"+svc.Name, func() {
+		bg := context.Background()
+		if err := u.checkAndUpdate(bg, svc, true); err != nil {
+			u.handlePollErrorAlways(bg, svc, err)
+		}
+	})
+	return p, nil
+}
+
+// Reject drops the update held for approval and blocks its digests the same
+// way a failed deploy does: they are skipped until the registry digest
+// changes or the service is unblocked.
+func (u *Updater) Reject(svc config.Service) (PendingDeploy, error) {
+	u.pendingMu.Lock()
+	p, ok := u.pending[svc.Name]
+	if !ok || p.Reason != holdApproval {
+		u.pendingMu.Unlock()
+		return PendingDeploy{}, ErrNoPendingApproval
+	}
+	delete(u.pending, svc.Name)
+	delete(u.approved, svc.Name)
+	u.pendingMu.Unlock()
+
+	u.blockedMu.Lock()
+	for img, digest := range p.Images {
+		u.blocked[svc.Name+"/"+img] = digest
+		logger.Printf("[updater] %s/%s: rejected, blocked digest %s", svc.Name, img, shortDigest(digest))
+	}
+	u.blockedMu.Unlock()
+	u.metrics.SetBlocked(svc.Name, true)
+	u.persist()
+	return p, nil
+}
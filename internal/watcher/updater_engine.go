@@ -0,0 +1,169 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/docker"
+	"github.com/studiowebux/dockward/internal/logger"
+)
+
+// replacedSuffix is appended to the name of a standalone container while its
+// replacement is verified. The renamed container is removed after a
+// successful deploy and swapped back by a rollback.
+const replacedSuffix = "_dockward_old"
+
+// errNotReplaced is returned by engineRestore when there is no replaced
+// container to swap back, e.g. because engineReplace already restored it.
+var errNotReplaced = errors.New("no replaced container to restore")
+
+// serviceContainers returns the containers of svc: the compose project's, or
+// the container named container_name for standalone services.
+func (u *Updater) serviceContainers(ctx context.Context, svc config.Service) ([]docker.Container, error) {
+	if !svc.Standalone() {
+		return u.docker.ListContainersByProject(ctx, svc.ComposeProject)
+	}
+	info, err := u.docker.InspectContainer(ctx, svc.ContainerName)
+	if docker.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []docker.Container{{
+		ID:     info.ID,
+		Names:  []string{info.Name},
+		Image:  info.Config.Image,
+		Labels: info.Config.Labels,
+		State:  info.State.Status,
+	}}, nil
+}
+
+// enginePull pulls the new image of each change through the Engine API, the
+// standalone counterpart of compose pull.
+func (u *Updater) enginePull(ctx context.Context, svc config.Service, changed []imageChange) (string, error) {
+	var out []string
+	for _, ch := range changed {
+		ref := ch.Prefix + ":" + ch.deployTag()
+		logger.Printf("[updater] %s: pulling %s", svc.Name, ref)
+		if err := u.docker.PullImage(ctx, ref); err != nil {
+			return strings.Join(out, "\n"), err
+		}
+		out = append(out, "pulled "+ref)
+	}
+	return strings.Join(out, "\n"), nil
+}
+
+// engineReplace replaces svc's standalone container with one running image
+// and otherwise identical configuration: the old container is stopped and
+// renamed with replacedSuffix, and the replacement is created under the
+// original name and started. If that fails, the old container is swapped
+// back before returning the error. Returns a log of the steps taken.
+func (u *Updater) engineReplace(ctx context.Context, svc config.Service, image string) (string, error) {
+	var steps []string
+	step := func(format string, args ...any) {
+		msg := fmt.Sprintf(format, args...)
+		logger.Printf("[updater] %s: %s", svc.Name, msg)
+		steps = append(steps, msg)
+	}
+	name := svc.ContainerName
+	oldName := name + replacedSuffix
+
+	old, err := u.docker.InspectContainer(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("inspect %s: %w", name, err)
+	}
+	// A replaced container left over from an interrupted deploy.
+	if stale, err := u.docker.InspectContainer(ctx, oldName); err == nil && stale.ID != old.ID {
+		if err := u.docker.RemoveContainer(ctx, stale.ID, true); err != nil {
+			return "", fmt.Errorf("remove stale %s: %w", oldName, err)
+		}
+		step("removed stale %s", oldName)
+	}
+
+	spec, err := u.docker.CloneSpec(ctx, old.ID, image)
+	if err != nil {
+		return "", err
+	}
+	if err := u.docker.StopContainer(ctx, old.ID, 10); err != nil {
+		return strings.Join(steps, "\n"), err
+	}
+	step("stopped %s (%s)", name, shortID(old.ID))
+	if err := u.docker.RenameContainer(ctx, old.ID, oldName); err != nil {
+		u.startOld(ctx, svc, old.ID)
+		return strings.Join(steps, "\n"), err
+	}
+	step("renamed %s to %s", name, oldName)
+
+	id, err := u.docker.CreateContainer(ctx, name, spec)
+	if err != nil {
+		out, rerr := u.engineRestore(ctx, svc)
+		return joinOutput(strings.Join(steps, "\n"), out), joinErr(err, rerr)
+	}
+	step("created %s (%s) from %s", name, shortID(id), image)
+	if err := u.docker.StartContainer(ctx, id); err != nil {
+		out, rerr := u.engineRestore(ctx, svc)
+		return joinOutput(strings.Join(steps, "\n"), out), joinErr(err, rerr)
+	}
+	step("started %s", name)
+	return strings.Join(steps, "\n"), nil
+}
+
+// engineRestore swaps a standalone service back to the container replaced by
+// engineReplace: the replacement is removed and the old container is renamed
+// back and started. Returns a log of the steps taken.
+func (u *Updater) engineRestore(ctx context.Context, svc config.Service) (string, error) {
+	name := svc.ContainerName
+	oldName := name + replacedSuffix
+	old, err := u.docker.InspectContainer(ctx, oldName)
+	if docker.IsNotFound(err) {
+		return "", errNotReplaced
+	}
+	if err != nil {
+		return "", fmt.Errorf("previous container %s: %w", oldName, err)
+	}
+
+	var steps []string
+	if cur, err := u.docker.InspectContainer(ctx, name); err == nil {
+		if err := u.docker.RemoveContainer(ctx, cur.ID, true); err != nil {
+			return "", err
+		}
+		steps = append(steps, fmt.Sprintf("removed %s (%s)", name, shortID(cur.ID)))
+	}
+	if err := u.docker.RenameContainer(ctx, old.ID, name); err != nil {
+		return strings.Join(steps, "\n"), err
+	}
+	steps = append(steps, fmt.Sprintf("renamed %s to %s", oldName, name))
+	if err := u.docker.StartContainer(ctx, old.ID); err != nil {
+		return strings.Join(steps, "\n"), err
+	}
+	steps = append(steps, fmt.Sprintf("started %s (%s)", name, shortID(old.ID)))
+	logger.Printf("[updater] %s: restored previous container %s", svc.Name, shortID(old.ID))
+	return strings.Join(steps, "\n"), nil
+}
+
+// startOld restarts a stopped old container whose replacement never began.
+func (u *Updater) startOld(ctx context.Context, svc config.Service, id string) {
+	if err := u.docker.StartContainer(ctx, id); err != nil {
+		logger.Printf("[updater] %s: restart previous container %s: %v", svc.Name, shortID(id), err)
+	}
+}
+
+// engineCleanup removes the container replaced by a successful standalone deploy.
+func (u *Updater) engineCleanup(ctx context.Context, svc config.Service) {
+	oldName := svc.ContainerName + replacedSuffix
+	if err := u.docker.RemoveContainer(ctx, oldName, true); err != nil && !docker.IsNotFound(err) {
+		logger.Printf("[updater] %s: remove %s: %v", svc.Name, oldName, err)
+	}
+}
+
+// joinErr reports err, noting a failed restore when rerr is set.
+func joinErr(err, rerr error) error {
+	if rerr == nil {
+		return err
+	}
+	return fmt.Errorf("%w (restore previous container: %v)", err, rerr)
+}
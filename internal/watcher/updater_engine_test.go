@@ -0,0 +1,146 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/docker"
+	"github.com/studiowebux/dockward/internal/notify"
+	"github.com/studiowebux/dockward/internal/state"
+)
+
+// newStandalone returns an updater on engine and a standalone service whose
+// container "web" runs app:1.
+func newStandalone(t *testing.T) (*fakeEngine, *Updater, *state.Store, config.Service) {
+	t.Helper()
+	engine, dc := newFakeEngine(t)
+	engine.addImage(docker.ImageInspect{ID: "sha256:oldimage"}, "localhost/app:1")
+	engine.addImage(docker.ImageInspect{ID: "sha256:newimage"}, "localhost/app:2")
+	engine.addContainer(&fakeContainer{ID: "old-1", Name: "web", Image: "localhost/app:1", ImageID: "sha256:oldimage", State: "running"})
+	st, _ := state.Open("")
+	u := NewUpdater(&config.Config{Runtime: "docker"}, dc, nil, notify.NewDispatcher(), NewMetrics(), nil, st)
+	svc := config.Service{Name: "web", Images: []string{"localhost/app:1"}, ContainerName: "web", HealthGrace: 1}
+	return engine, u, st, svc
+}
+
+func TestEngineReplace_SwapsAndCleansUp(t *testing.T) {
+	engine, u, _, svc := newStandalone(t)
+	// Left over from an interrupted deploy.
+	engine.addContainer(&fakeContainer{ID: "stale-1", Name: "web" + replacedSuffix, Image: "localhost/app:0", State: "exited"})
+	ctx := context.Background()
+
+	out, err := u.engineReplace(ctx, svc, "localhost/app:2")
+	if err != nil {
+		t.Fatalf("replace: %v\n%s", err, out)
+	}
+	cur := engine.get("web")
+	if cur == nil || cur.ID != "created-1" || cur.ImageID != "sha256:newimage" || cur.State != "running" {
+		t.Fatalf("want web running the new image, got %+v", cur)
+	}
+	old := engine.get("web" + replacedSuffix)
+	if old == nil || old.ID != "old-1" || old.State != "exited" {
+		t.Fatalf("want the old container stopped under %s, got %+v", "web"+replacedSuffix, old)
+	}
+	if engine.get("stale-1") != nil {
+		t.Error("want the stale replaced container removed")
+	}
+	for _, want := range []string{"removed stale", "stopped web", "renamed web to web" + replacedSuffix, "created web", "started web"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	u.engineCleanup(ctx, svc)
+	if engine.get("web"+replacedSuffix) != nil {
+		t.Error("want the replaced container removed by cleanup")
+	}
+	if engine.get("web") == nil {
+		t.Error("want the replacement kept by cleanup")
+	}
+}
+
+func TestEngineReplace_RestoresOnFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		fail    func(*fakeEngine)
+		wantErr string
+	}{
+		{"create fails", func(f *fakeEngine) { f.failCreate = true }, "create container web"},
+		{"start fails", func(f *fakeEngine) { f.failStart["created-1"] = true }, "start container created-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, u, _, svc := newStandalone(t)
+			tt.fail(engine)
+			ctx := context.Background()
+
+			_, err := u.engineReplace(ctx, svc, "localhost/app:2")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("want error %q, got %v", tt.wantErr, err)
+			}
+			if strings.Contains(err.Error(), "restore previous container") {
+				t.Errorf("want the restore to succeed, got %v", err)
+			}
+			cur := engine.get("web")
+			if cur == nil || cur.ID != "old-1" || cur.State != "running" {
+				t.Fatalf("want the old container running as web again, got %+v", cur)
+			}
+			engine.mu.Lock()
+			n := len(engine.containers)
+			engine.mu.Unlock()
+			if n != 1 {
+				t.Errorf("want only the old container left, got %d containers", n)
+			}
+			// Nothing left for a rollback to swap back.
+			if _, err := u.engineRestore(ctx, svc); !errors.Is(err, errNotReplaced) {
+				t.Errorf("want errNotReplaced, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDeploy_StandaloneRollsBackAfterFailedVerification(t *testing.T) {
+	interval := healthPollInterval
+	healthPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { healthPollInterval = interval })
+
+	engine, u, st, svc := newStandalone(t)
+	engine.remote["localhost/app:2"] = docker.ImageInspect{ID: "sha256:newimage"}
+	// The probe fails in the replacement only.
+	engine.probeExit["created-1"] = 1
+	svc.Probe = &config.Probe{Exec: []string{"true"}, Timeout: 5}
+	changed := []imageChange{{Image: "localhost/app:1", Prefix: "localhost/app", Repo: "app", Tag: "1", NewTag: "2", OldDigest: "sha256:old", NewDigest: "sha256:new"}}
+
+	if err := u.deploy(context.Background(), svc, changed); err != nil {
+		t.Fatalf("deploy: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for u.IsDeploying(svc.Name) {
+		if time.Now().After(deadline) {
+			t.Fatal("deploy still in progress")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	history := st.Snapshot().History["web"]
+	if len(history) == 0 || history[len(history)-1].Event != "rolled_back" {
+		t.Fatalf("want a rollback recorded, got %+v", history)
+	}
+	if reason := history[len(history)-1].Reason; !strings.Contains(reason, "probe: ") {
+		t.Errorf("want a probe failure reason, got %q", reason)
+	}
+	cur := engine.get("web")
+	if cur == nil || cur.ID != "old-1" || cur.State != "running" {
+		t.Fatalf("want the old container running as web again, got %+v", cur)
+	}
+	if engine.get("created-1") != nil || engine.get("web"+replacedSuffix) != nil {
+		t.Error("want the replacement removed and nothing left under the replaced name")
+	}
+	if got := engine.ref("localhost/app:1"); got != "sha256:oldimage" {
+		t.Errorf("want localhost/app:1 to point at the old image, got %q", got)
+	}
+}
@@ -0,0 +1,241 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/studiowebux/dockward/internal/compose"
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/logger"
+	"github.com/studiowebux/dockward/internal/saferun"
+	"github.com/studiowebux/dockward/internal/state"
+)
+
+var (
+	// ErrReleaseNotFound is returned by RollbackTo when the digest is not in
+	// the service's retained release history.
+	ErrReleaseNotFound = errors.New("digest not in release history")
+	// ErrDeployInProgress is returned by RollbackTo when the service is
+	// already in a deploy cycle.
+	ErrDeployInProgress = errors.New("deploy in progress")
+)
+
+// releaseTag returns the local tag that pins a retained digest
+// ("sha256:0123456789abcdef..." -> "dockward-0123456789ab").
+func releaseTag(digest string) string {
+	hex := strings.TrimPrefix(digest, "sha256:")
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+	return "dockward-" + hex
+}
+
+// retainRelease pins src (image ID or reference) under the release tag of
+// digest and records it in the service/image release history. Releases that
+// fall beyond svc.HistoryLimit have their local tag removed.
+func (u *Updater) retainRelease(ctx context.Context, svc config.Service, ch imageChange, src, digest string) {
+	if digest == "" {
+		return
+	}
+	tag := releaseTag(digest)
+	if err := u.docker.TagImage(ctx, src, ch.Prefix, tag); err != nil {
+		logger.Printf("[updater] %s/%s: failed to pin release %s: %v", svc.Name, ch.Image, tag, err)
+		return
+	}
+	evicted, err := u.state.RetainRelease(svc.Name+"/"+ch.Image, state.Release{Digest: digest, Tag: tag}, svc.HistoryLimit)
+	if err != nil {
+		logger.Printf("[updater] %s: ERROR: state write error: %v", svc.Name, err)
+	}
+	for _, r := range evicted {
+		if err := u.docker.RemoveImage(ctx, ch.Prefix+":"+r.Tag); err != nil {
+			logger.Printf("[updater] %s/%s: failed to remove release tag %s: %v", svc.Name, ch.Image, r.Tag, err)
+		}
+	}
+}
+
+// retainPrevious retains the digest being replaced by a deploy, unless it is
+// already in the release history. Covers images deployed before history existed.
+func (u *Updater) retainPrevious(ctx context.Context, svc config.Service, ch imageChange, imageID string) {
+	if _, ok := u.findRelease(svc.Name+"/"+ch.Image, ch.OldDigest); ok {
+		return
+	}
+	u.retainRelease(ctx, svc, ch, imageID, ch.OldDigest)
+}
+
+// findRelease returns the retained release of key with the given digest.
+func (u *Updater) findRelease(key, digest string) (state.Release, bool) {
+	for _, r := range u.state.Snapshot().Releases[key] {
+		if r.Digest == digest {
+			return r, true
+		}
+	}
+	return state.Release{}, false
+}
+
+// reapplyPins points the tracked tag of each pinned image of svc back at its
+// pinned release. Called after compose pull, which would otherwise move the
+// tag to the registry's current digest.
+func (u *Updater) reapplyPins(ctx context.Context, svc config.Service) {
+	for _, img := range svc.Images {
+		key := svc.Name + "/" + img
+		u.pinnedMu.RLock()
+		digest := u.pinned[key]
+		u.pinnedMu.RUnlock()
+		if digest == "" {
+			continue
+		}
+		rel, ok := u.findRelease(key, digest)
+		if !ok {
+			continue
+		}
+		target, err := u.resolveImage(svc, img)
+		if err != nil {
+			continue
+		}
+		if err := u.docker.TagImage(ctx, target.prefix()+":"+rel.Tag, target.prefix(), target.tag); err != nil {
+			logger.Printf("[updater] %s/%s: failed to reapply pin: %v", svc.Name, img, err)
+		}
+	}
+}
+
+// ImageHistory is the release history of one image of a service.
+type ImageHistory struct {
+	Image    string          `json:"image"`
+	Current  string          `json:"current,omitempty"` // digest currently deployed
+	Pinned   string          `json:"pinned,omitempty"`  // digest held by a manual rollback
+	Releases []state.Release `json:"releases"`          // retained digests, newest first
+}
+
+// ServiceHistory is returned by GET /history/<service>.
+type ServiceHistory struct {
+	Service string         `json:"service"`
+	Images  []ImageHistory `json:"images"`
+	Deploys []state.Deploy `json:"deploys"` // deploy records, newest first
+}
+
+// History returns the retained releases and deploy records of svc.
+func (u *Updater) History(svc config.Service) ServiceHistory {
+	snap := u.state.Snapshot()
+	deployed := u.DeployedInfos()
+	pinned := u.PinnedDigests()
+
+	h := ServiceHistory{Service: svc.Name, Images: []ImageHistory{}, Deploys: []state.Deploy{}}
+	for _, img := range svc.Images {
+		key := svc.Name + "/" + img
+		ih := ImageHistory{Image: img, Current: deployed[key].Digest, Pinned: pinned[key], Releases: []state.Release{}}
+		rels := snap.Releases[key]
+		for i := len(rels) - 1; i >= 0; i-- {
+			ih.Releases = append(ih.Releases, rels[i])
+		}
+		h.Images = append(h.Images, ih)
+	}
+	deploys := snap.History[svc.Name]
+	for i := len(deploys) - 1; i >= 0; i-- {
+		h.Deploys = append(h.Deploys, deploys[i])
+	}
+	return h
+}
+
+// RollbackTo redeploys svc with a retained digest. The pinned release tag is
+// retagged to the tracked tag and the project is recreated with compose up
+// (no pull); health verification runs as for any deploy, and on failure the
+// previously running image is restored. On success the image is pinned and
+// skipped by polling until UnpinService is called.
+func (u *Updater) RollbackTo(ctx context.Context, svc config.Service, digest string) error {
+	var img string
+	var rel state.Release
+	for _, candidate := range svc.Images {
+		if r, ok := u.findRelease(svc.Name+"/"+candidate, digest); ok {
+			img, rel = candidate, r
+			break
+		}
+	}
+	if img == "" {
+		return ErrReleaseNotFound
+	}
+	target, err := u.resolveImage(svc, img)
+	if err != nil {
+		return fmt.Errorf("resolve registry for %s: %w", img, err)
+	}
+
+	if !u.tryStartDeploy(svc.Name) {
+		return ErrDeployInProgress
+	}
+
+	oldDigest, _ := u.resolveLocalDigestForImage(ctx, svc, target, img)
+	changed := []imageChange{{
+		Image:     img,
+		Prefix:    target.prefix(),
+		Repo:      target.repo,
+		Tag:       target.tag,
+		OldDigest: oldDigest,
+		NewDigest: digest,
+		Pin:       true,
+	}}
+	u.tagRollbacks(ctx, svc, changed)
+
+	ch := changed[0]
+	pinnedRef := ch.Prefix + ":" + rel.Tag
+	if ch.OldRef != "" && ch.OldRef != ch.Prefix+":"+ch.Tag {
+		if err := u.docker.TagImage(ctx, pinnedRef, imageName(ch.OldRef), imageTag(ch.OldRef)); err != nil {
+			logger.Printf("[updater] %s/%s: retag to compose ref %s failed: %v", svc.Name, img, ch.OldRef, err)
+		}
+	}
+	if err := u.docker.TagImage(ctx, pinnedRef, ch.Prefix, ch.Tag); err != nil {
+		u.clearDeploying(svc.Name)
+		return fmt.Errorf("retag %s: %w", pinnedRef, err)
+	}
+
+	logger.Printf("[updater] %s/%s: rolling back to retained digest %s", svc.Name, img, shortDigest(digest))
+	saferun.Go("rollback-to-"+svc.Name, func() {
+		bg := context.Background()
+		var upOut string
+		var err error
+		if svc.Standalone() {
+			upOut, err = u.engineReplace(bg, svc, ch.Prefix+":"+ch.Tag)
+		} else {
+			upOut, err = compose.Up(bg, u.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile, u.composeEnv(svc, nil)...)
+		}
+		if err != nil {
+			logger.Printf("[updater] %s: rollback compose up failed: %v", svc.Name, err)
+			u.rollback(bg, svc, changed, "compose up failed: "+err.Error(), upOut)
+			u.clearDeploying(svc.Name)
+			return
+		}
+		u.verifyAfterDeploy(bg, svc, changed, upOut) // clears deploying when done
+	})
+	return nil
+}
+
+// PinnedDigests returns a copy of the pinned "service/image" -> digest map.
+func (u *Updater) PinnedDigests() map[string]string {
+	u.pinnedMu.RLock()
+	defer u.pinnedMu.RUnlock()
+	result := make(map[string]string, len(u.pinned))
+	for k, v := range u.pinned {
+		result[k] = v
+	}
+	return result
+}
+
+// UnpinService releases every pinned image of a service so the next poll
+// upgrades it to the registry's current digest. Returns false when nothing
+// was pinned.
+func (u *Updater) UnpinService(service string) bool {
+	prefix := service + "/"
+	found := false
+	u.pinnedMu.Lock()
+	for k := range u.pinned {
+		if strings.HasPrefix(k, prefix) {
+			delete(u.pinned, k)
+			found = true
+		}
+	}
+	u.pinnedMu.Unlock()
+	if found {
+		u.persist()
+	}
+	return found
+}
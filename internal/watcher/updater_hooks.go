@@ -0,0 +1,158 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/studiowebux/dockward/internal/audit"
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/hook"
+	"github.com/studiowebux/dockward/internal/logger"
+	"github.com/studiowebux/dockward/internal/notify"
+)
+
+// maxHookReason bounds the hook output quoted in a rollback reason; the full
+// output goes to the audit Output field.
+const maxHookReason = 500
+
+// Hook events, passed to hooks as DOCKWARD_EVENT.
+const (
+	hookPreDeploy    = "pre_deploy"
+	hookSmokeTest    = "smoke_test"
+	hookPostDeploy   = "post_deploy"
+	hookPostRollback = "post_rollback"
+	hookPreHeal      = "pre_heal"
+)
+
+// hookEnv returns the environment passed to svc's hooks for event: the
+// service, the event and, for deploys and rollbacks, the first changed image
+// with its old and new digests.
+func hookEnv(svc config.Service, event string, changed []imageChange) []string {
+	env := []string{
+		"DOCKWARD_SERVICE=" + svc.Name,
+		"DOCKWARD_EVENT=" + event,
+		"DOCKWARD_PROJECT=" + svc.ComposeProject,
+	}
+	if len(changed) > 0 {
+		env = append(env,
+			"DOCKWARD_IMAGE="+changed[0].Image,
+			"DOCKWARD_OLD_DIGEST="+changed[0].OldDigest,
+			"DOCKWARD_NEW_DIGEST="+changed[0].NewDigest,
+		)
+	}
+	return env
+}
+
+// runHooks runs hooks in order, stopping at the first failure. what names
+// them in logs, output blocks and the failure reason ("smoke test",
+// "pre_deploy hook"). Returns the combined output of the hooks that ran and,
+// when one fails, a reason quoting its output.
+func runHooks(ctx context.Context, runtime string, svc config.Service, what string, hooks []config.Hook, env []string) (output, reason string) {
+	c := hook.Compose{
+		Runtime: runtime,
+		Files:   svc.ComposeFiles,
+		Project: svc.ComposeProject,
+		EnvFile: svc.EnvFile,
+	}
+	for i := range hooks {
+		h := &hooks[i]
+		logger.Printf("[hook] %s: %s %s", svc.Name, what, h.Label())
+		out, err := hook.Run(ctx, h, c, env)
+		output = joinOutput(output, fmt.Sprintf("[%s %s]\n%s", what, h.Label(), out))
+		if err != nil {
+			logger.Printf("[hook] %s: %s %s failed: %v", svc.Name, what, h.Label(), err)
+			return output, hookFailure(what+" "+h.Label(), err, out)
+		}
+	}
+	return output, ""
+}
+
+// updaterHooks runs one of svc's hook lists for a deploy or rollback. The
+// tag policy variable is passed along with the hook environment.
+func (u *Updater) updaterHooks(ctx context.Context, svc config.Service, event, what string, hooks []config.Hook, changed []imageChange) (output, reason string) {
+	if len(hooks) == 0 {
+		return "", ""
+	}
+	env := append(hookEnv(svc, event, changed), u.composeEnv(svc, changed)...)
+	return runHooks(ctx, u.cfg.Runtime, svc, what, hooks, env)
+}
+
+// smokeTest runs svc's smoke tests in order once the deployed containers are
+// healthy. Returns the combined output of the tests that ran and, when one
+// fails, a rollback reason quoting its output.
+func (u *Updater) smokeTest(ctx context.Context, svc config.Service, changed []imageChange) (output, reason string) {
+	return u.updaterHooks(ctx, svc, hookSmokeTest, "smoke test", svc.SmokeTests, changed)
+}
+
+// preDeploy runs svc's pre_deploy hooks and returns their output. On failure
+// it records the aborted deploy in the audit log, notifies, and returns false.
+func (u *Updater) preDeploy(ctx context.Context, svc config.Service, changed []imageChange, composeOut string) (string, bool) {
+	out, reason := u.updaterHooks(ctx, svc, hookPreDeploy, "pre_deploy hook", svc.PreDeploy, changed)
+	if reason == "" {
+		return out, true
+	}
+	logger.Printf("[updater] %s: deploy aborted: %s", svc.Name, reason)
+	u.metrics.IncFailures(svc.Name)
+	u.dispatcher.Send(ctx, notify.Alert{
+		Service:   svc.Name,
+		Event:     "deploy_aborted",
+		Message:   "Deploy aborted by a failing pre_deploy hook.",
+		Reason:    reason,
+		OldDigest: changed[0].OldDigest,
+		NewDigest: changed[0].NewDigest,
+		Level:     notify.LevelCritical,
+	})
+	if err := u.audit.Write(audit.Entry{
+		Service:   svc.Name,
+		Event:     "deploy_aborted",
+		Message:   "Deploy aborted by a failing pre_deploy hook.",
+		Level:     "critical",
+		OldDigest: changed[0].OldDigest,
+		NewDigest: changed[0].NewDigest,
+		Reason:    reason,
+		Output:    joinOutput(composeOut, out),
+	}); err != nil {
+		logger.Printf("[updater] %s: audit write error: %v", svc.Name, err)
+	}
+	return out, false
+}
+
+// afterHooks runs svc's post_deploy or post_rollback hooks. The deploy or
+// rollback is already done, so a failure is only logged and audited.
+func (u *Updater) afterHooks(ctx context.Context, svc config.Service, event string, hooks []config.Hook, changed []imageChange) {
+	out, reason := u.updaterHooks(ctx, svc, event, event+" hook", hooks, changed)
+	if reason == "" {
+		return
+	}
+	if err := u.audit.Write(audit.Entry{
+		Service: svc.Name,
+		Event:   "hook_failed",
+		Message: fmt.Sprintf("%s hook failed.", event),
+		Level:   "warning",
+		Reason:  reason,
+		Output:  out,
+	}); err != nil {
+		logger.Printf("[updater] %s: audit write error: %v", svc.Name, err)
+	}
+}
+
+// hookFailure formats a failed hook as a reason: the first line of its error
+// followed by the tail of its output.
+func hookFailure(what string, err error, out string) string {
+	msg, _, _ := strings.Cut(err.Error(), "\n")
+	reason := fmt.Sprintf("%s failed: %s", what, msg)
+	out = strings.TrimSpace(out)
+	if len(out) > maxHookReason {
+		out = "..." + out[len(out)-maxHookReason:]
+	}
+	if out != "" {
+		reason += ": " + out
+	}
+	return reason
+}
+
+// joinOutput appends command output b to a, one block per line group.
+func joinOutput(a, b string) string {
+	return strings.TrimSpace(a + "\n" + b)
+}
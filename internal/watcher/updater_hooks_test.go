@@ -0,0 +1,101 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/docker"
+	"github.com/studiowebux/dockward/internal/notify"
+	"github.com/studiowebux/dockward/internal/registry"
+	"github.com/studiowebux/dockward/internal/state"
+)
+
+func TestHookFailure(t *testing.T) {
+	err := errors.New("docker compose run: exit status 1\noutput: 2 failed")
+	got := hookFailure("smoke test smoke", err, "checkout: ok\nlogin: FAIL\n")
+	want := "smoke test smoke failed: docker compose run: exit status 1: checkout: ok\nlogin: FAIL"
+	if got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+
+	got = hookFailure("smoke test curl", errors.New("GET http://x/: HTTP 502"), "")
+	if got != "smoke test curl failed: GET http://x/: HTTP 502" {
+		t.Errorf("want reason without output, got %q", got)
+	}
+
+	got = hookFailure("smoke test big", errors.New("exit status 1"), strings.Repeat("x", 2*maxHookReason)+"END")
+	if !strings.HasSuffix(got, "END") || len(got) > maxHookReason+64 {
+		t.Errorf("want truncated output keeping the tail, got %d bytes", len(got))
+	}
+}
+
+func TestHookEnv(t *testing.T) {
+	svc := config.Service{Name: "shop", ComposeProject: "shop"}
+	changed := []imageChange{{Image: "shop:latest", OldDigest: "sha256:old", NewDigest: "sha256:new"}}
+
+	got := strings.Join(hookEnv(svc, hookPreDeploy, changed), " ")
+	want := "DOCKWARD_SERVICE=shop DOCKWARD_EVENT=pre_deploy DOCKWARD_PROJECT=shop DOCKWARD_IMAGE=shop:latest DOCKWARD_OLD_DIGEST=sha256:old DOCKWARD_NEW_DIGEST=sha256:new"
+	if got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+	if env := hookEnv(svc, hookPreHeal, nil); len(env) != 3 {
+		t.Errorf("want no digests without a change, got %v", env)
+	}
+}
+
+func TestPreDeployAbort_BlocksDigestAndRestoresTag(t *testing.T) {
+	reg := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Content-Digest", "sha256:new")
+	}))
+	defer reg.Close()
+	prefix := strings.TrimPrefix(reg.URL, "http://") + "/app"
+
+	engine, dc := newFakeEngine(t)
+	engine.addImage(docker.ImageInspect{ID: "sha256:oldimage", RepoDigests: []string{prefix + "@sha256:old"}}, prefix+":latest")
+	engine.remote[prefix+":latest"] = docker.ImageInspect{ID: "sha256:newimage", RepoDigests: []string{prefix + "@sha256:new"}}
+	engine.addContainer(&fakeContainer{ID: "c1", Name: "app", Image: prefix + ":latest", ImageID: "sha256:oldimage", State: "running"})
+
+	runs := filepath.Join(t.TempDir(), "runs")
+	sent := &countNotifier{}
+	st, _ := state.Open("")
+	cfg := &config.Config{Registry: config.Registry{URL: reg.URL, PollInterval: 300}}
+	u := NewUpdater(cfg, dc, map[string]*registry.Client{
+		config.DefaultRegistryName: registry.NewClient(reg.URL, false, registry.Credentials{}),
+	}, notify.NewDispatcher(sent), NewMetrics(), nil, st)
+	svc := config.Service{
+		Name:          "app",
+		Images:        []string{"app:latest"},
+		ContainerName: "app",
+		PreDeploy:     []config.Hook{{Name: "gate", Command: []string{"sh", "-c", "echo run >> " + runs + "; exit 1"}, Timeout: 10}},
+	}
+
+	for range 2 {
+		if err := u.checkAndUpdate(context.Background(), svc, false); err != nil {
+			t.Fatalf("checkAndUpdate: %v", err)
+		}
+	}
+
+	out, _ := os.ReadFile(runs)
+	if n := strings.Count(string(out), "run"); n != 1 {
+		t.Errorf("want the pre_deploy hook to run once, ran %d times", n)
+	}
+	if sent.n != 1 {
+		t.Errorf("want one deploy_aborted alert, got %d", sent.n)
+	}
+	if got := u.BlockedDigests()["app/app:latest"]; got != "sha256:new" {
+		t.Errorf("want the aborted digest blocked, got %q", got)
+	}
+	if got := engine.ref(prefix + ":latest"); got != "sha256:oldimage" {
+		t.Errorf("want the tag restored to the running image, got %q", got)
+	}
+	if u.IsDeploying("app") {
+		t.Error("an aborted deploy should clear the deploying flag")
+	}
+}
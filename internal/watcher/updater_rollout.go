@@ -0,0 +1,189 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/compose"
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/docker"
+	"github.com/studiowebux/dockward/internal/logger"
+)
+
+// composeServiceLabel names the compose service a container belongs to.
+const composeServiceLabel = "com.docker.compose.service"
+
+// rolloutGroup is a compose service whose replicas run a changed image.
+type rolloutGroup struct {
+	service    string   // compose service name
+	containers []string // IDs of the replicas running the old image
+}
+
+// rolloutGroups returns the compose services of svc whose running containers
+// use one of the changed images. Must run before compose pull: once the tag
+// moves, the container list reports the old image by ID instead of by name.
+func (u *Updater) rolloutGroups(ctx context.Context, svc config.Service, changed []imageChange) []rolloutGroup {
+	containers, err := u.docker.ListContainersByProject(ctx, svc.ComposeProject)
+	if err != nil {
+		logger.Printf("[updater] %s: list containers for rollout: %v", svc.Name, err)
+		return nil
+	}
+	var groups []rolloutGroup
+	index := make(map[string]int)
+	for _, c := range containers {
+		name := c.Labels[composeServiceLabel]
+		if c.State != "running" || name == "" || !usesChangedImage(c, changed) {
+			continue
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, rolloutGroup{service: name})
+		}
+		groups[i].containers = append(groups[i].containers, c.ID)
+	}
+	return groups
+}
+
+// usesChangedImage matches a container to a changed image by name, the same
+// way tagRollbacks does (short and registry-prefixed forms).
+func usesChangedImage(c docker.Container, changed []imageChange) bool {
+	cName := imageName(c.Image)
+	for _, ch := range changed {
+		if cName == ch.Repo || strings.HasSuffix(cName, "/"+ch.Repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// rollingDeploy replaces the replicas of each group a batch at a time: stop and
+// remove the batch, let compose recreate it from the pulled image, then wait
+// for the new replicas to pass their health check within health_grace. Any
+// failure rolls back the whole service, which also restores replicas already
+// updated. Clears the deploying flag when done.
+func (u *Updater) rollingDeploy(ctx context.Context, svc config.Service, changed []imageChange, groups []rolloutGroup, env []string, composeOut string) {
+	defer u.clearDeploying(svc.Name)
+
+	grace := time.Duration(svc.HealthGrace) * time.Second
+	out := composeOut
+	var lastName, lastImage string
+	for _, g := range groups {
+		replicas := len(g.containers)
+		step := svc.Rollout.Batch(replicas)
+		for start := 0; start < replicas; start += step {
+			batch := g.containers[start:min(start+step, replicas)]
+			before, err := u.serviceContainerIDs(ctx, svc.ComposeProject, g.service)
+			if err != nil {
+				u.rollback(ctx, svc, changed, "list containers: "+err.Error(), out)
+				return
+			}
+
+			logger.Printf("[updater] %s: rolling %s replicas %d-%d of %d", svc.Name, g.service, start+1, start+len(batch), replicas)
+			for _, id := range batch {
+				if err := u.docker.StopContainer(ctx, id, 10); err != nil {
+					logger.Printf("[updater] %s: stop %s: %v", svc.Name, shortID(id), err)
+				}
+				if err := u.docker.RemoveContainer(ctx, id, true); err != nil {
+					u.rollback(ctx, svc, changed, fmt.Sprintf("remove %s replica: %v", g.service, err), out)
+					return
+				}
+			}
+
+			upOut, err := compose.Scale(ctx, u.cfg.Runtime, svc.ComposeFiles, svc.ComposeProject, svc.EnvFile, g.service, replicas, env...)
+			out = strings.TrimSpace(out + "\n" + upOut)
+			if err != nil {
+				u.rollback(ctx, svc, changed, fmt.Sprintf("compose up %s: %v", g.service, err), out)
+				return
+			}
+
+			after, err := u.serviceContainerIDs(ctx, svc.ComposeProject, g.service)
+			if err != nil {
+				u.rollback(ctx, svc, changed, "list containers: "+err.Error(), out)
+				return
+			}
+			var fresh []string
+			for id := range after {
+				if !before[id] {
+					fresh = append(fresh, id)
+				}
+			}
+			if len(fresh) == 0 {
+				u.rollback(ctx, svc, changed, fmt.Sprintf("no replacement %s replica was created", g.service), out)
+				return
+			}
+
+			name, image, reason := u.waitReplicas(ctx, svc, fresh, grace)
+			if ctx.Err() != nil {
+				return
+			}
+			if reason != "" {
+				logger.Printf("[updater] %s: %s replica failed, rolling back: %s", svc.Name, g.service, reason)
+				u.rollback(ctx, svc, changed, fmt.Sprintf("%s replica %s", g.service, reason), out)
+				return
+			}
+			lastName, lastImage = name, image
+		}
+	}
+	smokeOut, reason := u.smokeTest(ctx, svc, changed)
+	out = joinOutput(out, smokeOut)
+	if ctx.Err() != nil {
+		return
+	}
+	if reason != "" {
+		u.rollback(ctx, svc, changed, reason, out)
+		return
+	}
+	u.onDeploySuccess(ctx, svc, changed, lastName, lastImage, out)
+}
+
+// serviceContainerIDs returns the IDs of every container of a compose service.
+func (u *Updater) serviceContainerIDs(ctx context.Context, project, service string) (map[string]bool, error) {
+	containers, err := u.docker.ListContainersByProject(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool)
+	for _, c := range containers {
+		if c.Labels[composeServiceLabel] == service {
+			ids[c.ID] = true
+		}
+	}
+	return ids, nil
+}
+
+// waitReplicas polls the given containers until all are healthy (or running,
+// without a healthcheck) and svc's probe passes, one turns unhealthy, or grace
+// expires. Returns the name and image reference of a healthy replica and, on
+// failure, the reason naming the failing replica.
+func (u *Updater) waitReplicas(ctx context.Context, svc config.Service, ids []string, grace time.Duration) (name, image, reason string) {
+	deadline := time.Now().Add(grace)
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", "", "cancelled"
+		case <-ticker.C:
+		}
+
+		report := u.checkHealth(ctx, ids)
+		expired := time.Now().After(deadline)
+		done, reason := verdict(config.VerifyAll, report, expired)
+		if done, reason = u.probeVerdict(ctx, svc, ids, done, reason, expired); done {
+			return report.name, report.image, reason
+		}
+	}
+}
+
+// shortID truncates a container ID to the 12 characters docker ps shows.
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,112 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/docker"
+	"github.com/studiowebux/dockward/internal/notify"
+	"github.com/studiowebux/dockward/internal/state"
+)
+
+func TestUsesChangedImage(t *testing.T) {
+	changed := []imageChange{{Image: "app:latest", Repo: "app"}}
+	tests := []struct {
+		image string
+		want  bool
+	}{
+		{"app:latest", true},
+		{"localhost:5000/app:latest", true},
+		{"registry.example.com/team/app:1.4", true},
+		{"sidecar:latest", false},
+		{"myapp:latest", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := usesChangedImage(docker.Container{Image: tt.image}, changed); got != tt.want {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRollingDeploy(t *testing.T) {
+	tests := []struct {
+		name       string
+		brokenNew  bool
+		wantEvent  string
+		wantReason string
+		wantOld    bool // the second old replica is still running
+	}{
+		{"healthy replicas", false, "updated", "", false},
+		// The old replica passes the probe; only the new one must count.
+		{"probe fails on new replica", true, "rolled_back", "probe: new-1: ", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interval := healthPollInterval
+			healthPollInterval = 10 * time.Millisecond
+			t.Cleanup(func() { healthPollInterval = interval })
+
+			file := fakeComposeCLI(t)
+			engine, dc := newFakeEngine(t)
+			labels := map[string]string{"com.docker.compose.project": "shop", composeServiceLabel: "web"}
+			engine.addImage(docker.ImageInspect{ID: "sha256:oldimage"}, "app:latest", "localhost/app:rollback")
+			for _, id := range []string{"old-1", "old-2"} {
+				engine.addContainer(&fakeContainer{ID: id, Name: "shop-" + id, Image: "app:latest", ImageID: "sha256:oldimage", Labels: labels, State: "running"})
+			}
+			created := 0
+			engine.recreate = func(removed fakeContainer) *fakeContainer {
+				created++
+				id := fmt.Sprintf("new-%d", created)
+				if tt.brokenNew {
+					engine.probeExit[id] = 1
+				}
+				return &fakeContainer{ID: id, Name: "shop-" + id, Image: removed.Image, ImageID: "sha256:newimage", Labels: removed.Labels, State: "running"}
+			}
+
+			st, _ := state.Open("")
+			u := NewUpdater(&config.Config{Runtime: "docker"}, dc, nil, notify.NewDispatcher(), NewMetrics(), nil, st)
+			svc := config.Service{
+				Name:           "shop",
+				Images:         []string{"app:latest"},
+				ComposeProject: "shop",
+				ComposeFiles:   []string{file},
+				HealthGrace:    1,
+				Rollout:        &config.Rollout{Strategy: config.RolloutRolling},
+				Probe:          &config.Probe{Exec: []string{"true"}, Service: "web", Timeout: 5},
+			}
+			changed := []imageChange{{Image: "app:latest", Prefix: "localhost/app", Repo: "app", Tag: "latest", OldDigest: "sha256:old", NewDigest: "sha256:new"}}
+
+			ctx := context.Background()
+			groups := u.rolloutGroups(ctx, svc, changed)
+			if len(groups) != 1 || len(groups[0].containers) != 2 {
+				t.Fatalf("want one group of two replicas, got %+v", groups)
+			}
+			u.tryStartDeploy(svc.Name)
+			u.rollingDeploy(ctx, svc, changed, groups, nil, "")
+
+			history := st.Snapshot().History["shop"]
+			if len(history) == 0 {
+				t.Fatal("want a deploy recorded")
+			}
+			last := history[len(history)-1]
+			if last.Event != tt.wantEvent || !strings.Contains(last.Reason, tt.wantReason) {
+				t.Errorf("want %s (%q), got %s (%q)", tt.wantEvent, tt.wantReason, last.Event, last.Reason)
+			}
+			engine.mu.Lock()
+			oldRunning := engine.find("old-2") != nil
+			engine.mu.Unlock()
+			if oldRunning != tt.wantOld {
+				t.Errorf("second old replica running = %v, want %v", oldRunning, tt.wantOld)
+			}
+			if u.IsDeploying(svc.Name) {
+				t.Error("want the deploying flag cleared")
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/audit"
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/logger"
+	"github.com/studiowebux/dockward/internal/notify"
+	"github.com/studiowebux/dockward/internal/schedule"
+	"github.com/studiowebux/dockward/internal/state"
+)
+
+// Reasons an update is held as pending.
+const (
+	holdWindow   = "window"   // outside the service's deploy windows
+	holdFreeze   = "freeze"   // ad-hoc freeze set via POST /freeze
+	holdApproval = "approval" // require_approval: waiting for POST /approve
+)
+
+// PendingDeploy is an update detected while deploys are held.
+type PendingDeploy struct {
+	Reason  string            `json:"reason"`            // "window", "freeze" or "approval"
+	Since   time.Time         `json:"since"`             // when the update was first held
+	Until   *time.Time        `json:"until,omitempty"`   // next window opening or freeze end; nil for approvals and when no window ever opens
+	Images  map[string]string `json:"images"`            // image entry -> digest waiting to deploy
+	Current map[string]string `json:"current,omitempty"` // image entry -> digest running now
+}
+
+// holdReason reports whether deploys of svc are held at now and until when.
+// A freeze takes precedence over deploy windows.
+func (u *Updater) holdReason(svc config.Service, now time.Time) (string, time.Time) {
+	u.freezeMu.RLock()
+	freeze := u.freeze
+	u.freezeMu.RUnlock()
+	if freeze != nil && now.Before(freeze.Until) {
+		return holdFreeze, freeze.Until
+	}
+
+	windows, err := config.Windows(u.cfg.DeployWindowsFor(svc))
+	if err != nil {
+		// Rejected by config validation; never reached with a loaded config.
+		logger.Printf("[updater] %s: ignoring deploy windows: %v", svc.Name, err)
+		return "", time.Time{}
+	}
+	if !schedule.Open(windows, now) {
+		return holdWindow, schedule.NextOpen(windows, now)
+	}
+	return "", time.Time{}
+}
+
+// heldUntil describes a hold for logs, e.g. "held (window) until 2026-03-07T02:00:00Z".
+func heldUntil(reason string, until time.Time) string {
+	if until.IsZero() {
+		return fmt.Sprintf("held (%s)", reason)
+	}
+	return fmt.Sprintf("held (%s) until %s", reason, until.Format(time.RFC3339))
+}
+
+// holdDeploy records changed as pending for svc. The first hold of a given
+// set of digests is audited and notified; repeated polls only refresh Until.
+func (u *Updater) holdDeploy(ctx context.Context, svc config.Service, changed []imageChange, reason string, until time.Time) {
+	images := make(map[string]string, len(changed))
+	current := make(map[string]string, len(changed))
+	for _, ch := range changed {
+		images[ch.Image] = ch.NewDigest
+		current[ch.Image] = ch.OldDigest
+	}
+	p := PendingDeploy{Reason: reason, Since: time.Now(), Images: images, Current: current}
+	if !until.IsZero() {
+		p.Until = &until
+	}
+
+	u.pendingMu.Lock()
+	prev, existed := u.pending[svc.Name]
+	same := existed && prev.Reason == reason && sameDigests(prev.Images, images)
+	if same {
+		p.Since = prev.Since
+	}
+	u.pending[svc.Name] = p
+	u.pendingMu.Unlock()
+	if same {
+		return
+	}
+
+	event, level := "deploy_deferred", notify.LevelInfo
+	msg := "Update held outside deploy window; no window opens."
+	switch {
+	case reason == holdApproval:
+		event, level = "approval_required", notify.LevelWarning
+		msg = fmt.Sprintf("Update to %s awaiting approval (POST /approve/%s or /reject/%s).", shortDigest(changed[0].NewDigest), svc.Name, svc.Name)
+	case reason == holdFreeze:
+		msg = fmt.Sprintf("Update held by deploy freeze until %s.", until.Format(time.RFC3339))
+	case !until.IsZero():
+		msg = fmt.Sprintf("Update held outside deploy window; deploys at %s.", until.Format(time.RFC3339))
+	}
+	logger.Printf("[updater] %s: %s", svc.Name, msg)
+	u.dispatcher.Send(ctx, notify.Alert{
+		Service:   svc.Name,
+		Event:     event,
+		Message:   msg,
+		OldDigest: changed[0].OldDigest,
+		NewDigest: changed[0].NewDigest,
+		Level:     level,
+	})
+	if err := u.audit.Write(audit.Entry{
+		Service:   svc.Name,
+		Event:     event,
+		Message:   msg,
+		Level:     level,
+		OldDigest: changed[0].OldDigest,
+		NewDigest: changed[0].NewDigest,
+		Reason:    reason,
+	}); err != nil {
+		logger.Printf("[updater] %s: audit write error: %v", svc.Name, err)
+	}
+}
+
+func sameDigests(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// clearPending drops the pending entry and any approval of a service.
+func (u *Updater) clearPending(service string) {
+	u.pendingMu.Lock()
+	delete(u.pending, service)
+	delete(u.approved, service)
+	u.pendingMu.Unlock()
+}
+
+// releasePending re-checks every pending service whose hold has lifted, so
+// held updates deploy as soon as their window opens or the freeze ends.
+func (u *Updater) releasePending(ctx context.Context) {
+	pending := u.PendingDeploys()
+	if len(pending) == 0 {
+		return
+	}
+	now := time.Now()
+	for _, svc := range u.cfg.SnapshotServices() {
+		p, ok := pending[svc.Name]
+		if !ok || !svc.AutoUpdate || p.Reason == holdApproval {
+			continue // approvals are released by POST /approve
+		}
+		if reason, _ := u.holdReason(svc, now); reason != "" {
+			continue
+		}
+		logger.Printf("[updater] %s: deploy hold lifted, deploying pending update", svc.Name)
+		if err := u.checkAndUpdate(ctx, svc, false); err != nil {
+			u.handlePollError(ctx, svc, err)
+		}
+	}
+}
+
+// PendingDeploys returns a copy of the pending service -> update map.
+func (u *Updater) PendingDeploys() map[string]PendingDeploy {
+	u.pendingMu.RLock()
+	defer u.pendingMu.RUnlock()
+	result := make(map[string]PendingDeploy, len(u.pending))
+	for k, v := range u.pending {
+		result[k] = v
+	}
+	return result
+}
+
+// Freeze holds every image deploy until until. Updates detected meanwhile
+// are queued as pending and deploy once the freeze ends.
+func (u *Updater) Freeze(until time.Time, reason string) error {
+	f := &state.Freeze{Until: until.UTC(), Reason: reason}
+	u.freezeMu.Lock()
+	u.freeze = f
+	u.freezeMu.Unlock()
+	return u.state.Update(func(s *state.State) { s.Freeze = f })
+}
+
+// Unfreeze lifts the deploy freeze. Returns false when none was active.
+func (u *Updater) Unfreeze() (bool, error) {
+	u.freezeMu.Lock()
+	active := u.freeze != nil && time.Now().Before(u.freeze.Until)
+	u.freeze = nil
+	u.freezeMu.Unlock()
+	return active, u.state.Update(func(s *state.State) { s.Freeze = nil })
+}
+
+// ActiveFreeze returns the current deploy freeze, or nil when none is active.
+func (u *Updater) ActiveFreeze() *state.Freeze {
+	u.freezeMu.RLock()
+	defer u.freezeMu.RUnlock()
+	if u.freeze == nil || !time.Now().Before(u.freeze.Until) {
+		return nil
+	}
+	f := *u.freeze
+	return &f
+}
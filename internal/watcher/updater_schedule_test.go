@@ -0,0 +1,163 @@
+package watcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/notify"
+	"github.com/studiowebux/dockward/internal/state"
+)
+
+func TestHoldReason(t *testing.T) {
+	cfg := &config.Config{
+		// Global window: Saturday 02:00-04:00.
+		DeployWindows: []config.DeployWindow{{Days: []string{"sat"}, Start: "02:00", End: "04:00"}},
+	}
+	st, _ := state.Open("")
+	u := NewUpdater(cfg, nil, nil, nil, NewMetrics(), nil, st)
+
+	monday := time.Date(2026, 3, 2, 14, 0, 0, 0, time.Local)
+	saturday := time.Date(2026, 3, 7, 3, 0, 0, 0, time.Local)
+	global := config.Service{Name: "web"}
+	always := config.Service{Name: "api", DeployWindows: []config.DeployWindow{{Start: "00:00", End: "00:00"}}}
+
+	if reason, until := u.holdReason(global, monday); reason != holdWindow || !until.Equal(time.Date(2026, 3, 7, 2, 0, 0, 0, time.Local)) {
+		t.Errorf("monday: want window hold until saturday 02:00, got %q %v", reason, until)
+	}
+	if reason, _ := u.holdReason(global, saturday); reason != "" {
+		t.Errorf("saturday: want no hold, got %q", reason)
+	}
+	if reason, _ := u.holdReason(always, monday); reason != "" {
+		t.Errorf("service window overrides global: want no hold, got %q", reason)
+	}
+
+	freezeEnd := saturday.Add(time.Hour)
+	if err := u.Freeze(freezeEnd, "release day"); err != nil {
+		t.Fatal(err)
+	}
+	if reason, until := u.holdReason(always, saturday); reason != holdFreeze || !until.Equal(freezeEnd) {
+		t.Errorf("freeze: want freeze hold until %v, got %q %v", freezeEnd, reason, until)
+	}
+	if got := st.Snapshot().Freeze; got == nil || got.Reason != "release day" {
+		t.Errorf("want freeze persisted, got %+v", got)
+	}
+}
+
+func TestHoldDeploy_AuditsOncePerDigest(t *testing.T) {
+	st, _ := state.Open("")
+	u := NewUpdater(&config.Config{}, nil, nil, notify.NewDispatcher(), NewMetrics(), nil, st)
+	svc := config.Service{Name: "web"}
+	until := time.Now().Add(time.Hour)
+
+	u.holdDeploy(context.Background(), svc, []imageChange{{Image: "api:latest", NewDigest: "sha256:a"}}, holdWindow, until)
+	first := u.PendingDeploys()["web"]
+	u.holdDeploy(context.Background(), svc, []imageChange{{Image: "api:latest", NewDigest: "sha256:a"}}, holdWindow, until)
+	if got := u.PendingDeploys()["web"]; !got.Since.Equal(first.Since) {
+		t.Errorf("same digest: want Since kept, got %v then %v", first.Since, got.Since)
+	}
+
+	u.holdDeploy(context.Background(), svc, []imageChange{{Image: "api:latest", NewDigest: "sha256:b"}}, holdWindow, until)
+	if got := u.PendingDeploys()["web"].Images["api:latest"]; got != "sha256:b" {
+		t.Errorf("new digest: want sha256:b pending, got %q", got)
+	}
+
+	u.clearPending("web")
+	if len(u.PendingDeploys()) != 0 {
+		t.Error("want pending cleared")
+	}
+}
+
+func TestHandleFreeze(t *testing.T) {
+	st, _ := state.Open("")
+	api := testAPI(nil)
+	api.updater = NewUpdater(&config.Config{}, nil, nil, nil, NewMetrics(), nil, st)
+
+	tests := []struct {
+		method, path string
+		want         int
+		body         string
+	}{
+		{http.MethodPost, "/freeze", http.StatusBadRequest, ""},
+		{http.MethodPost, "/freeze?until=tomorrow", http.StatusBadRequest, ""},
+		{http.MethodPost, "/freeze?until=2000-01-01T00:00:00Z", http.StatusBadRequest, ""},
+		{http.MethodPost, "/freeze?duration=-1h", http.StatusBadRequest, ""},
+		{http.MethodPost, "/freeze?duration=2h&reason=launch", http.StatusOK, `"frozen"`},
+		{http.MethodDelete, "/freeze", http.StatusOK, `"unfrozen"`},
+		{http.MethodDelete, "/freeze", http.StatusOK, `"not_frozen"`},
+		{http.MethodGet, "/freeze", http.StatusMethodNotAllowed, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		api.handleFreeze(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s: want %d, got %d", tt.method, tt.path, tt.want, w.Code)
+		}
+		if tt.body != "" && !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s %s: want body containing %s, got %s", tt.method, tt.path, tt.body, w.Body.String())
+		}
+	}
+}
+
+func TestComposeDrift_HeldByFreeze(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "compose.yml")
+	os.WriteFile(file, []byte("services: {}\n"), 0o600)
+	st, _ := state.Open("")
+	u := NewUpdater(&config.Config{Runtime: "dockward-test-missing"}, nil, nil, notify.NewDispatcher(), NewMetrics(), nil, st)
+	svc := config.Service{Name: "web", ComposeProject: "web", ComposeFiles: []string{file}, ComposeWatch: true}
+
+	if err := u.checkComposeDrift(context.Background(), svc); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	if err := u.Freeze(time.Now().Add(time.Hour), ""); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(file, []byte("services: {web: {}}\n"), 0o600)
+	if err := u.checkComposeDrift(context.Background(), svc); err != nil {
+		t.Fatalf("frozen: want the redeploy held, got %v", err)
+	}
+	if u.IsDeploying("web") {
+		t.Error("frozen: want no redeploy started")
+	}
+
+	// Once the freeze lifts the held change is redeployed; compose cannot
+	// run here, so the attempt surfaces as an error.
+	if _, err := u.Unfreeze(); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.checkComposeDrift(context.Background(), svc); err == nil || !strings.Contains(err.Error(), "drift") {
+		t.Errorf("unfrozen: want a redeploy attempt, got %v", err)
+	}
+}
+
+func TestAutoStart_HeldOutsideWindow(t *testing.T) {
+	_, dc := newFakeEngine(t)
+	st, _ := state.Open("")
+	cfg := &config.Config{
+		Runtime:       "dockward-test-missing",
+		DeployWindows: []config.DeployWindow{{Days: []string{"sat"}, Start: "02:00", End: "02:01"}},
+	}
+	u := NewUpdater(cfg, dc, nil, notify.NewDispatcher(), NewMetrics(), nil, st)
+	svc := config.Service{Name: "web", ComposeProject: "web", ComposeFiles: []string{"compose.yml"}, AutoStart: true}
+
+	if reason, _ := u.holdReason(svc, time.Now()); reason == "" {
+		t.Skip("running inside the deploy window")
+	}
+	if err := u.checkAndUpdate(context.Background(), svc, false); err != nil {
+		t.Fatalf("want auto_start held, got %v", err)
+	}
+	if u.IsDeploying("web") || u.startAttempted["web"] != "" {
+		t.Error("want no start attempted outside the deploy window")
+	}
+
+	cfg.DeployWindows = nil
+	if err := u.checkAndUpdate(context.Background(), svc, false); err == nil {
+		t.Error("inside the window: want a start attempt")
+	}
+}
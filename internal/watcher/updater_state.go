@@ -0,0 +1,86 @@
+package watcher
+
+import (
+	"strings"
+
+	"github.com/studiowebux/dockward/internal/logger"
+	"github.com/studiowebux/dockward/internal/state"
+)
+
+// restoreState seeds the in-memory maps from the persisted state.
+// Called once from NewUpdater before any goroutine touches the maps.
+func (u *Updater) restoreState() {
+	snap := u.state.Snapshot()
+	for k, v := range snap.Blocked {
+		u.blocked[k] = v
+		if svc, _, ok := strings.Cut(k, "/"); ok && u.metrics != nil {
+			u.metrics.SetBlocked(svc, true)
+		}
+	}
+	for k, v := range snap.NotFound {
+		u.notFound[k] = v
+	}
+	for k, v := range snap.Pinned {
+		u.pinned[k] = v
+	}
+	u.freeze = snap.Freeze
+	for k, v := range snap.StartAttempted {
+		u.startAttempted[k] = v
+	}
+	for k, v := range snap.ComposeHashes {
+		u.composeHashes[k] = v
+	}
+	if n := len(snap.Blocked); n > 0 {
+		logger.Printf("[updater] restored %d blocked digest(s) from state", n)
+	}
+}
+
+// persist writes the durable subset of updater state (blocked, pinned,
+// notFound, startAttempted, composeHashes) to the state store. Call after any change
+// to those maps; errors are logged, the in-memory state stays authoritative.
+// The maps are copied under the store lock so concurrent calls write in the
+// order they read: a persist never overwrites a newer copy with an older one.
+func (u *Updater) persist() {
+	if err := u.state.Update(func(s *state.State) {
+		s.Blocked = u.BlockedDigests()
+		s.Pinned = u.PinnedDigests()
+		s.NotFound = u.NotFoundServices()
+
+		u.startAttemptedMu.RLock()
+		s.StartAttempted = make(map[string]string, len(u.startAttempted))
+		for k, v := range u.startAttempted {
+			s.StartAttempted[k] = v
+		}
+		u.startAttemptedMu.RUnlock()
+
+		u.composeHashesMu.Lock()
+		s.ComposeHashes = make(map[string]string, len(u.composeHashes))
+		for k, v := range u.composeHashes {
+			s.ComposeHashes[k] = v
+		}
+		u.composeHashesMu.Unlock()
+	}); err != nil {
+		logger.Printf("[updater] ERROR: state write error: %v", err)
+	}
+}
+
+// recordHistory appends one deploy record per changed image to the
+// service's persisted deploy history.
+func (u *Updater) recordHistory(service, event, reason string, changed []imageChange) {
+	for _, ch := range changed {
+		if err := u.state.AppendHistory(service, state.Deploy{
+			Image:     ch.Image,
+			Event:     event,
+			OldDigest: ch.OldDigest,
+			NewDigest: ch.NewDigest,
+			Reason:    reason,
+		}); err != nil {
+			logger.Printf("[updater] %s: ERROR: state write error: %v", service, err)
+		}
+	}
+}
+
+// State returns a snapshot of the persisted agent state.
+func (u *Updater) State() state.State {
+	return u.state.Snapshot()
+}
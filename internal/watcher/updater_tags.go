@@ -0,0 +1,63 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/logger"
+	"github.com/studiowebux/dockward/internal/state"
+	"github.com/studiowebux/dockward/internal/tagpolicy"
+)
+
+// currentTag returns the tag a tag policy last deployed for key
+// ("service/image"), or fallback (the tag written in config) before the
+// first policy deploy.
+func (u *Updater) currentTag(key, fallback string) string {
+	if tag := u.state.Snapshot().Tags[key]; tag != "" {
+		return tag
+	}
+	return fallback
+}
+
+// setCurrentTag persists the tag selected by a tag policy after a successful deploy.
+func (u *Updater) setCurrentTag(key, tag string) {
+	if err := u.state.Update(func(s *state.State) { s.Tags[key] = tag }); err != nil {
+		logger.Printf("[updater] ERROR: state write error: %v", err)
+	}
+}
+
+// selectTag lists the repository's tags and returns the highest one
+// accepted by the service's tag policy.
+func (u *Updater) selectTag(ctx context.Context, svc config.Service, target imageTarget) (string, error) {
+	policy, err := tagpolicy.Parse(svc.TagPolicy.Semver, svc.TagPolicy.Pattern)
+	if err != nil {
+		return "", fmt.Errorf("tag_policy: %w", err)
+	}
+	tags, err := target.client.Tags(ctx, target.repo)
+	if err != nil {
+		return "", fmt.Errorf("list tags %s: %w", target.repo, err)
+	}
+	tag, ok := policy.Select(tags)
+	if !ok {
+		return "", fmt.Errorf("no tag of %s matches tag_policy", target.repo)
+	}
+	return tag, nil
+}
+
+// composeEnv returns the extra environment for compose commands of svc:
+// the tag policy variable set to the current tag, or to the new tag of a
+// pending change in changed. Nil when svc has no tag policy.
+func (u *Updater) composeEnv(svc config.Service, changed []imageChange) []string {
+	if svc.TagPolicy == nil || len(svc.Images) == 0 {
+		return nil
+	}
+	img := svc.Images[0]
+	for _, ch := range changed {
+		if ch.Image == img && ch.NewTag != "" {
+			return []string{svc.TagPolicy.Env + "=" + ch.NewTag}
+		}
+	}
+	_, rest := config.SplitImageHost(img)
+	return []string{svc.TagPolicy.Env + "=" + u.currentTag(svc.Name+"/"+img, imageTag(rest))}
+}
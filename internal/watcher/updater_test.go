@@ -1,9 +1,18 @@
 package watcher
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/registry"
+	"github.com/studiowebux/dockward/internal/state"
 )
 
 func TestTryStartDeploy_GuardsConcurrentDeploys(t *testing.T) {
@@ -149,3 +158,134 @@ func TestBlockedDigests_MultiImage(t *testing.T) {
 		t.Error("all blocked entries should be cleared after UnblockService")
 	}
 }
+
+func TestResolveImage_PerRegistryHost(t *testing.T) {
+	cfg := &config.Config{
+		Registry: config.Registry{URL: "http://localhost:5000", PollInterval: 300},
+		Registries: []config.Registry{
+			{Name: "ghcr", URL: "https://ghcr.io", PollInterval: 600},
+			{Name: "internal", URL: "https://registry.corp:8443", PollInterval: 60},
+		},
+	}
+	u := &Updater{
+		cfg: cfg,
+		registries: map[string]*registry.Client{
+			"default":  registry.NewClient("http://localhost:5000", false, registry.Credentials{}),
+			"ghcr":     registry.NewClient("https://ghcr.io", false, registry.Credentials{}),
+			"internal": registry.NewClient("https://registry.corp:8443", false, registry.Credentials{}),
+		},
+	}
+
+	tests := []struct {
+		name       string
+		svc        config.Service
+		img        string
+		wantReg    string
+		wantPrefix string
+		wantRemote string
+	}{
+		{"short ref uses default", config.Service{}, "api:latest", "default", "localhost:5000/api", "api:latest"},
+		{"short ref uses service registry", config.Service{Registry: "internal"}, "team/api:v2", "internal", "registry.corp:8443/team/api", "team/api:v2"},
+		{"fully-qualified ref inferred by host", config.Service{Registry: "internal"}, "ghcr.io/org/app:1.4", "ghcr", "ghcr.io/org/app", "org/app:1.4"},
+		{"fully-qualified ref with port, no tag", config.Service{}, "localhost:5000/worker", "default", "localhost:5000/worker", "worker:latest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.resolveImage(tt.svc, tt.img)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.registry != tt.wantReg {
+				t.Errorf("registry: want %q, got %q", tt.wantReg, got.registry)
+			}
+			if got.prefix() != tt.wantPrefix {
+				t.Errorf("prefix: want %q, got %q", tt.wantPrefix, got.prefix())
+			}
+			if got.remoteRef() != tt.wantRemote {
+				t.Errorf("remote ref: want %q, got %q", tt.wantRemote, got.remoteRef())
+			}
+		})
+	}
+
+	if _, err := u.resolveImage(config.Service{}, "quay.io/org/app:1"); err == nil {
+		t.Error("want error for unconfigured registry host, got nil")
+	}
+
+	svc := config.Service{Images: []string{"api:latest", "registry.corp:8443/team/api:v2"}}
+	if got := u.pollInterval(svc); got != 60*time.Second {
+		t.Errorf("pollInterval: want 1m0s (shortest registry), got %s", got)
+	}
+}
+
+func TestUpdater_RestoresAndPersistsBlocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	st, err := state.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = st.Update(func(s *state.State) { s.Blocked["web/api:latest"] = "sha256:bad" })
+
+	u := NewUpdater(&config.Config{}, nil, nil, nil, NewMetrics(), nil, st)
+	if got := u.BlockedDigests()["web/api:latest"]; got != "sha256:bad" {
+		t.Fatalf("want restored blocked digest, got %q", got)
+	}
+
+	u.UnblockService("web")
+
+	reopened, err := state.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.Snapshot().Blocked; len(got) != 0 {
+		t.Errorf("want unblock persisted, got %v", got)
+	}
+}
+
+func TestTagPolicy_SelectAndComposeEnv(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"tags": []string{"1.3.0", "1.4.0", "1.4.2", "1.5.0", "latest"}})
+	}))
+	defer srv.Close()
+
+	st, _ := state.Open("")
+	cfg := &config.Config{Registry: config.Registry{URL: srv.URL, PollInterval: 300}}
+	u := NewUpdater(cfg, nil, map[string]*registry.Client{
+		config.DefaultRegistryName: registry.NewClient(srv.URL, false, registry.Credentials{}),
+	}, nil, NewMetrics(), nil, st)
+	svc := config.Service{
+		Name:      "web",
+		Images:    []string{"app:1.4.0"},
+		TagPolicy: &config.TagPolicy{Semver: "~1.4", Env: "APP_TAG"},
+	}
+
+	target, err := u.resolveImage(svc, "app:1.4.0")
+	if err != nil {
+		t.Fatalf("resolveImage: %v", err)
+	}
+	if target.tag != "1.4.0" {
+		t.Errorf("before first policy deploy: want configured tag 1.4.0, got %q", target.tag)
+	}
+	selected, err := u.selectTag(context.Background(), svc, target)
+	if err != nil {
+		t.Fatalf("selectTag: %v", err)
+	}
+	if selected != "1.4.2" {
+		t.Errorf("selected: want 1.4.2, got %q", selected)
+	}
+
+	changed := []imageChange{{Image: "app:1.4.0", Tag: "1.4.0", NewTag: selected}}
+	if got := u.composeEnv(svc, changed); len(got) != 1 || got[0] != "APP_TAG=1.4.2" {
+		t.Errorf("deploy env: want [APP_TAG=1.4.2], got %v", got)
+	}
+	if got := u.composeEnv(svc, nil); len(got) != 1 || got[0] != "APP_TAG=1.4.0" {
+		t.Errorf("rollback env: want [APP_TAG=1.4.0], got %v", got)
+	}
+
+	u.setCurrentTag("web/app:1.4.0", selected)
+	if target, _ = u.resolveImage(svc, "app:1.4.0"); target.tag != "1.4.2" {
+		t.Errorf("after policy deploy: want tag 1.4.2, got %q", target.tag)
+	}
+	if got := u.composeEnv(config.Service{Name: "plain", Images: []string{"app"}}, nil); got != nil {
+		t.Errorf("no tag policy: want nil env, got %v", got)
+	}
+}
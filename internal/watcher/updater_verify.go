@@ -0,0 +1,203 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/docker"
+)
+
+// healthPollInterval is how often deploy verification inspects containers.
+var healthPollInterval = 5 * time.Second
+
+// healthReport is the health of a set of containers at one poll.
+type healthReport struct {
+	total   int
+	healthy int
+	failed  int    // unhealthy by healthcheck: will not recover within the grace period
+	problem string // "<container>: <reason>" of the first failed (else not yet healthy) container
+	name    string // name of the first healthy container, for notifications
+	image   string // its image reference
+}
+
+// verifyTargets returns the IDs of the containers post-deploy verification
+// covers: every container of verify_services when set, else every project
+// container under "all" and "any", else (the "changed" default) the
+// containers running one of the changed images. With no changed images
+// (drift, auto-start) "changed" covers the whole project.
+func (u *Updater) verifyTargets(ctx context.Context, svc config.Service, changed []imageChange) ([]string, error) {
+	containers, err := u.serviceContainers(ctx, svc)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, c := range containers {
+		switch {
+		case len(svc.VerifyServices) > 0:
+			if !slices.Contains(svc.VerifyServices, c.Labels[composeServiceLabel]) {
+				continue
+			}
+		case svc.Verify == config.VerifyChanged && len(changed) > 0:
+			if !usesChangedImage(c, changed) {
+				continue
+			}
+		}
+		ids = append(ids, c.ID)
+	}
+	return ids, nil
+}
+
+// checkHealth inspects each container once. The report's problem names the
+// first unhealthy container, else the first one not healthy yet.
+func (u *Updater) checkHealth(ctx context.Context, ids []string) healthReport {
+	r := healthReport{total: len(ids)}
+	var waiting string
+	for _, id := range ids {
+		info, err := u.docker.InspectContainer(ctx, id)
+		if err != nil {
+			if waiting == "" {
+				waiting = fmt.Sprintf("%s: inspect failed: %v", shortID(id), err)
+			}
+			continue
+		}
+		healthy, failed, reason := containerVerdict(info)
+		switch {
+		case healthy:
+			r.healthy++
+			if r.name == "" {
+				r.name, r.image = info.ContainerName(), info.Config.Image
+			}
+		case failed:
+			r.failed++
+			if r.problem == "" {
+				r.problem = info.ContainerName() + ": " + reason
+			}
+		case waiting == "":
+			waiting = info.ContainerName() + ": " + reason
+		}
+	}
+	if r.problem == "" {
+		r.problem = waiting
+	}
+	return r
+}
+
+// containerVerdict classifies one container. A container without a
+// healthcheck is healthy once running; one with a healthcheck needs Docker's
+// "healthy". Only "unhealthy" is a definite failure; anything else may still
+// recover within the grace period, and reason says why it is not healthy yet.
+func containerVerdict(info *docker.ContainerInspect) (healthy, failed bool, reason string) {
+	if info.State.Health == nil {
+		if info.State.Running {
+			return true, false, ""
+		}
+		if info.State.Status == "exited" {
+			return false, false, fmt.Sprintf("exited with code %d", info.State.ExitCode)
+		}
+		return false, false, "container not running (" + info.State.Status + ")"
+	}
+	switch info.State.Health.Status {
+	case "healthy":
+		return true, false, ""
+	case "unhealthy":
+		return false, true, healthReason(info, "unhealthy")
+	default:
+		return false, false, healthReason(info, "still "+info.State.Health.Status+" after health_grace")
+	}
+}
+
+// healthReason returns the last healthcheck output, or fallback when empty.
+func healthReason(info *docker.ContainerInspect, fallback string) string {
+	if out := strings.TrimSpace(info.LastHealthOutput()); out != "" {
+		return out
+	}
+	return fallback
+}
+
+// verdict applies the service's verify policy to a report: done is true once
+// the outcome is known, with reason empty on success. expired forces an
+// outcome when the grace period is over.
+func verdict(policy string, r healthReport, expired bool) (done bool, reason string) {
+	if r.problem == "" {
+		r.problem = "not healthy after health_grace"
+	}
+	if r.total == 0 {
+		if expired {
+			return true, "no container to verify found after deploy"
+		}
+		return false, ""
+	}
+	if policy == config.VerifyAny {
+		switch {
+		case r.healthy > 0:
+			return true, ""
+		case r.failed == r.total || expired:
+			return true, r.problem
+		}
+		return false, ""
+	}
+	switch {
+	case r.failed > 0:
+		return true, r.problem
+	case r.healthy == r.total:
+		return true, ""
+	case expired:
+		return true, r.problem
+	}
+	return false, ""
+}
+
+// probeVerdict gates a successful container verdict on svc's probe, if any.
+// A failing probe keeps verification waiting until the grace period expires,
+// then fails it with the probe error.
+func (u *Updater) probeVerdict(ctx context.Context, svc config.Service, ids []string, done bool, reason string, expired bool) (bool, string) {
+	if !done || reason != "" || svc.Probe == nil {
+		return done, reason
+	}
+	var err error
+	for _, id := range u.probeTargets(ctx, svc, ids) {
+		if err = runProbe(ctx, u.docker, svc, id); err != nil {
+			if id != "" {
+				err = fmt.Errorf("%s: %w", shortID(id), err)
+			}
+			break
+		}
+	}
+	switch {
+	case err == nil:
+		return true, ""
+	case expired:
+		return true, "probe: " + err.Error()
+	default:
+		return false, ""
+	}
+}
+
+// probeTargets returns the containers a verification probe runs in. An exec
+// probe runs in each verified container of probe.service (each of ids when
+// unset), so the new containers are probed rather than an old replica, and
+// falls back to probeTarget when none of ids belongs to probe.service. HTTP
+// and TCP probes do not run in a container and run once.
+func (u *Updater) probeTargets(ctx context.Context, svc config.Service, ids []string) []string {
+	if len(svc.Probe.Exec) == 0 {
+		return []string{""}
+	}
+	var targets []string
+	for _, id := range ids {
+		if svc.Probe.Service != "" {
+			info, err := u.docker.InspectContainer(ctx, id)
+			if err != nil || info.Config.Labels[composeServiceLabel] != svc.Probe.Service {
+				continue
+			}
+		}
+		targets = append(targets, id)
+	}
+	if len(targets) == 0 {
+		return []string{probeTarget(ctx, u.docker, svc)}
+	}
+	return targets
+}
@@ -0,0 +1,65 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/studiowebux/dockward/internal/config"
+	"github.com/studiowebux/dockward/internal/docker"
+)
+
+func TestContainerVerdict(t *testing.T) {
+	tests := []struct {
+		name       string
+		state      docker.ContainerState
+		healthy    bool
+		failed     bool
+		wantReason string
+	}{
+		{"running without healthcheck", docker.ContainerState{Running: true}, true, false, ""},
+		{"exited", docker.ContainerState{Status: "exited", ExitCode: 137}, false, false, "exited with code 137"},
+		{"healthy", docker.ContainerState{Running: true, Health: &docker.HealthState{Status: "healthy"}}, true, false, ""},
+		{"unhealthy with output", docker.ContainerState{Running: true, Health: &docker.HealthState{
+			Status: "unhealthy", Log: []docker.HealthLog{{Output: "connection refused\n"}},
+		}}, false, true, "connection refused"},
+		{"unhealthy without output", docker.ContainerState{Running: true, Health: &docker.HealthState{Status: "unhealthy"}}, false, true, "unhealthy"},
+		{"starting", docker.ContainerState{Running: true, Health: &docker.HealthState{Status: "starting"}}, false, false, "still starting after health_grace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			healthy, failed, reason := containerVerdict(&docker.ContainerInspect{State: tt.state})
+			if healthy != tt.healthy || failed != tt.failed || reason != tt.wantReason {
+				t.Errorf("want (%v, %v, %q), got (%v, %v, %q)", tt.healthy, tt.failed, tt.wantReason, healthy, failed, reason)
+			}
+		})
+	}
+}
+
+func TestVerdict(t *testing.T) {
+	const bad = "myapp-worker-1: unhealthy"
+	tests := []struct {
+		name       string
+		policy     string
+		report     healthReport
+		expired    bool
+		wantDone   bool
+		wantReason string
+	}{
+		{"no containers yet", config.VerifyChanged, healthReport{}, false, false, ""},
+		{"no containers after grace", config.VerifyChanged, healthReport{}, true, true, "no container to verify found after deploy"},
+		{"all healthy", config.VerifyAll, healthReport{total: 2, healthy: 2}, false, true, ""},
+		{"one still starting", config.VerifyAll, healthReport{total: 2, healthy: 1, problem: "myapp-worker-1: starting"}, false, false, ""},
+		{"one unhealthy fails at once", config.VerifyAll, healthReport{total: 2, healthy: 1, failed: 1, problem: bad}, false, true, bad},
+		{"starting after grace fails", config.VerifyChanged, healthReport{total: 2, healthy: 1, problem: "myapp-worker-1: starting"}, true, true, "myapp-worker-1: starting"},
+		{"any: one healthy is enough", config.VerifyAny, healthReport{total: 2, healthy: 1, failed: 1, problem: bad}, false, true, ""},
+		{"any: waits while one may recover", config.VerifyAny, healthReport{total: 2, failed: 1, problem: bad}, false, false, ""},
+		{"any: all unhealthy", config.VerifyAny, healthReport{total: 2, failed: 2, problem: bad}, false, true, bad},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done, reason := verdict(tt.policy, tt.report, tt.expired)
+			if done != tt.wantDone || reason != tt.wantReason {
+				t.Errorf("want (%v, %q), got (%v, %q)", tt.wantDone, tt.wantReason, done, reason)
+			}
+		})
+	}
+}
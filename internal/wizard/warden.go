@@ -40,6 +40,7 @@ func RunWarden(path string) error {
 	if err := editWardenAPI(s, &cfg.API); err != nil {
 		return err
 	}
+	editWardenEvents(s, &cfg.Events)
 	if err := editAgents(s, &cfg); err != nil {
 		return err
 	}
@@ -58,6 +59,12 @@ func editWardenAPI(s *bufio.Scanner, a *warden.WardenAPI) error {
 	return nil
 }
 
+func editWardenEvents(s *bufio.Scanner, e *warden.EventsConfig) {
+	fmt.Println("[Events]")
+	e.Dir = prompt(s, fmt.Sprintf("  Event store directory (persistent, queryable history, leave empty for the ring buffer only) [%s]: ", e.Dir), e.Dir)
+	fmt.Println()
+}
+
 func editAgents(s *bufio.Scanner, cfg *warden.WardenConfig) error {
 	for {
 		fmt.Printf("[Agents] (%d configured)\n", len(cfg.Agents))
@@ -127,6 +134,7 @@ func editAgent(s *bufio.Scanner, a *warden.AgentConfig) {
 	a.ID = prompt(s, fmt.Sprintf("  ID (display name) [%s]: ", a.ID), a.ID)
 	a.URL = prompt(s, fmt.Sprintf("  URL (agent base URL for heartbeat, e.g. http://host:9090) [%s]: ", a.URL), a.URL)
 	a.Token = prompt(s, fmt.Sprintf("  Token (must match agent push.token, $ENV_VAR supported) [%s]: ", a.Token), a.Token)
+	a.APIToken = prompt(s, fmt.Sprintf("  API token (agent operator token for proxied actions, empty = none) [%s]: ", a.APIToken), a.APIToken)
 	fmt.Println()
 }
 